@@ -38,6 +38,7 @@ const (
 	hashOptionName        = "hash"
 	inlineOptionName      = "inline"
 	inlineLimitOptionName = "inline-limit"
+	addStatsOptionName    = "stats"
 )
 
 const adderOutChanSize = 8
@@ -118,6 +119,7 @@ You can now check what blocks have been created by:
 		cmdkit.StringOption(hashOptionName, "Hash function to use. Implies CIDv1 if not sha2-256. (experimental)").WithDefault("sha2-256"),
 		cmdkit.BoolOption(inlineOptionName, "Inline small blocks into CIDs. (experimental)"),
 		cmdkit.IntOption(inlineLimitOptionName, "Maximum block size to inline. (experimental)").WithDefault(32),
+		cmdkit.BoolOption(addStatsOptionName, "Skip writing blocks already in the blockstore and report reused vs written counts."),
 	},
 	PreRun: func(req *cmds.Request, env cmds.Environment) error {
 		quiet, _ := req.Options[quietOptionName].(bool)
@@ -160,6 +162,7 @@ You can now check what blocks have been created by:
 		inline, _ := req.Options[inlineOptionName].(bool)
 		inlineLimit, _ := req.Options[inlineLimitOptionName].(int)
 		pathName, _ := req.Options[stdinPathName].(string)
+		withStats, _ := req.Options[addStatsOptionName].(bool)
 
 		hashFunCode, ok := mh.Names[strings.ToLower(hashFunStr)]
 		if !ok {
@@ -202,11 +205,17 @@ You can now check what blocks have been created by:
 			opts = append(opts, options.Unixfs.Layout(options.TrickleLayout))
 		}
 
+		var dedupStats *options.AddDedupStats
+		if withStats {
+			dedupStats = new(options.AddDedupStats)
+			opts = append(opts, options.Unixfs.Dedup(dedupStats))
+		}
+
 		errCh := make(chan error)
 		go func() {
 			var err error
 			defer func() { errCh <- err }()
-			defer close(events)
+			// the events channel is closed by the API when the add is done
 			_, err = api.Unixfs().Add(req.Context, req.Files, opts...)
 		}()
 
@@ -215,7 +224,16 @@ You can now check what blocks have been created by:
 			return err
 		}
 
-		return <-errCh
+		if err := <-errCh; err != nil {
+			return err
+		}
+
+		if dedupStats != nil {
+			// the counters are only complete once the add has finished
+			return res.Emit(&coreiface.AddEvent{Dedup: dedupStats})
+		}
+
+		return nil
 	},
 	PostRun: cmds.PostRunMap{
 		cmds.CLI: func(res cmds.Response, re cmds.ResponseEmitter) error {
@@ -270,6 +288,11 @@ You can now check what blocks have been created by:
 							break LOOP
 						}
 						output := out.(*coreiface.AddEvent)
+						if output.Dedup != nil {
+							fmt.Fprintf(os.Stderr, "blocks reused: %d, written: %d\n",
+								output.Dedup.Reused, output.Dedup.Written)
+							continue
+						}
 						if len(output.Hash) > 0 {
 							lastHash = output.Hash
 							if quieter {
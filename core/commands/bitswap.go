@@ -3,6 +3,8 @@ package commands
 import (
 	"fmt"
 	"io"
+	"sort"
+	"time"
 
 	cmdenv "github.com/ipsn/go-ipfs/core/commands/cmdenv"
 	e "github.com/ipsn/go-ipfs/core/commands/e"
@@ -11,6 +13,7 @@ import (
 	bitswap "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap"
 	decision "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/decision"
 	peer "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peer"
+	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
 	cmds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmds"
 	cidutil "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cidutil"
 	cmdkit "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmdkit"
@@ -31,19 +34,38 @@ var BitswapCmd = &cmds.Command{
 }
 
 const (
-	peerOptionName = "peer"
+	peerOptionName           = "peer"
+	bitswapHistoryOptionName = "history"
+	bitswapWithAgeOptionName = "with-age"
 )
 
+type wantlistEntry struct {
+	Key cid.Cid
+
+	// Age is how long the want has been outstanding, only populated with
+	// --with-age
+	Age time.Duration `json:",omitempty"`
+}
+
+type wantlistOutput struct {
+	Keys []cid.Cid
+
+	// Entries carries the per-key details requested with --with-age
+	Entries []wantlistEntry `json:",omitempty"`
+}
+
 var showWantlistCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Show blocks currently on the wantlist.",
 		ShortDescription: `
-Print out all blocks currently on the bitswap wantlist for the local peer.`,
+Print out all blocks currently on the bitswap wantlist for the local peer.
+With '--with-age' each entry also shows how long it has been outstanding.`,
 	},
 	Options: []cmdkit.Option{
 		cmdkit.StringOption(peerOptionName, "p", "Specify which peer to show wantlist for. Default: self."),
+		cmdkit.BoolOption(bitswapWithAgeOptionName, "Also show how long each entry has been on the wantlist."),
 	},
-	Type: KeyList{},
+	Type: wantlistOutput{},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		nd, err := cmdenv.GetNode(env)
 		if err != nil {
@@ -59,6 +81,8 @@ Print out all blocks currently on the bitswap wantlist for the local peer.`,
 			return e.TypeErr(bs, nd.Exchange)
 		}
 
+		withAge, _ := req.Options[bitswapWithAgeOptionName].(bool)
+
 		pstr, found := req.Options[peerOptionName].(string)
 		if found {
 			pid, err := peer.IDB58Decode(pstr)
@@ -66,14 +90,46 @@ Print out all blocks currently on the bitswap wantlist for the local peer.`,
 				return err
 			}
 			if pid != nd.Identity {
-				return cmds.EmitOnce(res, &KeyList{bs.WantlistForPeer(pid)})
+				if withAge {
+					return fmt.Errorf("ages are only tracked for the local wantlist")
+				}
+				return cmds.EmitOnce(res, &wantlistOutput{Keys: bs.WantlistForPeer(pid)})
 			}
 		}
 
-		return cmds.EmitOnce(res, &KeyList{bs.GetWantlist()})
+		if !withAge {
+			return cmds.EmitOnce(res, &wantlistOutput{Keys: bs.GetWantlist()})
+		}
+
+		now := time.Now()
+		entries := bs.WantlistEntries()
+		out := &wantlistOutput{
+			Keys:    make([]cid.Cid, 0, len(entries)),
+			Entries: make([]wantlistEntry, 0, len(entries)),
+		}
+		for _, e := range entries {
+			out.Keys = append(out.Keys, e.Cid)
+			out.Entries = append(out.Entries, wantlistEntry{
+				Key: e.Cid,
+				Age: now.Sub(e.Created),
+			})
+		}
+		sort.Slice(out.Entries, func(i, j int) bool {
+			return out.Entries[i].Age > out.Entries[j].Age
+		})
+
+		return cmds.EmitOnce(res, out)
 	},
 	Encoders: cmds.EncoderMap{
-		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *KeyList) error {
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *wantlistOutput) error {
+			if len(out.Entries) > 0 {
+				// oldest wants first, those are the likely leaks
+				for _, e := range out.Entries {
+					fmt.Fprintf(w, "%s\t%s\n", e.Key, e.Age.Round(time.Second))
+				}
+				return nil
+			}
+
 			// sort the keys first
 			cidutil.Sort(out.Keys)
 			for _, key := range out.Keys {
@@ -90,6 +146,9 @@ var bitswapStatCmd = &cmds.Command{
 		Tagline:          "Show some diagnostic information on the bitswap agent.",
 		ShortDescription: ``,
 	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption(bitswapHistoryOptionName, "Also return the periodic counter snapshots covering the given span, e.g. '10m'."),
+	},
 	Type: bitswap.Stat{},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		nd, err := cmdenv.GetNode(env)
@@ -111,12 +170,23 @@ var bitswapStatCmd = &cmds.Command{
 			return err
 		}
 
+		if span, found := req.Options[bitswapHistoryOptionName].(string); found {
+			d, err := time.ParseDuration(span)
+			if err != nil {
+				return fmt.Errorf("invalid history span %q: %s", span, err)
+			}
+			st.History = bs.StatHistory(d)
+		}
+
 		return cmds.EmitOnce(res, st)
 	},
 	Encoders: cmds.EncoderMap{
 		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, s *bitswap.Stat) error {
 			fmt.Fprintln(w, "bitswap status")
 			fmt.Fprintf(w, "\tprovides buffer: %d / %d\n", s.ProvideBufLen, bitswap.HasBlockBufferSize)
+			fmt.Fprintf(w, "\tprovides backlog: %d / %d\n", s.ProvideBacklog, bitswap.ProvideBacklogMax)
+			fmt.Fprintf(w, "\tprovides dropped: %d\n", s.ProvidesDropped)
+			fmt.Fprintf(w, "\tprovider dials skipped: %d\n", s.ProviderDialsSkipped)
 			fmt.Fprintf(w, "\tblocks received: %d\n", s.BlocksReceived)
 			fmt.Fprintf(w, "\tblocks sent: %d\n", s.BlocksSent)
 			fmt.Fprintf(w, "\tdata received: %d\n", s.DataReceived)
@@ -131,6 +201,16 @@ var bitswapStatCmd = &cmds.Command{
 			for _, p := range s.Peers {
 				fmt.Fprintf(w, "\t\t%s\n", p)
 			}
+			if len(s.History) > 0 {
+				fmt.Fprintf(w, "\thistory [%d samples]\n", len(s.History))
+				for _, h := range s.History {
+					fmt.Fprintf(w, "\t\t%s: sent %d blocks / %s, received %d blocks / %s, wantlist %d, peers %d\n",
+						h.Timestamp.Format(time.RFC3339),
+						h.BlocksSent, humanize.Bytes(h.DataSent),
+						h.BlocksReceived, humanize.Bytes(h.DataReceived),
+						h.WantlistSize, h.Peers)
+				}
+			}
 
 			return nil
 		}),
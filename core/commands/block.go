@@ -13,6 +13,7 @@ import (
 
 	cmds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmds"
 	cmdkit "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmdkit"
+	mbase "github.com/ipsn/go-ipfs/gxlibs/github.com/multiformats/go-multibase"
 	mh "github.com/ipsn/go-ipfs/gxlibs/github.com/multiformats/go-multihash"
 )
 
@@ -40,6 +41,69 @@ multihash.
 		"get":  blockGetCmd,
 		"put":  blockPutCmd,
 		"rm":   blockRmCmd,
+		"list": blockListCmd,
+	},
+}
+
+const (
+	cidBaseOptionName  = "cid-base"
+	blockAllOptionName = "all"
+)
+
+var blockListCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List all raw IPFS blocks in the local blockstore.",
+		ShortDescription: `
+'ipfs block list' is a plumbing command for enumerating all blocks stored
+locally. It streams one CID per line and makes no guarantees about ordering.
+`,
+	},
+
+	Options: []cmdkit.Option{
+		cmdkit.StringOption(cidBaseOptionName, "Multibase to use to encode version 1 CIDs in output."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+
+		base, baseSet := req.Options[cidBaseOptionName].(string)
+		var enc mbase.Encoder
+		if baseSet {
+			enc, err = mbase.EncoderByName(base)
+			if err != nil {
+				return err
+			}
+		}
+
+		blocks, err := api.Block().List(req.Context)
+		if err != nil {
+			return err
+		}
+
+		for b := range blocks {
+			key := b.Path().Cid().String()
+			if baseSet {
+				key, err = b.Path().Cid().StringOfBase(enc.Encoding())
+				if err != nil {
+					return err
+				}
+			}
+
+			if err := res.Emit(&BlockStat{Key: key, Size: b.Size()}); err != nil {
+				return err
+			}
+		}
+
+		return req.Context.Err()
+	},
+	Type: BlockStat{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, bs *BlockStat) error {
+			_, err := fmt.Fprintf(w, "%s\n", bs.Key)
+			return err
+		}),
 	},
 }
 
@@ -57,7 +121,10 @@ on raw IPFS blocks. It outputs the following to stdout:
 	},
 
 	Arguments: []cmdkit.Argument{
-		cmdkit.StringArg("key", true, false, "The base58 multihash of an existing block to stat.").EnableStdin(),
+		cmdkit.StringArg("key", false, false, "The base58 multihash of an existing block to stat.").EnableStdin(),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption(blockAllOptionName, "Stat every block in the local blockstore, streaming the results."),
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		api, err := cmdenv.GetApi(env, req)
@@ -65,6 +132,33 @@ on raw IPFS blocks. It outputs the following to stdout:
 			return err
 		}
 
+		if all, _ := req.Options[blockAllOptionName].(bool); all {
+			if len(req.Arguments) > 0 {
+				return errors.New("cannot combine keys with --all")
+			}
+
+			stats, err := api.Block().StatAll(req.Context)
+			if err != nil {
+				return err
+			}
+
+			for b := range stats {
+				err := res.Emit(&BlockStat{
+					Key:  b.Path().Cid().String(),
+					Size: b.Size(),
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return req.Context.Err()
+		}
+
+		if len(req.Arguments) == 0 {
+			return errors.New("argument 'key' is required unless --all is set")
+		}
+
 		p, err := coreiface.ParsePath(req.Arguments[0])
 		if err != nil {
 			return err
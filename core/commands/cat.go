@@ -9,7 +9,6 @@ import (
 	"github.com/ipsn/go-ipfs/core/commands/cmdenv"
 	"github.com/ipsn/go-ipfs/core/coreapi/interface"
 
-	"github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-files"
 	cmds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmds"
 	"github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmdkit"
 )
@@ -123,49 +122,47 @@ func cat(ctx context.Context, api iface.CoreAPI, paths []string, offset int64, m
 			return nil, 0, err
 		}
 
-		f, err := api.Unixfs().Get(ctx, fpath)
-		if err != nil {
-			return nil, 0, err
-		}
+		if offset > 0 {
+			// skip over whole files falling before the requested offset;
+			// sizing a file only reads its root node
+			f, err := api.Unixfs().GetFile(ctx, fpath)
+			if err != nil {
+				return nil, 0, err
+			}
 
-		file, ok := f.(files.File)
-		if !ok {
-			return nil, 0, iface.ErrNotFile
-		}
+			fsize, err := f.Size()
+			f.Close()
+			if err != nil {
+				return nil, 0, err
+			}
 
-		fsize, err := file.Size()
-		if err != nil {
-			return nil, 0, err
+			if offset > fsize {
+				offset -= fsize
+				continue
+			}
 		}
 
-		if offset > fsize {
-			offset = offset - fsize
-			continue
+		remaining := int64(-1)
+		if max > 0 {
+			remaining = max - int64(length)
 		}
 
-		count, err := file.Seek(offset, io.SeekStart)
+		file, err := api.Unixfs().GetRange(ctx, fpath, offset, remaining)
 		if err != nil {
 			return nil, 0, err
 		}
 		offset = 0
 
-		fsize, err = file.Size()
+		size, err := file.Size()
 		if err != nil {
 			return nil, 0, err
 		}
 
-		size := uint64(fsize - count)
-		length += size
+		length += uint64(size)
+		readers = append(readers, file)
 		if max > 0 && length >= uint64(max) {
-			var r io.Reader = file
-			if overshoot := int64(length - uint64(max)); overshoot != 0 {
-				r = io.LimitReader(file, int64(size)-overshoot)
-				length = uint64(max)
-			}
-			readers = append(readers, r)
 			break
 		}
-		readers = append(readers, file)
 	}
 	return readers, length, nil
 }
@@ -77,6 +77,7 @@ func TestCommands(t *testing.T) {
 		"/block",
 		"/block/get",
 		"/block/put",
+		"/block/list",
 		"/block/rm",
 		"/block/stat",
 		"/bootstrap",
@@ -112,14 +113,23 @@ func TestCommands(t *testing.T) {
 		"/dns",
 		"/file",
 		"/file/ls",
+		"/file/chmod",
 		"/files",
 		"/files/chcid",
+		"/files/checksum-verify",
+		"/files/chmod",
+		"/files/clone",
 		"/files/cp",
 		"/files/flush",
+		"/files/find",
 		"/files/ls",
 		"/files/mkdir",
 		"/files/mv",
+		"/files/publish-key",
+		"/files/publish-key/set",
 		"/files/read",
+		"/files/relayout",
+		"/files/resolve",
 		"/files/rm",
 		"/files/stat",
 		"/filestore",
@@ -131,9 +141,12 @@ func TestCommands(t *testing.T) {
 		"/id",
 		"/key",
 		"/key/gen",
+		"/key/import",
 		"/key/list",
 		"/key/rename",
 		"/key/rm",
+		"/key/sign",
+		"/key/verify",
 		"/log",
 		"/log/level",
 		"/log/ls",
@@ -148,6 +161,7 @@ func TestCommands(t *testing.T) {
 		"/name/pubsub/cancel",
 		"/name/resolve",
 		"/object",
+		"/object/canonicalize",
 		"/object/data",
 		"/object/diff",
 		"/object/get",
@@ -155,9 +169,11 @@ func TestCommands(t *testing.T) {
 		"/object/new",
 		"/object/patch",
 		"/object/patch/add-link",
+		"/object/patch/apply",
 		"/object/patch/append-data",
 		"/object/patch/rm-link",
 		"/object/patch/set-data",
+		"/object/patch/splice-data",
 		"/object/put",
 		"/object/stat",
 		"/p2p",
@@ -170,9 +186,12 @@ func TestCommands(t *testing.T) {
 		"/p2p/stream/ls",
 		"/pin",
 		"/pin/add",
+		"/pin/export",
+		"/pin/import",
 		"/ping",
 		"/pin/ls",
 		"/pin/rm",
+		"/pin/stats",
 		"/pin/update",
 		"/pin/verify",
 		"/pubsub",
@@ -187,10 +206,12 @@ func TestCommands(t *testing.T) {
 		"/repo/gc",
 		"/repo/stat",
 		"/repo/verify",
+		"/repo/warm",
 		"/repo/version",
 		"/resolve",
 		"/shutdown",
 		"/stats",
+		"/stats/all",
 		"/stats/bitswap",
 		"/stats/bw",
 		"/stats/repo",
@@ -198,12 +219,15 @@ func TestCommands(t *testing.T) {
 		"/swarm/addrs",
 		"/swarm/addrs/listen",
 		"/swarm/addrs/local",
+		"/swarm/bandwidth",
 		"/swarm/connect",
 		"/swarm/disconnect",
 		"/swarm/filters",
 		"/swarm/filters/add",
 		"/swarm/filters/rm",
 		"/swarm/peers",
+		"/swarm/protect",
+		"/swarm/unprotect",
 		"/tar",
 		"/tar/add",
 		"/tar/cat",
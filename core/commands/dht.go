@@ -141,7 +141,10 @@ var findProvidersDhtCmd = &cmds.Command{
 			return err
 		}
 
-		if !n.OnlineMode() {
+		// the global --offline flag limits the lookup to the local
+		// provider store, so it must get past the online check
+		offline, _ := req.Options[OfflineOption].(bool)
+		if !offline && !n.OnlineMode() {
 			return ErrNotOnline
 		}
 
@@ -162,7 +165,19 @@ var findProvidersDhtCmd = &cmds.Command{
 		ctx, cancel := context.WithCancel(req.Context)
 		ctx, events := notif.RegisterForQueryEvents(ctx)
 
-		pchan := n.Routing.FindProvidersAsync(ctx, c, numProviders)
+		var pchan <-chan pstore.PeerInfo
+		if offline {
+			lr, ok := n.Routing.(interface {
+				LocalFindProvidersAsync(context.Context, cid.Cid, int) <-chan pstore.PeerInfo
+			})
+			if !ok {
+				cancel()
+				return errors.New("offline provider lookup not supported by this routing implementation")
+			}
+			pchan = lr.LocalFindProvidersAsync(ctx, c, numProviders)
+		} else {
+			pchan = n.Routing.FindProvidersAsync(ctx, c, numProviders)
+		}
 
 		go func() {
 			defer cancel()
@@ -1,14 +1,23 @@
 package commands
 
 import (
+	"bufio"
 	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	gopath "path"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ipsn/go-ipfs/core"
 	"github.com/ipsn/go-ipfs/core/commands/cmdenv"
@@ -20,7 +29,11 @@ import (
 	"github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-mfs"
 	"github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-exchange-offline"
 	"github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmds"
+	"github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-files"
 	ft "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs"
+	importer "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs/importer"
+	h "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs/importer/helpers"
+	uio "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs/io"
 	ipld "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipld-format"
 	logging "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-log"
 	dag "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-merkledag"
@@ -50,18 +63,67 @@ operations.
 	},
 	Options: []cmdkit.Option{
 		cmdkit.BoolOption(filesFlushOptionName, "f", "Flush target and ancestors after write.").WithDefault(true),
+		cmdkit.BoolOption(quietOptionName, "q", "Print only the affected path."),
+		cmdkit.BoolOption(quieterOptionName, "Q", "Print only the resulting cid."),
 	},
 	Subcommands: map[string]*cmds.Command{
-		"read":  filesReadCmd,
-		"write": filesWriteCmd,
-		"mv":    filesMvCmd,
-		"cp":    filesCpCmd,
-		"ls":    filesLsCmd,
-		"mkdir": filesMkdirCmd,
-		"stat":  filesStatCmd,
-		"rm":    filesRmCmd,
-		"flush": filesFlushCmd,
-		"chcid": filesChcidCmd,
+		"read":            filesReadCmd,
+		"checksum-verify": filesChecksumVerifyCmd,
+		"write":           filesWriteCmd,
+		"mv":              filesMvCmd,
+		"cp":              filesCpCmd,
+		"clone":           filesCloneCmd,
+		"ls":              filesLsCmd,
+		"find":            filesFindCmd,
+		"mkdir":           filesMkdirCmd,
+		"stat":            filesStatCmd,
+		"rm":              filesRmCmd,
+		"flush":           filesFlushCmd,
+		"chcid":           filesChcidCmd,
+		"chmod":           filesChmodCmd,
+
+		"relayout": filesRelayoutCmd,
+
+		"resolve": filesResolveCmd,
+
+		"publish-key": filesPublishKeyCmd,
+	},
+}
+
+var filesPublishKeyCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Manage the IPNS key used to publish the files root.",
+		ShortDescription: `
+By default the files (MFS) root is only persisted locally. Setting a publish
+key makes the republisher also publish the root under the given keystore key,
+so the files tree stays reachable under a stable name even if the node
+identity changes.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"set": filesPublishKeySetCmd,
+	},
+}
+
+var filesPublishKeySetCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Set the keystore key the MFS republisher publishes under.",
+		ShortDescription: `
+Selects which keystore key the files root is published under. The change
+takes effect on the next publish, without restarting the daemon. Use 'self'
+for the node identity key.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("name", true, false, "Name of the key to publish the files root under."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		nd, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+
+		return nd.SetFilesPublishKey(req.Arguments[0])
 	},
 }
 
@@ -81,9 +143,37 @@ type statOutput struct {
 	CumulativeSize uint64
 	Blocks         int
 	Type           string
-	WithLocality   bool   `json:",omitempty"`
-	Local          bool   `json:",omitempty"`
-	SizeLocal      uint64 `json:",omitempty"`
+	WithLocality   bool            `json:",omitempty"`
+	Local          bool            `json:",omitempty"`
+	SizeLocal      uint64          `json:",omitempty"`
+	Ancestors      []AncestorEntry `json:",omitempty"`
+	Layout         []LinkInfo      `json:",omitempty"`
+
+	// FileCount and DirCount tally the nodes of an aggregated subtree,
+	// only populated by --recursive
+	FileCount int `json:",omitempty"`
+	DirCount  int `json:",omitempty"`
+
+	// Mode (octal) and Mtime (seconds since the epoch) mirror the unixfs
+	// metadata stored on the node, empty when none was set
+	Mode  string `json:",omitempty"`
+	Mtime int64  `json:",omitempty"`
+}
+
+// LinkInfo describes one child link of a statted node: for files the Size is
+// the unixfs data size of the chunk, for directories the cumulative size of
+// the entry.
+type LinkInfo struct {
+	Hash string
+	Name string `json:",omitempty"`
+	Size uint64
+}
+
+// AncestorEntry is the resolved cid of one ancestor directory of the statted
+// path, from the files root down.
+type AncestorEntry struct {
+	Path string
+	Hash string
 }
 
 const (
@@ -95,8 +185,77 @@ Type: <type>`
 	filesFormatOptionName    = "format"
 	filesSizeOptionName      = "size"
 	filesWithLocalOptionName = "with-local"
+	filesAncestorsOptionName = "ancestors"
+	filesVerboseOptionName   = "verbose"
+	filesStatCacheOptionName = "cache"
 )
 
+// statCacheTTL bounds how long a cached stat result may be served. Entries
+// are keyed by the files root cid, so any MFS write already invalidates
+// them; the TTL only keeps stale keys from lingering.
+var statCacheTTL = 30 * time.Second
+
+// statCacheSize caps the number of remembered stat results.
+const statCacheSize = 256
+
+type statCacheKey struct {
+	path string
+	root string
+}
+
+type statCacheEntry struct {
+	out   *statOutput
+	added time.Time
+}
+
+// statCache remembers recent stat results for interactive browsing, keyed
+// by path and the cid of the files root at the time of the stat. MFS
+// content is content-addressed, so an entry is valid exactly as long as the
+// root cid is unchanged; a write moves the root to a new cid and naturally
+// misses the cache.
+type statCache struct {
+	lk      sync.Mutex
+	entries map[statCacheKey]statCacheEntry
+}
+
+var filesStatCache = &statCache{entries: make(map[statCacheKey]statCacheEntry)}
+
+func (sc *statCache) get(path string, root cid.Cid) (*statOutput, bool) {
+	key := statCacheKey{path: path, root: root.String()}
+
+	sc.lk.Lock()
+	defer sc.lk.Unlock()
+
+	e, ok := sc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Since(e.added) > statCacheTTL {
+		delete(sc.entries, key)
+		return nil, false
+	}
+	return e.out, true
+}
+
+func (sc *statCache) put(path string, root cid.Cid, o *statOutput) {
+	sc.lk.Lock()
+	defer sc.lk.Unlock()
+
+	if len(sc.entries) >= statCacheSize {
+		// drop expired entries first, clear everything if that isn't enough
+		for k, e := range sc.entries {
+			if time.Since(e.added) > statCacheTTL {
+				delete(sc.entries, k)
+			}
+		}
+		if len(sc.entries) >= statCacheSize {
+			sc.entries = make(map[statCacheKey]statCacheEntry)
+		}
+	}
+
+	sc.entries[statCacheKey{path: path, root: root.String()}] = statCacheEntry{out: o, added: time.Now()}
+}
+
 var filesStatCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Display file status.",
@@ -107,10 +266,14 @@ var filesStatCmd = &cmds.Command{
 	},
 	Options: []cmdkit.Option{
 		cmdkit.StringOption(filesFormatOptionName, "Print statistics in given format. Allowed tokens: "+
-			"<hash> <size> <cumulsize> <type> <childs>. Conflicts with other format options.").WithDefault(defaultStatFormat),
+			"<hash> <size> <cumulsize> <type> <childs> <mode> <mtime>. Conflicts with other format options.").WithDefault(defaultStatFormat),
 		cmdkit.BoolOption(filesHashOptionName, "Print only hash. Implies '--format=<hash>'. Conflicts with other format options."),
 		cmdkit.BoolOption(filesSizeOptionName, "Print only size. Implies '--format=<cumulsize>'. Conflicts with other format options."),
 		cmdkit.BoolOption(filesWithLocalOptionName, "Compute the amount of the dag that is local, and if possible the total size"),
+		cmdkit.BoolOption(filesAncestorsOptionName, "Include the cids of each ancestor directory from the files root down to the path."),
+		cmdkit.BoolOption(filesVerboseOptionName, "v", "Include the block layout: child link cids and their unixfs sizes."),
+		cmdkit.BoolOption(filesStatCacheOptionName, "Serve repeated stats of unchanged content from a short-lived cache."),
+		cmdkit.BoolOption(recursiveOptionName, "r", "Aggregate stats over the whole subtree when the path is a directory."),
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 
@@ -147,6 +310,45 @@ var filesStatCmd = &cmds.Command{
 			dagserv = node.DAG
 		}
 
+		verbose, _ := req.Options[filesVerboseOptionName].(bool)
+		withAncestors, _ := req.Options[filesAncestorsOptionName].(bool)
+
+		if recursive, _ := req.Options[recursiveOptionName].(bool); recursive {
+			if strings.HasPrefix(path, "/ipfs/") {
+				return fmt.Errorf("--recursive is only supported for mfs paths")
+			}
+
+			fsn, err := mfs.Lookup(node.FilesRoot, path)
+			if err != nil {
+				return err
+			}
+
+			o, err := statRecursive(req.Context, fsn)
+			if err != nil {
+				return err
+			}
+
+			return cmds.EmitOnce(res, o)
+		}
+
+		// the cached output only matches a plain stat of an mfs path; the
+		// files root cid in the key makes any write a miss
+		useCache, _ := req.Options[filesStatCacheOptionName].(bool)
+		useCache = useCache && !withLocal && !withAncestors && !verbose && !strings.HasPrefix(path, "/ipfs/")
+
+		var rootCid cid.Cid
+		if useCache {
+			rootnd, err := node.FilesRoot.GetDirectory().GetNode()
+			if err != nil {
+				return err
+			}
+			rootCid = rootnd.Cid()
+
+			if o, ok := filesStatCache.get(path, rootCid); ok {
+				return cmds.EmitOnce(res, o)
+			}
+		}
+
 		nd, err := getNodeFromPath(req.Context, node, api, path)
 		if err != nil {
 			return err
@@ -157,6 +359,27 @@ var filesStatCmd = &cmds.Command{
 			return err
 		}
 
+		if useCache {
+			filesStatCache.put(path, rootCid, o)
+		}
+		if verbose {
+			o.Layout, err = statNodeLayout(nd)
+			if err != nil {
+				return err
+			}
+		}
+
+		if withAncestors {
+			if strings.HasPrefix(path, "/ipfs/") {
+				return fmt.Errorf("--ancestors is only supported for mfs paths")
+			}
+
+			o.Ancestors, err = ancestorChain(node.FilesRoot, path)
+			if err != nil {
+				return err
+			}
+		}
+
 		if !withLocal {
 			return cmds.EmitOnce(res, o)
 		}
@@ -177,9 +400,27 @@ var filesStatCmd = &cmds.Command{
 			s = strings.Replace(s, "<cumulsize>", fmt.Sprintf("%d", out.CumulativeSize), -1)
 			s = strings.Replace(s, "<childs>", fmt.Sprintf("%d", out.Blocks), -1)
 			s = strings.Replace(s, "<type>", out.Type, -1)
+			s = strings.Replace(s, "<mode>", out.Mode, -1)
+			var mtime string
+			if out.Mtime != 0 {
+				mtime = strconv.FormatInt(out.Mtime, 10)
+			}
+			s = strings.Replace(s, "<mtime>", mtime, -1)
 
 			fmt.Fprintln(w, s)
 
+			if out.Mode != "" {
+				fmt.Fprintf(w, "Mode: %s\n", out.Mode)
+			}
+			if out.Mtime != 0 {
+				fmt.Fprintf(w, "Mtime: %d\n", out.Mtime)
+			}
+
+			if out.FileCount > 0 || out.DirCount > 0 {
+				fmt.Fprintf(w, "Files: %d\n", out.FileCount)
+				fmt.Fprintf(w, "Directories: %d\n", out.DirCount)
+			}
+
 			if out.WithLocality {
 				fmt.Fprintf(w, "Local: %s of %s (%.2f%%)\n",
 					humanize.Bytes(out.SizeLocal),
@@ -188,12 +429,65 @@ var filesStatCmd = &cmds.Command{
 				)
 			}
 
+			for _, a := range out.Ancestors {
+				fmt.Fprintf(w, "%s\t%s\n", a.Hash, a.Path)
+			}
+
+			if verbose, _ := req.Options[filesVerboseOptionName].(bool); verbose && len(out.Layout) > 0 {
+				fmt.Fprintln(w, "Layout:")
+				for _, l := range out.Layout {
+					if l.Name != "" {
+						fmt.Fprintf(w, "\t%s\t%d\t%s\n", l.Hash, l.Size, l.Name)
+					} else {
+						fmt.Fprintf(w, "\t%s\t%d\n", l.Hash, l.Size)
+					}
+				}
+			}
+
 			return nil
 		}),
 	},
 	Type: statOutput{},
 }
 
+// ancestorChain resolves every ancestor directory of an mfs path, from the
+// files root down to the path itself.
+func ancestorChain(root *mfs.Root, path string) ([]AncestorEntry, error) {
+	add := func(out []AncestorEntry, p string) ([]AncestorEntry, error) {
+		fsn, err := mfs.Lookup(root, p)
+		if err != nil {
+			return nil, err
+		}
+
+		nd, err := fsn.GetNode()
+		if err != nil {
+			return nil, err
+		}
+
+		return append(out, AncestorEntry{Path: p, Hash: nd.Cid().String()}), nil
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	out, err := add(nil, "/")
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range parts {
+		if parts[i] == "" {
+			continue
+		}
+
+		out, err = add(out, "/"+strings.Join(parts[:i+1], "/"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
 func moreThanOne(a, b, c bool) bool {
 	return a && b || b && c || a && c
 }
@@ -212,9 +506,31 @@ func statGetFormatOptions(req *cmds.Request) (string, error) {
 		return "<hash>", nil
 	} else if size {
 		return "<cumulsize>", nil
-	} else {
-		return format, nil
 	}
+
+	if err := validateStatFormat(format); err != nil {
+		return "", err
+	}
+	return format, nil
+}
+
+// statFormatTokens are the tokens the stat --format string understands.
+var statFormatTokens = []string{
+	"<hash>", "<size>", "<cumulsize>", "<childs>", "<type>", "<mode>", "<mtime>",
+}
+
+// validateStatFormat rejects format strings containing unknown <...> tokens.
+func validateStatFormat(format string) error {
+	s := format
+	for _, tok := range statFormatTokens {
+		s = strings.Replace(s, tok, "", -1)
+	}
+	if open := strings.Index(s, "<"); open >= 0 {
+		if end := strings.Index(s[open:], ">"); end >= 0 {
+			return fmt.Errorf("unknown format token %s", s[open:open+end+1])
+		}
+	}
+	return nil
 }
 
 func statNode(nd ipld.Node) (*statOutput, error) {
@@ -242,13 +558,20 @@ func statNode(nd ipld.Node) (*statOutput, error) {
 			return nil, fmt.Errorf("unrecognized node type: %s", d.Type())
 		}
 
-		return &statOutput{
+		out := &statOutput{
 			Hash:           c.String(),
 			Blocks:         len(nd.Links()),
 			Size:           d.FileSize(),
 			CumulativeSize: cumulsize,
 			Type:           ndtype,
-		}, nil
+		}
+		if m := d.Mode(); m != 0 {
+			out.Mode = strconv.FormatUint(uint64(m), 8)
+		}
+		if mt := d.Mtime(); !mt.IsZero() {
+			out.Mtime = mt.Unix()
+		}
+		return out, nil
 	case *dag.RawNode:
 		return &statOutput{
 			Hash:           c.String(),
@@ -262,6 +585,93 @@ func statNode(nd ipld.Node) (*statOutput, error) {
 	}
 }
 
+// statRecursive aggregates stats over a whole mfs subtree. Hash, Type and
+// CumulativeSize describe the root of the subtree (the cumulative size
+// already covers every node below it); Size sums the unixfs file sizes,
+// Blocks counts the child blocks across all nodes, and FileCount/DirCount
+// tally the entries.
+func statRecursive(ctx context.Context, fsn mfs.FSNode) (*statOutput, error) {
+	nd, err := fsn.GetNode()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := statNode(nd)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, ok := fsn.(*mfs.Directory)
+	if !ok {
+		out.FileCount = 1
+		return out, nil
+	}
+
+	out.DirCount = 1
+	out.Size = 0
+
+	listing, err := dir.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range listing {
+		child, err := dir.Child(entry.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		sub, err := statRecursive(ctx, child)
+		if err != nil {
+			return nil, err
+		}
+
+		out.Size += sub.Size
+		out.Blocks += sub.Blocks
+		out.FileCount += sub.FileCount
+		out.DirCount += sub.DirCount
+	}
+
+	return out, nil
+}
+
+// statNodeLayout lists the child links of a node. For unixfs files the sizes
+// come from the block size metadata, exposing the chunking of the content;
+// for everything else the link Tsize is reported.
+func statNodeLayout(nd ipld.Node) ([]LinkInfo, error) {
+	links := nd.Links()
+	if len(links) == 0 {
+		return nil, nil
+	}
+
+	layout := make([]LinkInfo, len(links))
+	for i, l := range links {
+		layout[i] = LinkInfo{
+			Hash: l.Cid.String(),
+			Name: l.Name,
+			Size: l.Size,
+		}
+	}
+
+	pbnd, ok := nd.(*dag.ProtoNode)
+	if !ok {
+		return layout, nil
+	}
+
+	d, err := ft.FSNodeFromBytes(pbnd.Data())
+	if err != nil {
+		return nil, err
+	}
+
+	if d.NumChildren() == len(links) {
+		for i := range layout {
+			layout[i].Size = d.BlockSize(i)
+		}
+	}
+
+	return layout, nil
+}
+
 func walkBlock(ctx context.Context, dagserv ipld.DAGService, nd ipld.Node) (bool, uint64, error) {
 	// Start with the block data size
 	sizeLocal := uint64(len(nd.RawData()))
@@ -294,14 +704,53 @@ func walkBlock(ctx context.Context, dagserv ipld.DAGService, nd ipld.Node) (bool
 	return local, sizeLocal, nil
 }
 
+// subtreeSize returns the cumulative size of all blocks below nd, counting
+// blocks shared within the subtree only once.
+func subtreeSize(ctx context.Context, dagserv ipld.DAGService, nd ipld.Node, seen *cid.Set) (uint64, error) {
+	if !seen.Visit(nd.Cid()) {
+		return 0, nil
+	}
+
+	size := uint64(len(nd.RawData()))
+
+	for _, link := range nd.Links() {
+		child, err := dagserv.Get(ctx, link.Cid)
+		if err != nil {
+			return 0, err
+		}
+
+		childSize, err := subtreeSize(ctx, dagserv, child, seen)
+		if err != nil {
+			return 0, err
+		}
+		size += childSize
+	}
+
+	return size, nil
+}
+
+const filesDepthOptionName = "depth"
+
 var filesCpCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Copy files into mfs.",
+		ShortDescription: `
+Copy files into mfs.
+
+The copy links the source object into place without fetching its content.
+With '--depth N' the top N levels of a directory are fetched into the local
+repo as well, so the structure can be explored offline; entries below the
+cut-off stay stubs - links that list name, hash and size but whose content
+remains unresolvable until fetched from the network.
+`,
 	},
 	Arguments: []cmdkit.Argument{
 		cmdkit.StringArg("source", true, false, "Source object to copy."),
 		cmdkit.StringArg("dest", true, false, "Destination to copy object to."),
 	},
+	Options: []cmdkit.Option{
+		cmdkit.IntOption(filesDepthOptionName, "Fetch only this many levels of a directory, leaving deeper entries as unfetched links."),
+	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		nd, err := cmdenv.GetNode(env)
 		if err != nil {
@@ -335,6 +784,15 @@ var filesCpCmd = &cmds.Command{
 			return fmt.Errorf("cp: cannot get node from path %s: %s", src, err)
 		}
 
+		if depth, ok := req.Options[filesDepthOptionName].(int); ok {
+			if depth < 1 {
+				return fmt.Errorf("cp: depth must be at least 1")
+			}
+			if err := fetchToDepth(req.Context, nd.DAG, node, depth); err != nil {
+				return fmt.Errorf("cp: cannot fetch %s to depth %d: %s", src, depth, err)
+			}
+		}
+
 		err = mfs.PutNode(nd.FilesRoot, dst, node)
 		if err != nil {
 			return fmt.Errorf("cp: cannot put node in path %s: %s", dst, err)
@@ -347,72 +805,313 @@ var filesCpCmd = &cmds.Command{
 			}
 		}
 
-		return nil
+		return emitQuiet(req, res, nd.FilesRoot, dst)
 	},
+	Encoders: filesQuietEncoders,
+	Type:     filesQuietOutput{},
 }
 
-func getNodeFromPath(ctx context.Context, node *core.IpfsNode, api iface.CoreAPI, p string) (ipld.Node, error) {
-	switch {
-	case strings.HasPrefix(p, "/ipfs/"):
-		np, err := iface.ParsePath(p)
-		if err != nil {
-			return nil, err
-		}
-
-		return api.ResolveNode(ctx, np)
-	default:
-		fsn, err := mfs.Lookup(node.FilesRoot, p)
-		if err != nil {
-			return nil, err
-		}
-
-		return fsn.GetNode()
-	}
-}
-
-type filesLsOutput struct {
-	Entries []mfs.NodeListing
+type filesCloneOutput struct {
+	Cid string
 }
 
-const (
-	longOptionName     = "l"
-	dontSortOptionName = "U"
-)
-
-var filesLsCmd = &cmds.Command{
+var filesCloneCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
-		Tagline: "List directories in the local mutable namespace.",
+		Tagline: "Clone a directory within mfs, sharing all blocks.",
 		ShortDescription: `
-List directories in the local mutable namespace.
-
-Examples:
-
-    $ ipfs files ls /welcome/docs/
-    about
-    contact
-    help
-    quick-start
-    readme
-    security-notes
-
-    $ ipfs files ls /myfiles/a/b/c/d
-    foo
-    bar
+Clones an MFS directory to a new location without copying any data. The
+source subtree is flushed to get a stable CID and the destination becomes
+another link to the same nodes, so cloning is instant and adds no blocks
+to the repo regardless of the directory size. Later modifications to
+either side only create new nodes along the modified paths, leaving the
+rest shared.
+
+Use 'ipfs files cp' to link content from an /ipfs/ path instead.
 `,
 	},
 	Arguments: []cmdkit.Argument{
-		cmdkit.StringArg("path", false, false, "Path to show listing for. Defaults to '/'."),
-	},
-	Options: []cmdkit.Option{
-		cmdkit.BoolOption(longOptionName, "Use long listing format."),
-		cmdkit.BoolOption(dontSortOptionName, "Do not sort; list entries in directory order."),
+		cmdkit.StringArg("source", true, false, "MFS directory to clone."),
+		cmdkit.StringArg("dest", true, false, "Destination to link the clone at."),
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
-		var arg string
+		nd, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
 
-		if len(req.Arguments) == 0 {
-			arg = "/"
-		} else {
+		flush, _ := req.Options[filesFlushOptionName].(bool)
+
+		src, err := checkPath(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		src = strings.TrimRight(src, "/")
+
+		if strings.HasPrefix(src, "/ipfs/") {
+			return fmt.Errorf("clone: source must be an mfs path, use 'files cp' for %s", src)
+		}
+
+		dst, err := checkPath(req.Arguments[1])
+		if err != nil {
+			return err
+		}
+
+		if dst[len(dst)-1] == '/' {
+			dst += gopath.Base(src)
+		}
+
+		node, err := cloneDir(nd.FilesRoot, src, dst)
+		if err != nil {
+			return err
+		}
+
+		if flush {
+			err := mfs.FlushPath(nd.FilesRoot, dst)
+			if err != nil {
+				return fmt.Errorf("clone: cannot flush the created directory %s: %s", dst, err)
+			}
+		}
+
+		return cmds.EmitOnce(res, &filesCloneOutput{Cid: node.Cid().String()})
+	},
+	Type: filesCloneOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *filesCloneOutput) error {
+			fmt.Fprintf(w, "%s (shared, 0 bytes copied)\n", out.Cid)
+			return nil
+		}),
+	},
+}
+
+// cloneDir flushes the directory at src so it has a stable CID and links the
+// resulting node at dst. No data is copied; both paths reference the same
+// nodes until one of them is modified.
+func cloneDir(root *mfs.Root, src, dst string) (ipld.Node, error) {
+	err := mfs.FlushPath(root, src)
+	if err != nil {
+		return nil, fmt.Errorf("clone: cannot flush %s: %s", src, err)
+	}
+
+	fsn, err := mfs.Lookup(root, src)
+	if err != nil {
+		return nil, fmt.Errorf("clone: cannot get node from path %s: %s", src, err)
+	}
+
+	if _, ok := fsn.(*mfs.Directory); !ok {
+		return nil, fmt.Errorf("clone: %s is not a directory", src)
+	}
+
+	node, err := fsn.GetNode()
+	if err != nil {
+		return nil, err
+	}
+
+	err = mfs.PutNode(root, dst, node)
+	if err != nil {
+		return nil, fmt.Errorf("clone: cannot put node in path %s: %s", dst, err)
+	}
+
+	return node, nil
+}
+
+// fetchToDepth fetches the top depth levels of a DAG into the local repo.
+// The node itself counts as the first level, so depth 1 fetches nothing
+// beyond it. Links below the cut-off are left untouched: they still list
+// name, hash and size, but their content cannot be resolved until the
+// blocks are retrieved from the network.
+func fetchToDepth(ctx context.Context, dserv ipld.DAGService, nd ipld.Node, depth int) error {
+	if depth <= 1 {
+		return nil
+	}
+
+	for _, lnk := range nd.Links() {
+		child, err := lnk.GetNode(ctx, dserv)
+		if err != nil {
+			return err
+		}
+
+		if err := fetchToDepth(ctx, dserv, child, depth-1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getNodeFromPath(ctx context.Context, node *core.IpfsNode, api iface.CoreAPI, p string) (ipld.Node, error) {
+	start, components, err := splitWalkPath(ctx, node, api, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return walkNodePath(ctx, node.DAG, nil, start, components, nil)
+}
+
+// pathStep describes a single component of a step-by-step path walk.
+type pathStep struct {
+	Name  string // the component that was looked up
+	Cid   string // the directory it was looked up in
+	Found bool
+	Child string `json:",omitempty"` // cid of the child when found
+	Type  string `json:",omitempty"` // "directory", "file" or "symlink" when known
+	Local bool   // whether the child was already in the local repo
+}
+
+// splitWalkPath turns a files command path into the node the walk starts
+// from and the components left to resolve below it.
+func splitWalkPath(ctx context.Context, node *core.IpfsNode, api iface.CoreAPI, p string) (ipld.Node, []string, error) {
+	if strings.HasPrefix(p, "/ipfs/") {
+		parts := strings.Split(strings.Trim(p, "/"), "/")
+		if len(parts) < 2 {
+			return nil, nil, fmt.Errorf("invalid path %q", p)
+		}
+
+		np, err := iface.ParsePath("/ipfs/" + parts[1])
+		if err != nil {
+			return nil, nil, err
+		}
+		start, err := api.ResolveNode(ctx, np)
+		if err != nil {
+			return nil, nil, err
+		}
+		return start, parts[2:], nil
+	}
+
+	start, err := node.FilesRoot.GetDirectory().GetNode()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var components []string
+	for _, c := range strings.Split(p, "/") {
+		if c != "" {
+			components = append(components, c)
+		}
+	}
+	return start, components, nil
+}
+
+// walkNodePath resolves components one at a time starting from start,
+// calling visit (when non-nil) after every lookup. When offlineDserv is
+// non-nil each child is first tried against it to learn whether the block
+// was already local; dserv is used to fetch whatever is missing. The walk
+// stops at the first component that cannot be resolved, with an error
+// naming the component and the directory it was looked up in.
+func walkNodePath(ctx context.Context, dserv, offlineDserv ipld.DAGService, start ipld.Node, components []string, visit func(pathStep) error) (ipld.Node, error) {
+	cur := start
+	for i, name := range components {
+		step := pathStep{Name: name, Cid: cur.Cid().String(), Local: true}
+
+		var child ipld.Node
+		dir, dirErr := uio.NewDirectoryFromNode(dserv, cur)
+		if dirErr == nil {
+			if offlineDserv != nil {
+				if offDir, err := uio.NewDirectoryFromNode(offlineDserv, cur); err == nil {
+					if _, err := offDir.Find(ctx, name); err == ipld.ErrNotFound {
+						step.Local = false
+					}
+				}
+			}
+
+			var err error
+			child, err = dir.Find(ctx, name)
+			if os.IsNotExist(err) {
+				if visit != nil {
+					if verr := visit(step); verr != nil {
+						return nil, verr
+					}
+				}
+				return nil, fmt.Errorf("%s does not exist: no entry named %q in %s", "/"+gopath.Join(components[:i+1]...), name, cur.Cid())
+			} else if err != nil {
+				return nil, fmt.Errorf("cannot resolve %q in %s: %s", name, cur.Cid(), err)
+			}
+		} else {
+			// not a unixfs directory, fall back to plain link resolution
+			// so generic ipld nodes keep resolving
+			lnk, _, err := cur.ResolveLink([]string{name})
+			if err != nil {
+				return nil, fmt.Errorf("cannot resolve %q: %s is not a directory", name, cur.Cid())
+			}
+			child, err = lnk.GetNode(ctx, dserv)
+			if err != nil {
+				return nil, fmt.Errorf("cannot resolve %q in %s: %s", name, cur.Cid(), err)
+			}
+		}
+
+		step.Found = true
+		step.Child = child.Cid().String()
+		switch nd := child.(type) {
+		case *dag.RawNode:
+			step.Type = "file"
+		case *dag.ProtoNode:
+			if fsn, err := ft.FSNodeFromBytes(nd.Data()); err == nil {
+				switch fsn.Type() {
+				case ft.TDirectory, ft.THAMTShard:
+					step.Type = "directory"
+				case ft.TFile, ft.TRaw:
+					step.Type = "file"
+				case ft.TSymlink:
+					step.Type = "symlink"
+				}
+			}
+		}
+
+		if visit != nil {
+			if err := visit(step); err != nil {
+				return nil, err
+			}
+		}
+		cur = child
+	}
+
+	return cur, nil
+}
+
+type filesLsOutput struct {
+	Entries []mfs.NodeListing
+}
+
+const (
+	longOptionName     = "l"
+	dontSortOptionName = "U"
+	filesDuOptionName  = "du"
+)
+
+var filesLsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List directories in the local mutable namespace.",
+		ShortDescription: `
+List directories in the local mutable namespace.
+
+Examples:
+
+    $ ipfs files ls /welcome/docs/
+    about
+    contact
+    help
+    quick-start
+    readme
+    security-notes
+
+    $ ipfs files ls /myfiles/a/b/c/d
+    foo
+    bar
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("path", false, false, "Path to show listing for. Defaults to '/'."),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption(longOptionName, "Use long listing format."),
+		cmdkit.BoolOption(dontSortOptionName, "Do not sort; list entries in directory order."),
+		cmdkit.BoolOption(filesDuOptionName, "With -l, show the cumulative (deduplicated) size of directory entries. Walks each subtree, which can be expensive."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		var arg string
+
+		if len(req.Arguments) == 0 {
+			arg = "/"
+		} else {
 			arg = req.Arguments[0]
 		}
 
@@ -453,6 +1152,31 @@ Examples:
 			if err != nil {
 				return err
 			}
+
+			du, _ := req.Options[filesDuOptionName].(bool)
+			if du {
+				for i, l := range listing {
+					if l.Type != int(mfs.TDir) {
+						continue
+					}
+
+					child, err := fsn.Child(l.Name)
+					if err != nil {
+						return err
+					}
+
+					cnd, err := child.GetNode()
+					if err != nil {
+						return err
+					}
+
+					size, err := subtreeSize(req.Context, nd.DAG, cnd, cid.NewSet())
+					if err != nil {
+						return err
+					}
+					listing[i].Size = int64(size)
+				}
+			}
 			return cmds.EmitOnce(res, &filesLsOutput{listing})
 		case *mfs.File:
 			_, name := gopath.Split(path)
@@ -466,42 +1190,275 @@ Examples:
 				}
 				out.Entries[0].Size = size
 
-				nd, err := fsn.GetNode()
-				if err != nil {
-					return err
-				}
-				out.Entries[0].Hash = nd.Cid().String()
+				nd, err := fsn.GetNode()
+				if err != nil {
+					return err
+				}
+				out.Entries[0].Hash = nd.Cid().String()
+			}
+			return cmds.EmitOnce(res, out)
+		default:
+			return errors.New("unrecognized type")
+		}
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *filesLsOutput) error {
+			noSort, _ := req.Options[dontSortOptionName].(bool)
+			if !noSort {
+				sort.Slice(out.Entries, func(i, j int) bool {
+					return strings.Compare(out.Entries[i].Name, out.Entries[j].Name) < 0
+				})
+			}
+
+			long, _ := req.Options[longOptionName].(bool)
+			for _, o := range out.Entries {
+				if long {
+					if o.Type == int(mfs.TDir) {
+						o.Name += "/"
+					}
+					fmt.Fprintf(w, "%s\t%s\t%d\n", o.Name, o.Hash, o.Size)
+				} else {
+					fmt.Fprintf(w, "%s\n", o.Name)
+				}
+			}
+
+			return nil
+		}),
+	},
+	Type: filesLsOutput{},
+}
+
+const (
+	filesFindNameOptionName = "name"
+	filesFindTypeOptionName = "type"
+)
+
+type filesFindOutput struct {
+	Path string
+}
+
+var filesFindCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Search the local mutable namespace by name.",
+		ShortDescription: `
+Recursively walk the files API tree from a starting directory and print
+the full path of every entry whose name matches the '--name' glob
+pattern, like unix 'find -name'. Matches are streamed as they are found.
+
+Examples:
+
+    $ ipfs files find / --name '*.txt'
+    /docs/readme.txt
+    /notes/todo.txt
+
+    $ ipfs files find /docs --name 'img*' --type d
+    /docs/images
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("path", false, false, "Directory to start the search from. Defaults to '/'."),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption(filesFindNameOptionName, "Glob pattern entry names must match (e.g. '*.txt')."),
+		cmdkit.StringOption(filesFindTypeOptionName, "Only match entries of this type, 'f' for files or 'd' for directories."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		nd, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+
+		var arg string
+		if len(req.Arguments) == 0 {
+			arg = "/"
+		} else {
+			arg = req.Arguments[0]
+		}
+
+		path, err := checkPath(arg)
+		if err != nil {
+			return err
+		}
+
+		pattern, _ := req.Options[filesFindNameOptionName].(string)
+		typeFilter, _ := req.Options[filesFindTypeOptionName].(string)
+
+		return findMatches(req.Context, nd.FilesRoot, path, pattern, typeFilter, func(match string) error {
+			return res.Emit(&filesFindOutput{Path: match})
+		})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *filesFindOutput) error {
+			fmt.Fprintln(w, out.Path)
+			return nil
+		}),
+	},
+	Type: filesFindOutput{},
+}
+
+// findMatches walks the mfs tree under path depth-first and calls emit with
+// the full path of every entry whose name matches the glob pattern. An empty
+// pattern matches every name; typeFilter narrows matches to files ("f") or
+// directories ("d").
+func findMatches(ctx context.Context, root *mfs.Root, path, pattern, typeFilter string, emit func(string) error) error {
+	switch typeFilter {
+	case "", "f", "d":
+	default:
+		return fmt.Errorf("invalid type filter %q, expected 'f' or 'd'", typeFilter)
+	}
+
+	fsn, err := mfs.Lookup(root, path)
+	if err != nil {
+		return err
+	}
+
+	dir, ok := fsn.(*mfs.Directory)
+	if !ok {
+		return fmt.Errorf("%s was not a directory", path)
+	}
+
+	listing, err := dir.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, nl := range listing {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		matched := true
+		if pattern != "" {
+			matched, err = gopath.Match(pattern, nl.Name)
+			if err != nil {
+				return fmt.Errorf("invalid pattern %q: %s", pattern, err)
+			}
+		}
+
+		isDir := nl.Type == int(mfs.TDir)
+		if typeFilter == "f" && isDir || typeFilter == "d" && !isDir {
+			matched = false
+		}
+
+		child := gopath.Join(path, nl.Name)
+		if matched {
+			if err := emit(child); err != nil {
+				return err
+			}
+		}
+
+		if isDir {
+			if err := findMatches(ctx, root, child, pattern, typeFilter, emit); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+const filesResolveVerboseOptionName = "verbose"
+
+type filesResolveOutput struct {
+	Step *pathStep `json:",omitempty"` // one message per component in verbose mode
+	Cid  string    `json:",omitempty"` // set on the final message
+}
+
+var filesResolveCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Resolve a path component by component.",
+		ShortDescription: `
+Resolve a files API or /ipfs path down to the cid it names. With '-v' each
+resolution step is printed: the directory the component was looked up in,
+whether it was found, its type and - for /ipfs paths - whether the block
+was already local or had to be fetched. The walk stops at the first
+component that cannot be resolved, making it easy to see exactly where a
+"file does not exist" error comes from.
+
+Examples:
+
+    $ ipfs files resolve -v /docs/missing.txt
+    QmY5heUM5qgRubMDD1og9fhCPA6QdkMp3QCwd4s7gJsyE7: "docs" found, directory QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQH (local)
+    Error: /docs/missing.txt does not exist: no entry named "missing.txt" in QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQH
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("path", true, false, "Path to resolve."),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption(filesResolveVerboseOptionName, "v", "Print every resolution step."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		nd, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+
+		path, err := checkPath(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		var offlineDserv ipld.DAGService
+		if strings.HasPrefix(path, "/ipfs/") {
+			// probe lookups against the local repo only, so each step can
+			// report whether the block had to be fetched
+			bserv := bservice.New(nd.Blockstore, offline.Exchange(nd.Blockstore))
+			offlineDserv = dag.NewDAGService(bserv)
+		}
+
+		start, components, err := splitWalkPath(req.Context, nd, api, path)
+		if err != nil {
+			return err
+		}
+
+		var visit func(pathStep) error
+		if verbose, _ := req.Options[filesResolveVerboseOptionName].(bool); verbose {
+			visit = func(step pathStep) error {
+				return res.Emit(&filesResolveOutput{Step: &step})
 			}
-			return cmds.EmitOnce(res, out)
-		default:
-			return errors.New("unrecognized type")
 		}
+
+		final, err := walkNodePath(req.Context, nd.DAG, offlineDserv, start, components, visit)
+		if err != nil {
+			return err
+		}
+
+		return res.Emit(&filesResolveOutput{Cid: final.Cid().String()})
 	},
 	Encoders: cmds.EncoderMap{
-		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *filesLsOutput) error {
-			noSort, _ := req.Options[dontSortOptionName].(bool)
-			if !noSort {
-				sort.Slice(out.Entries, func(i, j int) bool {
-					return strings.Compare(out.Entries[i].Name, out.Entries[j].Name) < 0
-				})
-			}
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *filesResolveOutput) error {
+			if out.Step != nil {
+				s := out.Step
+				if !s.Found {
+					fmt.Fprintf(w, "%s: %q not found\n", s.Cid, s.Name)
+					return nil
+				}
 
-			long, _ := req.Options[longOptionName].(bool)
-			for _, o := range out.Entries {
-				if long {
-					if o.Type == int(mfs.TDir) {
-						o.Name += "/"
-					}
-					fmt.Fprintf(w, "%s\t%s\t%d\n", o.Name, o.Hash, o.Size)
-				} else {
-					fmt.Fprintf(w, "%s\n", o.Name)
+				typ := s.Type
+				if typ == "" {
+					typ = "unknown"
+				}
+				where := "local"
+				if !s.Local {
+					where = "fetched"
 				}
+				fmt.Fprintf(w, "%s: %q found, %s %s (%s)\n", s.Cid, s.Name, typ, s.Child, where)
+				return nil
 			}
 
+			fmt.Fprintln(w, out.Cid)
 			return nil
 		}),
 	},
-	Type: filesLsOutput{},
+	Type: filesResolveOutput{},
 }
 
 const (
@@ -529,6 +1486,7 @@ Examples:
 	Options: []cmdkit.Option{
 		cmdkit.Int64Option(filesOffsetOptionName, "o", "Byte offset to begin reading from."),
 		cmdkit.Int64Option(filesCountOptionName, "n", "Maximum number of bytes to read."),
+		cmdkit.BoolOption(filesStatsOptionName, "Report transfer timing statistics after the content."),
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		nd, err := cmdenv.GetNode(env)
@@ -585,7 +1543,25 @@ Examples:
 			}
 			r = io.LimitReader(r, int64(count))
 		}
-		return res.Emit(r)
+
+		withStats, _ := req.Options[filesStatsOptionName].(bool)
+		if !withStats {
+			return res.Emit(r)
+		}
+
+		start := time.Now()
+		tr := &timedReader{r: r}
+		if err := res.Emit(tr); err != nil {
+			return err
+		}
+
+		return res.Emit(newTransferStats(tr.bytes, start, tr.dur, 0, 0))
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, st *filesTransferStats) error {
+			fmt.Fprintf(w, "\n%s\n", st.trailer())
+			return nil
+		}),
 	},
 }
 
@@ -602,6 +1578,138 @@ func (crw *contextReaderWrapper) Read(b []byte) (int, error) {
 	return crw.R.CtxReadFull(crw.ctx, b)
 }
 
+// checksumHashers maps the supported --hash values to their constructors.
+var checksumHashers = map[string]func() hash.Hash{
+	"sha1":     sha1.New,
+	"sha2-256": sha256.New,
+	"sha2-512": sha512.New,
+}
+
+type checksumVerifyOutput struct {
+	Path   string
+	Hash   string
+	Digest string
+}
+
+var filesChecksumVerifyCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Verify a file in mfs against an expected checksum.",
+		ShortDescription: `
+Stream a file through the requested hash function and compare the digest
+against the hex-encoded one given as an argument. The command exits nonzero
+on a mismatch, printing both digests. '--offset' and '--count' restrict the
+check to a byte range, just like 'ipfs files read'.
+
+Examples:
+
+    $ ipfs files read /test/hello | sha256sum
+    5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  -
+    $ ipfs files checksum-verify /test/hello 5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03
+    /test/hello: OK
+`,
+	},
+
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("path", true, false, "Path to file to be verified."),
+		cmdkit.StringArg("digest", true, false, "Expected hex-encoded digest."),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption(filesHashOptionName, "Hash function to verify with {sha1, sha2-256, sha2-512}.").WithDefault("sha2-256"),
+		cmdkit.Int64Option(filesOffsetOptionName, "o", "Byte offset to begin verifying from."),
+		cmdkit.Int64Option(filesCountOptionName, "n", "Maximum number of bytes to verify."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		nd, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+
+		path, err := checkPath(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		hashFunc, _ := req.Options[filesHashOptionName].(string)
+		mkHasher, ok := checksumHashers[hashFunc]
+		if !ok {
+			return fmt.Errorf("unsupported hash function: %s", hashFunc)
+		}
+
+		expected := strings.ToLower(req.Arguments[1])
+		if _, err := hex.DecodeString(expected); err != nil {
+			return fmt.Errorf("digest is not valid hex: %s", err)
+		}
+
+		fsn, err := mfs.Lookup(nd.FilesRoot, path)
+		if err != nil {
+			return err
+		}
+
+		fi, ok := fsn.(*mfs.File)
+		if !ok {
+			return fmt.Errorf("%s was not a file", path)
+		}
+
+		rfd, err := fi.Open(mfs.OpenReadOnly, false)
+		if err != nil {
+			return err
+		}
+
+		defer rfd.Close()
+
+		offset, _ := req.Options[filesOffsetOptionName].(int64)
+		if offset < 0 {
+			return fmt.Errorf("cannot specify negative offset")
+		}
+
+		filen, err := rfd.Size()
+		if err != nil {
+			return err
+		}
+
+		if offset > filen {
+			return fmt.Errorf("offset was past end of file (%d > %d)", offset, filen)
+		}
+
+		_, err = rfd.Seek(offset, io.SeekStart)
+		if err != nil {
+			return err
+		}
+
+		var r io.Reader = &contextReaderWrapper{R: rfd, ctx: req.Context}
+		count, found := req.Options[filesCountOptionName].(int64)
+		if found {
+			if count < 0 {
+				return fmt.Errorf("cannot specify negative 'count'")
+			}
+			r = io.LimitReader(r, count)
+		}
+
+		hasher := mkHasher()
+		if _, err := io.Copy(hasher, r); err != nil {
+			return err
+		}
+
+		actual := hex.EncodeToString(hasher.Sum(nil))
+		if actual != expected {
+			return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", path, expected, actual)
+		}
+
+		return cmds.EmitOnce(res, &checksumVerifyOutput{
+			Path:   path,
+			Hash:   hashFunc,
+			Digest: actual,
+		})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *checksumVerifyOutput) error {
+			fmt.Fprintf(w, "%s: OK\n", out.Path)
+			return nil
+		}),
+	},
+	Type: checksumVerifyOutput{},
+}
+
 var filesMvCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Move files.",
@@ -629,13 +1737,46 @@ Example:
 		if err != nil {
 			return err
 		}
+		src = strings.TrimRight(src, "/")
+
 		dst, err := checkPath(req.Arguments[1])
 		if err != nil {
 			return err
 		}
 
-		return mfs.Mv(nd.FilesRoot, src, dst)
+		dst, err = resolveMvDest(nd.FilesRoot, src, dst)
+		if err != nil {
+			return err
+		}
+
+		if err := mfs.Mv(nd.FilesRoot, src, dst); err != nil {
+			return err
+		}
+
+		return emitQuiet(req, res, nd.FilesRoot, dst)
 	},
+	Encoders: filesQuietEncoders,
+	Type:     filesQuietOutput{},
+}
+
+// resolveMvDest applies shell-style mv semantics to dst: a destination
+// ending in '/' or naming an existing directory gets the source basename
+// appended, and moving a path onto itself or into its own descendant is
+// rejected since that would corrupt the tree.
+func resolveMvDest(root *mfs.Root, src, dst string) (string, error) {
+	if dst[len(dst)-1] == '/' {
+		dst += gopath.Base(src)
+	} else if fsn, err := mfs.Lookup(root, dst); err == nil {
+		if _, ok := fsn.(*mfs.Directory); ok {
+			dst += "/" + gopath.Base(src)
+		}
+	}
+
+	if dst == src || strings.HasPrefix(dst, src+"/") {
+		return "", fmt.Errorf("mv: cannot move %s to %s, which is inside it", src, dst)
+	}
+
+	return dst, nil
 }
 
 const (
@@ -644,8 +1785,85 @@ const (
 	filesTruncateOptionName  = "truncate"
 	filesRawLeavesOptionName = "raw-leaves"
 	filesFlushOptionName     = "flush"
+	filesSplitOptionName     = "split"
+	filesFromOptionName      = "from"
+	filesStatsOptionName     = "stats"
+	filesExclusiveOptionName = "exclusive"
+	filesModeOptionName      = "mode"
+	filesMtimeOptionName     = "mtime"
 )
 
+type filesWriteOutput struct {
+	Files []string
+	Cid   string              `json:",omitempty"`
+	Stats *filesTransferStats `json:",omitempty"`
+}
+
+// filesTransferStats reports the timing of a files read or write, for
+// performance debugging with '--stats'. Durations are in nanoseconds.
+type filesTransferStats struct {
+	Bytes        int64
+	WallTime     time.Duration
+	CopyTime     time.Duration
+	DagWriteTime time.Duration `json:",omitempty"`
+	FlushTime    time.Duration `json:",omitempty"`
+
+	// Throughput is in bytes per second
+	Throughput float64
+}
+
+// newTransferStats fills in wall time and throughput for a transfer of n
+// bytes that started at start.
+func newTransferStats(n int64, start time.Time, copyTime, dagTime, flushTime time.Duration) *filesTransferStats {
+	st := &filesTransferStats{
+		Bytes:        n,
+		WallTime:     time.Since(start),
+		CopyTime:     copyTime,
+		DagWriteTime: dagTime,
+		FlushTime:    flushTime,
+	}
+	if st.WallTime > 0 {
+		st.Throughput = float64(n) / st.WallTime.Seconds()
+	}
+	return st
+}
+
+// trailer renders the stats as a single line for text output.
+func (st *filesTransferStats) trailer() string {
+	return fmt.Sprintf("%d bytes in %v (%.2f MB/s; copy %v, dag %v, flush %v)",
+		st.Bytes, st.WallTime, st.Throughput/(1<<20), st.CopyTime, st.DagWriteTime, st.FlushTime)
+}
+
+// timedWriter counts the time spent in the wrapped Write calls, separating
+// the cost of DAG writes from that of reading the source in a copy loop.
+type timedWriter struct {
+	w   io.Writer
+	dur time.Duration
+}
+
+func (tw *timedWriter) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := tw.w.Write(p)
+	tw.dur += time.Since(start)
+	return n, err
+}
+
+// timedReader is the read-side counterpart, timing the DAG reads feeding
+// the response.
+type timedReader struct {
+	r     io.Reader
+	bytes int64
+	dur   time.Duration
+}
+
+func (tr *timedReader) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := tr.r.Read(p)
+	tr.dur += time.Since(start)
+	tr.bytes += int64(n)
+	return n, err
+}
+
 var filesWriteCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Write to a mutable file in a given filesystem.",
@@ -672,6 +1890,12 @@ EXAMPLE:
 
     echo "hello world" | ipfs files write --create /myfs/a/b/file
     echo "hello world" | ipfs files write --truncate /myfs/a/b/file
+    ipfs files write --create --from /ipfs/QmSomeHash /myfs/a/b/file
+
+The '--from' option reads the data from an existing IPFS path instead of the
+request body, copying the bytes into the target file. This re-chunks and
+re-hashes the content according to the options above rather than referencing
+the existing nodes (use 'ipfs files cp' for that).
 
 WARNING:
 
@@ -682,7 +1906,7 @@ stat' on the file or any of its ancestors.
 	},
 	Arguments: []cmdkit.Argument{
 		cmdkit.StringArg("path", true, false, "Path to write to."),
-		cmdkit.FileArg("data", true, false, "Data to write.").EnableStdin(),
+		cmdkit.FileArg("data", false, false, "Data to write (not needed with --from).").EnableStdin(),
 	},
 	Options: []cmdkit.Option{
 		cmdkit.Int64Option(filesOffsetOptionName, "o", "Byte offset to begin writing at."),
@@ -691,6 +1915,10 @@ stat' on the file or any of its ancestors.
 		cmdkit.BoolOption(filesTruncateOptionName, "t", "Truncate the file to size zero before writing."),
 		cmdkit.Int64Option(filesCountOptionName, "n", "Maximum number of bytes to read."),
 		cmdkit.BoolOption(filesRawLeavesOptionName, "Use raw blocks for newly created leaf nodes. (experimental)"),
+		cmdkit.Int64Option(filesSplitOptionName, "Split input across sequentially-named files capped at the given byte size."),
+		cmdkit.StringOption(filesFromOptionName, "Read the data from an IPFS path instead of the request body."),
+		cmdkit.BoolOption(filesStatsOptionName, "Report transfer timing statistics."),
+		cmdkit.BoolOption(filesExclusiveOptionName, "x", "Hold the file's write lock for the whole write, serializing concurrent writers."),
 		cidVersionOption,
 		hashOption,
 	},
@@ -728,6 +1956,83 @@ stat' on the file or any of its ancestors.
 			}
 		}
 
+		fromPath, fromFound := req.Options[filesFromOptionName].(string)
+		if fromFound {
+			if req.Files != nil {
+				if it := req.Files.Entries(); it.Next() {
+					return fmt.Errorf("cannot use --from with data supplied in the request")
+				}
+			}
+		} else if req.Files == nil {
+			return fmt.Errorf("missing data: supply a file argument or use --from")
+		}
+
+		// getSrc returns the data source for the write: the content resolved
+		// from --from, streamed through the unixfs reader, or the request
+		// body. Writing from an IPFS path copies the bytes, so the target is
+		// chunked and hashed according to this command's options rather than
+		// referencing the existing nodes.
+		getSrc := func() (io.Reader, error) {
+			if !fromFound {
+				return cmdenv.GetFileArg(req.Files.Entries())
+			}
+
+			fp, err := iface.ParsePath(fromPath)
+			if err != nil {
+				return nil, err
+			}
+
+			api, err := cmdenv.GetApi(env, req)
+			if err != nil {
+				return nil, err
+			}
+
+			f, err := api.Unixfs().Get(req.Context, fp)
+			if err != nil {
+				return nil, err
+			}
+
+			fi, ok := f.(files.File)
+			if !ok {
+				return nil, iface.ErrNotFile
+			}
+
+			return fi, nil
+		}
+
+		splitSize, splitFound := req.Options[filesSplitOptionName].(int64)
+		if splitFound {
+			if splitSize <= 0 {
+				return fmt.Errorf("split size must be positive")
+			}
+			if offset != 0 || trunc {
+				return fmt.Errorf("cannot combine --split with --offset or --truncate")
+			}
+
+			count, countfound := req.Options[filesCountOptionName].(int64)
+			if countfound && count < 0 {
+				return fmt.Errorf("cannot have negative byte count")
+			}
+
+			r, err := getSrc()
+			if err != nil {
+				return err
+			}
+			if countfound {
+				r = io.LimitReader(r, count)
+			}
+
+			created, err := splitWrite(nd.FilesRoot, path, r, splitSize, flush, prefix, rawLeaves, rawLeavesDef)
+			if err != nil {
+				return err
+			}
+
+			return cmds.EmitOnce(re, &filesWriteOutput{Files: created})
+		}
+
+		withStats, _ := req.Options[filesStatsOptionName].(bool)
+		start := time.Now()
+
 		fi, err := getFileHandle(nd.FilesRoot, path, create, prefix)
 		if err != nil {
 			return err
@@ -736,51 +2041,161 @@ stat' on the file or any of its ancestors.
 			fi.RawLeaves = rawLeaves
 		}
 
-		wfd, err := fi.Open(mfs.OpenWriteOnly, flush)
+		openMode := mfs.OpenWriteOnly
+		if exclusive, _ := req.Options[filesExclusiveOptionName].(bool); exclusive {
+			openMode = mfs.OpenExclusive
+		}
+
+		wfd, err := fi.Open(openMode, flush)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			err := wfd.Close()
+			if err != nil {
+				if retErr == nil {
+					retErr = err
+				} else {
+					log.Error("files: error closing file mfs file descriptor", err)
+				}
+			}
+		}()
+
+		if trunc {
+			if err := wfd.Truncate(0); err != nil {
+				return err
+			}
+		}
+
+		count, countfound := req.Options[filesCountOptionName].(int64)
+		if countfound && count < 0 {
+			return fmt.Errorf("cannot have negative byte count")
+		}
+
+		_, err = wfd.Seek(int64(offset), io.SeekStart)
+		if err != nil {
+			flog.Error("seekfail: ", err)
+			return err
+		}
+
+		r, err := getSrc()
+		if err != nil {
+			return err
+		}
+		if countfound {
+			r = io.LimitReader(r, int64(count))
+		}
+
+		if !withStats {
+			if _, err := io.Copy(wfd, r); err != nil {
+				return err
+			}
+
+			quiet, _ := req.Options[quietOptionName].(bool)
+			quieter, _ := req.Options[quieterOptionName].(bool)
+			switch {
+			case quieter:
+				// the descriptor has to be flushed before the final
+				// cid is known
+				if err := wfd.Flush(); err != nil {
+					return err
+				}
+				fnd, err := fi.GetNode()
+				if err != nil {
+					return err
+				}
+				return cmds.EmitOnce(re, &filesWriteOutput{Cid: fnd.Cid().String()})
+			case quiet:
+				return cmds.EmitOnce(re, &filesWriteOutput{Files: []string{path}})
+			}
+			return nil
+		}
+
+		tw := &timedWriter{w: wfd}
+		copyStart := time.Now()
+		n, err := io.Copy(tw, r)
 		if err != nil {
 			return err
 		}
+		copyTime := time.Since(copyStart)
 
-		defer func() {
-			err := wfd.Close()
-			if err != nil {
-				if retErr == nil {
-					retErr = err
-				} else {
-					log.Error("files: error closing file mfs file descriptor", err)
-				}
+		// flush explicitly so its cost shows up separately from the copy;
+		// the deferred close then has nothing left to do
+		flushStart := time.Now()
+		if flush {
+			if err := wfd.Flush(); err != nil {
+				return err
 			}
-		}()
+		}
+		flushTime := time.Since(flushStart)
 
-		if trunc {
-			if err := wfd.Truncate(0); err != nil {
-				return err
+		return cmds.EmitOnce(re, &filesWriteOutput{
+			Stats: newTransferStats(n, start, copyTime, tw.dur, flushTime),
+		})
+	},
+	Type: filesWriteOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *filesWriteOutput) error {
+			if out.Cid != "" {
+				fmt.Fprintln(w, out.Cid)
+			}
+			for _, f := range out.Files {
+				fmt.Fprintln(w, f)
+			}
+			if out.Stats != nil {
+				fmt.Fprintln(w, out.Stats.trailer())
+			}
+			return nil
+		}),
+	},
+}
+
+// splitWrite writes the data from r across sequentially-named files
+// ("<path>.000", "<path>.001", ...), each capped at splitSize bytes, and
+// returns the list of files created. The final file holds whatever partial
+// chunk remains.
+func splitWrite(root *mfs.Root, path string, r io.Reader, splitSize int64, flush bool, prefix cid.Builder, rawLeaves, rawLeavesDef bool) ([]string, error) {
+	br := bufio.NewReader(r)
+
+	var created []string
+	for i := 0; ; i++ {
+		if i > 0 {
+			// only create another file if there is data left
+			if _, err := br.Peek(1); err == io.EOF {
+				break
+			} else if err != nil {
+				return created, err
 			}
 		}
 
-		count, countfound := req.Options[filesCountOptionName].(int64)
-		if countfound && count < 0 {
-			return fmt.Errorf("cannot have negative byte count")
+		name := fmt.Sprintf("%s.%03d", path, i)
+		fi, err := getFileHandle(root, name, true, prefix)
+		if err != nil {
+			return created, err
+		}
+		if rawLeavesDef {
+			fi.RawLeaves = rawLeaves
 		}
 
-		_, err = wfd.Seek(int64(offset), io.SeekStart)
+		wfd, err := fi.Open(mfs.OpenWriteOnly, flush)
 		if err != nil {
-			flog.Error("seekfail: ", err)
-			return err
+			return created, err
 		}
 
-		var r io.Reader
-		r, err = cmdenv.GetFileArg(req.Files.Entries())
+		_, err = io.Copy(wfd, io.LimitReader(br, splitSize))
+		cerr := wfd.Close()
 		if err != nil {
-			return err
+			return created, err
 		}
-		if countfound {
-			r = io.LimitReader(r, int64(count))
+		if cerr != nil {
+			return created, cerr
 		}
 
-		_, err = io.Copy(wfd, r)
-		return err
-	},
+		created = append(created, name)
+	}
+
+	return created, nil
 }
 
 var filesMkdirCmd = &cmds.Command{
@@ -806,6 +2221,8 @@ Examples:
 	},
 	Options: []cmdkit.Option{
 		cmdkit.BoolOption(filesParentsOptionName, "p", "No error if existing, make parent directories as needed."),
+		cmdkit.StringOption(filesModeOptionName, "Octal unix permission bits to store on the new directory, e.g. 755."),
+		cmdkit.Int64Option(filesMtimeOptionName, "Modification time to store on the new directory, in seconds since the epoch."),
 		cidVersionOption,
 		hashOption,
 	},
@@ -823,6 +2240,20 @@ Examples:
 
 		flush, _ := req.Options[filesFlushOptionName].(bool)
 
+		var mode os.FileMode
+		if modeStr, ok := req.Options[filesModeOptionName].(string); ok {
+			m, err := strconv.ParseUint(modeStr, 8, 32)
+			if err != nil {
+				return fmt.Errorf("invalid mode %q: %s", modeStr, err)
+			}
+			mode = os.FileMode(m)
+		}
+
+		var mtime time.Time
+		if secs, ok := req.Options[filesMtimeOptionName].(int64); ok {
+			mtime = time.Unix(secs, 0)
+		}
+
 		prefix, err := getPrefix(req)
 		if err != nil {
 			return err
@@ -833,10 +2264,17 @@ Examples:
 			Mkparents:  dashp,
 			Flush:      flush,
 			CidBuilder: prefix,
+			Mode:       mode,
+			Mtime:      mtime,
 		})
+		if err != nil {
+			return err
+		}
 
-		return err
+		return emitQuiet(req, res, root, dirtomake)
 	},
+	Encoders: filesQuietEncoders,
+	Type:     filesQuietOutput{},
 }
 
 var filesFlushCmd = &cmds.Command{
@@ -861,8 +2299,14 @@ are run with the '--flush=false'.
 			path = req.Arguments[0]
 		}
 
-		return mfs.FlushPath(nd.FilesRoot, path)
+		if err := mfs.FlushPath(nd.FilesRoot, path); err != nil {
+			return err
+		}
+
+		return emitQuiet(req, res, nd.FilesRoot, path)
 	},
+	Encoders: filesQuietEncoders,
+	Type:     filesQuietOutput{},
 }
 
 var filesChcidCmd = &cmds.Command{
@@ -897,10 +2341,157 @@ Change the cid version or hash function of the root node of a given path.
 			return err
 		}
 
-		return updatePath(nd.FilesRoot, path, prefix, flush)
+		if err := updatePath(nd.FilesRoot, path, prefix, flush); err != nil {
+			return err
+		}
+
+		return emitQuiet(req, res, nd.FilesRoot, path)
+	},
+	Encoders: filesQuietEncoders,
+	Type:     filesQuietOutput{},
+}
+
+const filesLayoutOptionName = "layout"
+
+var filesRelayoutCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Rebuild a file with a different DAG layout.",
+		ShortDescription: `
+Streams the file at the given path through a new DAG builder using the
+requested layout ("balanced" for random access, "trickle" for sequential
+streaming) and replaces the MFS entry in place. The rebuilt content is
+verified to match the original before the entry is swapped.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("path", true, false, "Path to the file to rebuild."),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption(filesLayoutOptionName, "The target DAG layout, \"balanced\" or \"trickle\".").WithDefault("balanced"),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		nd, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+
+		path, err := checkPath(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		var layout importer.Layout
+		layoutStr, _ := req.Options[filesLayoutOptionName].(string)
+		switch layoutStr {
+		case "balanced":
+			layout = importer.BalancedLayout
+		case "trickle":
+			layout = importer.TrickleLayout
+		default:
+			return fmt.Errorf("unknown layout '%s', must be one of {balanced, trickle}", layoutStr)
+		}
+
+		flush, _ := req.Options[filesFlushOptionName].(bool)
+
+		fsn, err := mfs.Lookup(nd.FilesRoot, path)
+		if err != nil {
+			return err
+		}
+		fi, ok := fsn.(*mfs.File)
+		if !ok {
+			return fmt.Errorf("%s is not a file", path)
+		}
+
+		fnd, err := fi.GetNode()
+		if err != nil {
+			return err
+		}
+
+		newCid, err := importer.Relayout(req.Context, nd.DAG, fnd.Cid(), layout, h.DagBuilderParams{
+			Dagserv:    nd.DAG,
+			CidBuilder: fnd.Cid().Prefix(),
+		})
+		if err != nil {
+			return err
+		}
+
+		// already in the requested layout
+		if newCid.Equals(fnd.Cid()) {
+			return nil
+		}
+
+		newNd, err := nd.DAG.Get(req.Context, newCid)
+		if err != nil {
+			return err
+		}
+
+		dir, name := gopath.Split(path)
+		parent, err := mfs.Lookup(nd.FilesRoot, dir)
+		if err != nil {
+			return fmt.Errorf("parent lookup: %s", err)
+		}
+
+		pdir, ok := parent.(*mfs.Directory)
+		if !ok {
+			return fmt.Errorf("no such file or directory: %s", path)
+		}
+
+		if err := pdir.Unlink(name); err != nil {
+			return err
+		}
+		if err := pdir.AddChild(name, newNd); err != nil {
+			return err
+		}
+
+		if flush {
+			return mfs.FlushPath(nd.FilesRoot, path)
+		}
+		return nil
 	},
 }
 
+// filesQuietOutput is emitted by mutating files commands when --quiet or
+// --quieter is set: the affected mfs path, or just the resulting cid.
+type filesQuietOutput struct {
+	Path string `json:",omitempty"`
+	Cid  string `json:",omitempty"`
+}
+
+var filesQuietEncoders = cmds.EncoderMap{
+	cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *filesQuietOutput) error {
+		if out.Cid != "" {
+			fmt.Fprintln(w, out.Cid)
+		} else {
+			fmt.Fprintln(w, out.Path)
+		}
+		return nil
+	}),
+}
+
+// emitQuiet reports the affected path (--quiet) or its cid (--quieter) for
+// commands that are otherwise silent on success.
+func emitQuiet(req *cmds.Request, res cmds.ResponseEmitter, root *mfs.Root, path string) error {
+	quiet, _ := req.Options[quietOptionName].(bool)
+	quieter, _ := req.Options[quieterOptionName].(bool)
+	if !quiet && !quieter {
+		return nil
+	}
+
+	if !quieter {
+		return res.Emit(&filesQuietOutput{Path: path})
+	}
+
+	fsn, err := mfs.Lookup(root, path)
+	if err != nil {
+		return err
+	}
+	nd, err := fsn.GetNode()
+	if err != nil {
+		return err
+	}
+	return res.Emit(&filesQuietOutput{Cid: nd.Cid().String()})
+}
+
 func updatePath(rt *mfs.Root, pth string, builder cid.Builder, flush bool) error {
 	if builder == nil {
 		return nil
@@ -925,6 +2516,123 @@ func updatePath(rt *mfs.Root, pth string, builder cid.Builder, flush bool) error
 	return nil
 }
 
+type filesChmodOutput struct {
+	Path string
+}
+
+var filesChmodCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Change the unix permission bits stored on a path.",
+		ShortDescription: `
+Update the unix permission bits stored in the unixfs metadata of a file or
+directory. Only the metadata and the parent links are rebuilt; file content
+blocks keep their cids. With -R the mode is applied to the target and,
+recursively, to everything below it, streaming each updated path.
+
+Examples:
+
+    $ ipfs files chmod 644 /docs/readme.txt
+    $ ipfs files chmod -R 755 /docs
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("mode", true, false, "Octal unix permission bits to store, e.g. 755."),
+		cmdkit.StringArg("path", true, false, "Path to update."),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption(recursiveOptionName, "R", "Apply the mode recursively to all children."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		nd, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+
+		m, err := strconv.ParseUint(req.Arguments[0], 8, 32)
+		if err != nil || m == 0 || m > 07777 {
+			return fmt.Errorf("invalid mode %q", req.Arguments[0])
+		}
+		mode := os.FileMode(m)
+
+		path, err := checkPath(req.Arguments[1])
+		if err != nil {
+			return err
+		}
+
+		recursive, _ := req.Options[recursiveOptionName].(bool)
+		flush, _ := req.Options[filesFlushOptionName].(bool)
+
+		err = chmodPath(req.Context, nd.FilesRoot, path, mode, recursive, func(updated string) error {
+			return res.Emit(&filesChmodOutput{Path: updated})
+		})
+		if err != nil {
+			return err
+		}
+
+		if flush {
+			return mfs.FlushPath(nd.FilesRoot, path)
+		}
+		return nil
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *filesChmodOutput) error {
+			fmt.Fprintln(w, out.Path)
+			return nil
+		}),
+	},
+	Type: filesChmodOutput{},
+}
+
+// chmodPath stores the mode on the node at path, after recursing into its
+// children when recursive is set. Children are updated first so the parent's
+// node is rebuilt only once with all child links already final. Every updated
+// path is reported through emit.
+func chmodPath(ctx context.Context, root *mfs.Root, path string, mode os.FileMode, recursive bool, emit func(string) error) error {
+	fsn, err := mfs.Lookup(root, path)
+	if err != nil {
+		return err
+	}
+
+	if dir, ok := fsn.(*mfs.Directory); ok && recursive {
+		names, err := dir.ListNames(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
+			if err := chmodPath(ctx, root, gopath.Join(path, name), mode, true, emit); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch n := fsn.(type) {
+	case *mfs.Directory:
+		err = n.SetMetadata(mode, time.Time{})
+	case *mfs.File:
+		err = n.SetMetadata(mode, time.Time{})
+	default:
+		return fmt.Errorf("cannot chmod %s: unsupported node type", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	return emit(path)
+}
+
+const filesDryRunOptionName = "dry-run"
+
+type filesRmOutput struct {
+	Path string
+}
+
 var filesRmCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Remove a file.",
@@ -937,6 +2645,9 @@ Remove files or directories.
     dog
     fish
     $ ipfs files rm -r /bar
+
+With '--dry-run' the paths that would be removed are printed instead,
+leaving the tree untouched.
 `,
 	},
 
@@ -946,6 +2657,7 @@ Remove files or directories.
 	Options: []cmdkit.Option{
 		cmdkit.BoolOption(recursiveOptionName, "r", "Recursively remove directories."),
 		cmdkit.BoolOption(forceOptionName, "Forcibly remove target at path; implies -r for directories"),
+		cmdkit.BoolOption(filesDryRunOptionName, "Print what would be removed without modifying the tree."),
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		nd, err := cmdenv.GetNode(env)
@@ -967,6 +2679,14 @@ Remove files or directories.
 			path = path[:len(path)-1]
 		}
 
+		if dryRun, _ := req.Options[filesDryRunOptionName].(bool); dryRun {
+			dashr, _ := req.Options[recursiveOptionName].(bool)
+			force, _ := req.Options[forceOptionName].(bool)
+			return rmDryRun(req.Context, nd.FilesRoot, path, dashr, force, func(p string) error {
+				return res.Emit(&filesRmOutput{Path: p})
+			})
+		}
+
 		dir, name := gopath.Split(path)
 		parent, err := mfs.Lookup(nd.FilesRoot, dir)
 		if err != nil {
@@ -987,7 +2707,11 @@ Remove files or directories.
 				return err
 			}
 
-			return pdir.Flush()
+			if err := pdir.Flush(); err != nil {
+				return err
+			}
+
+			return rmEmitQuiet(req, res, path)
 		}
 
 		// get child node by name, when the node is corrupted and nonexistent,
@@ -1011,8 +2735,69 @@ Remove files or directories.
 			return err
 		}
 
-		return pdir.Flush()
+		if err := pdir.Flush(); err != nil {
+			return err
+		}
+
+		return rmEmitQuiet(req, res, path)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *filesRmOutput) error {
+			fmt.Fprintln(w, out.Path)
+			return nil
+		}),
 	},
+	Type: filesRmOutput{},
+}
+
+// rmEmitQuiet reports the removed path when --quiet or --quieter is set.
+// Removed entries no longer have a cid, so both options print the path.
+func rmEmitQuiet(req *cmds.Request, res cmds.ResponseEmitter, path string) error {
+	quiet, _ := req.Options[quietOptionName].(bool)
+	quieter, _ := req.Options[quieterOptionName].(bool)
+	if !quiet && !quieter {
+		return nil
+	}
+	return res.Emit(&filesRmOutput{Path: path})
+}
+
+// rmDryRun emits the absolute mfs path of every entry that removing path
+// would unlink, walking the subtree for directories. It performs the same
+// checks as the real removal but never modifies or flushes the tree.
+func rmDryRun(ctx context.Context, root *mfs.Root, path string, recursive, force bool, emit func(string) error) error {
+	dir, name := gopath.Split(path)
+	parent, err := mfs.Lookup(root, dir)
+	if err != nil {
+		return fmt.Errorf("parent lookup: %s", err)
+	}
+
+	pdir, ok := parent.(*mfs.Directory)
+	if !ok {
+		return fmt.Errorf("no such file or directory: %s", path)
+	}
+
+	child, err := pdir.Child(name)
+	if err != nil {
+		// '--force' removes entries whose nodes cannot be loaded, so a
+		// dry run still reports them
+		if !force {
+			return err
+		}
+		return emit(path)
+	}
+
+	if _, ok := child.(*mfs.Directory); ok {
+		if !recursive && !force {
+			return fmt.Errorf("%s is a directory, use -r to remove directories", path)
+		}
+
+		if err := emit(path); err != nil {
+			return err
+		}
+		return findMatches(ctx, root, path, "", "", emit)
+	}
+
+	return emit(path)
 }
 
 func getPrefixNew(req *cmds.Request) (cid.Builder, error) {
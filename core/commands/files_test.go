@@ -0,0 +1,1157 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	bservice "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-blockservice"
+	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
+	ds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore"
+	dssync "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore/sync"
+	blockstore "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-blockstore"
+	"github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmdkit"
+	"github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmds"
+	offline "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-exchange-offline"
+	dag "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-merkledag"
+	mfs "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-mfs"
+	ft "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs"
+)
+
+func TestAncestorChain(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	dserv := dag.NewDAGService(bservice.New(bs, offline.Exchange(bs)))
+
+	root, err := mfs.NewRoot(ctx, dserv, ft.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mfs.Mkdir(root, "/a/b/c", mfs.MkdirOpts{Mkparents: true, Flush: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err := ancestorChain(root, "/a/b/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"/", "/a", "/a/b", "/a/b/c"}
+	if len(chain) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(chain))
+	}
+
+	for i, p := range expected {
+		if chain[i].Path != p {
+			t.Errorf("unexpected entry %d path: %s", i, chain[i].Path)
+		}
+
+		fsn, err := mfs.Lookup(root, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nd, err := fsn.GetNode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chain[i].Hash != nd.Cid().String() {
+			t.Errorf("unexpected cid for %s: %s", p, chain[i].Hash)
+		}
+	}
+
+	if _, err := ancestorChain(root, "/a/missing"); err == nil {
+		t.Fatal("expected an error for a missing path")
+	}
+}
+
+func countBlocks(t *testing.T, bs blockstore.Blockstore) int {
+	ch, err := bs.AllKeysChan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for range ch {
+		count++
+	}
+	return count
+}
+
+func TestCloneDir(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	dserv := dag.NewDAGService(bservice.New(bs, offline.Exchange(bs)))
+
+	root, err := mfs.NewRoot(ctx, dserv, ft.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mfs.Mkdir(root, "/a/b/c", mfs.MkdirOpts{Mkparents: true, Flush: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := countBlocks(t, bs)
+
+	node, err := cloneDir(root, "/a", "/a2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the clone links the very same nodes
+	fsn, err := mfs.Lookup(root, "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcnd, err := fsn.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !node.Cid().Equals(srcnd.Cid()) {
+		t.Fatalf("expected the clone to share the source cid, got %s != %s", node.Cid(), srcnd.Cid())
+	}
+
+	if _, err := mfs.Lookup(root, "/a2/b/c"); err != nil {
+		t.Fatalf("expected the cloned subtree to be reachable: %s", err)
+	}
+
+	// zero bytes were copied
+	if after := countBlocks(t, bs); after != before {
+		t.Fatalf("expected cloning to add no blocks, had %d, now %d", before, after)
+	}
+
+	// modifying the clone must not affect the original
+	err = mfs.Mkdir(root, "/a2/d", mfs.MkdirOpts{Flush: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := mfs.Lookup(root, "/a/d"); err == nil {
+		t.Fatal("expected a modification of the clone to leave the original untouched")
+	}
+
+	fsn, err = mfs.Lookup(root, "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nd, err := fsn.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !nd.Cid().Equals(srcnd.Cid()) {
+		t.Fatal("expected the original directory cid to be unchanged")
+	}
+
+	// cloning a file is refused
+	if _, err := cloneDir(root, "/a/b/c/missing", "/x"); err == nil {
+		t.Fatal("expected an error cloning a missing path")
+	}
+}
+
+func TestFetchToDepth(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	dserv := dag.NewDAGService(bservice.New(bs, offline.Exchange(bs)))
+
+	// build top -> mid -> leaf, with only top and mid available locally
+	leaf := ft.EmptyDirNode()
+	mid := ft.EmptyDirNode()
+	if err := mid.AddNodeLink("leaf", leaf); err != nil {
+		t.Fatal(err)
+	}
+	top := ft.EmptyDirNode()
+	if err := top.AddNodeLink("mid", mid); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dserv.Add(ctx, mid); err != nil {
+		t.Fatal(err)
+	}
+	if err := dserv.Add(ctx, top); err != nil {
+		t.Fatal(err)
+	}
+
+	// depth 1 links only the top level, nothing else is resolved
+	if err := fetchToDepth(ctx, dserv, top, 1); err != nil {
+		t.Fatalf("depth 1 should not touch children: %s", err)
+	}
+
+	// depth 2 resolves mid but leaves leaf a stub
+	if err := fetchToDepth(ctx, dserv, top, 2); err != nil {
+		t.Fatalf("depth 2 should only need the middle level: %s", err)
+	}
+
+	// going past the available levels tries to resolve the stub and fails
+	if err := fetchToDepth(ctx, dserv, top, 3); err == nil {
+		t.Fatal("expected an error resolving the stubbed leaf offline")
+	}
+}
+
+func TestResolveMvDest(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	dserv := dag.NewDAGService(bservice.New(bs, offline.Exchange(bs)))
+
+	root, err := mfs.NewRoot(ctx, dserv, ft.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mfs.Mkdir(root, "/dir", mfs.MkdirOpts{Mkparents: true, Flush: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mfs.Mkdir(root, "/other", mfs.MkdirOpts{Mkparents: true, Flush: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filend := dag.NodeWithData(ft.FilePBData([]byte("data"), 4))
+	if err := dserv.Add(ctx, filend); err != nil {
+		t.Fatal(err)
+	}
+	if err := mfs.PutNode(root, "/file", filend); err != nil {
+		t.Fatal(err)
+	}
+
+	// a destination that isn't a directory is left alone
+	dst, err := resolveMvDest(root, "/file", "/renamed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst != "/renamed" {
+		t.Fatalf("unexpected destination: %s", dst)
+	}
+
+	// moving a file into an existing directory appends the basename
+	dst, err = resolveMvDest(root, "/file", "/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst != "/dir/file" {
+		t.Fatalf("unexpected destination: %s", dst)
+	}
+	if err := mfs.Mv(root, "/file", dst); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mfs.Lookup(root, "/dir/file"); err != nil {
+		t.Fatalf("expected the file inside the directory: %s", err)
+	}
+
+	// a trailing slash appends the basename even before resolving
+	dst, err = resolveMvDest(root, "/dir/file", "/other/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst != "/other/file" {
+		t.Fatalf("unexpected destination: %s", dst)
+	}
+
+	// moving a directory into a directory nests it under its own name
+	dst, err = resolveMvDest(root, "/other", "/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst != "/dir/other" {
+		t.Fatalf("unexpected destination: %s", dst)
+	}
+	if err := mfs.Mv(root, "/other", dst); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mfs.Lookup(root, "/dir/other"); err != nil {
+		t.Fatalf("expected the directory to be nested: %s", err)
+	}
+
+	// a directory can't be moved into its own descendant or onto itself
+	if _, err := resolveMvDest(root, "/dir", "/dir/other"); err == nil {
+		t.Fatal("expected an error moving a directory into its descendant")
+	}
+	if _, err := resolveMvDest(root, "/dir", "/dir/"); err == nil {
+		t.Fatal("expected an error moving a directory into itself")
+	}
+}
+
+func TestFindMatches(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	dserv := dag.NewDAGService(bservice.New(bs, offline.Exchange(bs)))
+
+	root, err := mfs.NewRoot(ctx, dserv, ft.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mfs.Mkdir(root, "/docs/images", mfs.MkdirOpts{Mkparents: true, Flush: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"/readme.txt", "/docs/notes.txt", "/docs/images/logo.png"} {
+		filend := dag.NodeWithData(ft.FilePBData([]byte(p), uint64(len(p))))
+		if err := dserv.Add(ctx, filend); err != nil {
+			t.Fatal(err)
+		}
+		if err := mfs.PutNode(root, p, filend); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	find := func(path, pattern, typeFilter string) []string {
+		var out []string
+		err := findMatches(ctx, root, path, pattern, typeFilter, func(match string) error {
+			out = append(out, match)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	got := find("/", "*.txt", "")
+	expected := []string{"/docs/notes.txt", "/readme.txt"}
+	sort.Strings(got)
+	if len(got) != len(expected) {
+		t.Fatalf("unexpected matches: %v", got)
+	}
+	for i, p := range expected {
+		if got[i] != p {
+			t.Fatalf("unexpected match %d: %s", i, got[i])
+		}
+	}
+
+	// the type filter narrows matches to directories
+	got = find("/", "*", "d")
+	sort.Strings(got)
+	if len(got) != 2 || got[0] != "/docs" || got[1] != "/docs/images" {
+		t.Fatalf("unexpected directory matches: %v", got)
+	}
+
+	// searching below the start path only sees that subtree
+	got = find("/docs", "*.txt", "f")
+	if len(got) != 1 || got[0] != "/docs/notes.txt" {
+		t.Fatalf("unexpected subtree matches: %v", got)
+	}
+
+	// a bad type filter is rejected
+	err = findMatches(ctx, root, "/", "", "x", func(string) error { return nil })
+	if err == nil {
+		t.Fatal("expected an error for an invalid type filter")
+	}
+
+	// cancellation stops the walk
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	err = findMatches(cctx, root, "/", "", "", func(string) error { return nil })
+	if err == nil {
+		t.Fatal("expected a context error from a cancelled walk")
+	}
+}
+
+func TestWriteTransferStats(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	dserv := dag.NewDAGService(bservice.New(bs, offline.Exchange(bs)))
+
+	root, err := mfs.NewRoot(ctx, dserv, ft.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([]byte, 512*1024)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	// mirror the instrumented write path of filesWriteCmd
+	start := time.Now()
+	fi, err := getFileHandle(root, "/payload", true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wfd, err := fi.Open(mfs.OpenWriteOnly, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tw := &timedWriter{w: wfd}
+	copyStart := time.Now()
+	n, err := io.Copy(tw, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	copyTime := time.Since(copyStart)
+
+	flushStart := time.Now()
+	if err := wfd.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	flushTime := time.Since(flushStart)
+	if err := wfd.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	st := newTransferStats(n, start, copyTime, tw.dur, flushTime)
+
+	if st.Bytes != int64(len(data)) {
+		t.Fatalf("unexpected byte count: %d", st.Bytes)
+	}
+	if st.WallTime <= 0 || st.CopyTime <= 0 || st.DagWriteTime <= 0 {
+		t.Fatalf("expected non-zero timings: %+v", st)
+	}
+	if st.Throughput <= 0 {
+		t.Fatalf("expected a throughput figure: %+v", st)
+	}
+
+	// the phases nest inside the measured wall time
+	if st.CopyTime+st.FlushTime > st.WallTime {
+		t.Fatalf("phases exceed wall time: %+v", st)
+	}
+	if st.DagWriteTime > st.CopyTime {
+		t.Fatalf("dag write time exceeds the copy time containing it: %+v", st)
+	}
+}
+
+func TestSubtreeSize(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	dserv := dag.NewDAGService(bservice.New(bs, offline.Exchange(bs)))
+
+	root, err := mfs.NewRoot(ctx, dserv, ft.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mfs.Mkdir(root, "/d", mfs.MkdirOpts{Mkparents: true, Flush: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// two entries share the exact same content, the third is unique
+	shared := dag.NodeWithData(ft.FilePBData([]byte("shared"), 6))
+	unique := dag.NodeWithData(ft.FilePBData([]byte("different"), 9))
+	for _, nd := range []*dag.ProtoNode{shared, unique} {
+		if err := dserv.Add(ctx, nd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for p, nd := range map[string]*dag.ProtoNode{
+		"/d/one": shared,
+		"/d/two": shared,
+		"/d/three": unique,
+	} {
+		if err := mfs.PutNode(root, p, nd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fsn, err := mfs.Lookup(root, "/d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirnd, err := fsn.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := subtreeSize(ctx, dserv, dirnd, cid.NewSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the shared block is counted once
+	expected := uint64(len(dirnd.RawData()) + len(shared.RawData()) + len(unique.RawData()))
+	if size != expected {
+		t.Fatalf("expected a deduplicated size of %d, got %d", expected, size)
+	}
+}
+
+func TestStatNodeLayout(t *testing.T) {
+	chunk1 := dag.NodeWithData(ft.FilePBData([]byte("data"), 4))
+	chunk2 := dag.NodeWithData(ft.FilePBData([]byte("moardata"), 8))
+
+	// a file node referencing two chunks, as the chunker would build it
+	fsn := ft.NewFSNode(ft.TFile)
+	fsn.AddBlockSize(4)
+	fsn.AddBlockSize(8)
+	fb, err := fsn.GetBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := dag.NodeWithData(fb)
+	if err := file.AddNodeLink("", chunk1); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddNodeLink("", chunk2); err != nil {
+		t.Fatal(err)
+	}
+
+	layout, err := statNodeLayout(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(layout) != 2 {
+		t.Fatalf("expected 2 layout entries, got %d", len(layout))
+	}
+	if layout[0].Size != 4 || layout[1].Size != 8 {
+		t.Fatalf("expected unixfs chunk sizes 4 and 8, got %d and %d", layout[0].Size, layout[1].Size)
+	}
+	if layout[0].Hash != chunk1.Cid().String() || layout[1].Hash != chunk2.Cid().String() {
+		t.Fatal("unexpected layout cids")
+	}
+
+	// directory entries report the link's cumulative size instead
+	dir := ft.EmptyDirNode()
+	if err := dir.AddNodeLink("child", file); err != nil {
+		t.Fatal(err)
+	}
+
+	layout, err = statNodeLayout(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layout) != 1 || layout[0].Name != "child" {
+		t.Fatalf("unexpected directory layout: %v", layout)
+	}
+	if layout[0].Size == 0 {
+		t.Fatal("expected the directory entry to carry the link size")
+	}
+
+	// leaves have no layout
+	layout, err = statNodeLayout(chunk1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout != nil {
+		t.Fatalf("expected no layout for a leaf, got %v", layout)
+	}
+}
+
+func TestMkdirMetadata(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	dserv := dag.NewDAGService(bservice.New(bs, offline.Exchange(bs)))
+
+	root, err := mfs.NewRoot(ctx, dserv, ft.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mtime := time.Unix(1500000000, 0)
+	err = mfs.Mkdir(root, "/a/b", mfs.MkdirOpts{
+		Mkparents: true,
+		Flush:     true,
+		Mode:      0755,
+		Mtime:     mtime,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsn, err := mfs.Lookup(root, "/a/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nd, err := fsn.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := statNode(nd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Mode != "755" {
+		t.Errorf("expected mode 755, got %q", st.Mode)
+	}
+	if st.Mtime != mtime.Unix() {
+		t.Errorf("expected mtime %d, got %d", mtime.Unix(), st.Mtime)
+	}
+
+	// intermediate directories get no metadata
+	fsn, err = mfs.Lookup(root, "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nd, err = fsn.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err = statNode(nd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Mode != "" || st.Mtime != 0 {
+		t.Errorf("expected no metadata on the parent, got mode %q mtime %d", st.Mode, st.Mtime)
+	}
+
+	// without the options the directory node is byte-identical to before
+	if err := mfs.Mkdir(root, "/plain", mfs.MkdirOpts{Flush: true}); err != nil {
+		t.Fatal(err)
+	}
+	fsn, err = mfs.Lookup(root, "/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nd, err = fsn.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !nd.Cid().Equals(ft.EmptyDirNode().Cid()) {
+		t.Error("expected a metadata-less mkdir to produce the canonical empty directory")
+	}
+}
+
+func TestChmodRecursive(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	dserv := dag.NewDAGService(bservice.New(bs, offline.Exchange(bs)))
+
+	root, err := mfs.NewRoot(ctx, dserv, ft.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mfs.Mkdir(root, "/docs/images", mfs.MkdirOpts{Mkparents: true, Flush: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logond := dag.NodeWithData(ft.FilePBData([]byte("logo"), 4))
+	if err := dserv.Add(ctx, logond); err != nil {
+		t.Fatal(err)
+	}
+	if err := mfs.PutNode(root, "/docs/images/logo.png", logond); err != nil {
+		t.Fatal(err)
+	}
+
+	// build a two-leaf file so the test can check the content blocks survive
+	var leaves []cid.Cid
+	fsnd := ft.NewFSNode(ft.TFile)
+	filend := dag.NodeWithData(nil)
+	for _, content := range []string{"aaaa", "bbbb"} {
+		leaf := dag.NodeWithData(ft.FilePBData([]byte(content), uint64(len(content))))
+		if err := dserv.Add(ctx, leaf); err != nil {
+			t.Fatal(err)
+		}
+		if err := filend.AddNodeLink("", leaf); err != nil {
+			t.Fatal(err)
+		}
+		fsnd.AddBlockSize(uint64(len(content)))
+		leaves = append(leaves, leaf.Cid())
+	}
+	data, err := fsnd.GetBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	filend.SetData(data)
+	if err := dserv.Add(ctx, filend); err != nil {
+		t.Fatal(err)
+	}
+	if err := mfs.PutNode(root, "/docs/report", filend); err != nil {
+		t.Fatal(err)
+	}
+
+	var updated []string
+	err = chmodPath(ctx, root, "/docs", 0755, true, func(p string) error {
+		updated = append(updated, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(updated) != 4 || updated[len(updated)-1] != "/docs" {
+		t.Fatalf("expected 4 updates ending with the target itself, got %v", updated)
+	}
+
+	statMode := func(path string) string {
+		fsn, err := mfs.Lookup(root, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nd, err := fsn.GetNode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		st, err := statNode(nd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return st.Mode
+	}
+
+	for _, p := range []string{"/docs", "/docs/images", "/docs/images/logo.png", "/docs/report"} {
+		if m := statMode(p); m != "755" {
+			t.Errorf("expected mode 755 on %s, got %q", p, m)
+		}
+	}
+
+	// the mode does not leak above the target
+	if m := statMode("/"); m != "" {
+		t.Errorf("expected no mode on the root, got %q", m)
+	}
+
+	// only the file's root node was rebuilt, the content blocks keep their cids
+	fsn, err := mfs.Lookup(root, "/docs/report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nd, err := fsn.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nd.Links()) != len(leaves) {
+		t.Fatalf("expected %d leaves, got %d", len(leaves), len(nd.Links()))
+	}
+	for i, lnk := range nd.Links() {
+		if !lnk.Cid.Equals(leaves[i]) {
+			t.Errorf("leaf %d was rewritten: %s != %s", i, lnk.Cid, leaves[i])
+		}
+	}
+
+	// without -R only the target itself is updated
+	err = chmodPath(ctx, root, "/docs/images", 0700, false, func(string) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := statMode("/docs/images"); m != "700" {
+		t.Errorf("expected mode 700, got %q", m)
+	}
+	if m := statMode("/docs/images/logo.png"); m != "755" {
+		t.Errorf("expected the child to keep mode 755, got %q", m)
+	}
+}
+
+func TestStatCache(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	dserv := dag.NewDAGService(bservice.New(bs, offline.Exchange(bs)))
+
+	root, err := mfs.NewRoot(ctx, dserv, ft.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	putFile := func(path, content string) {
+		filend := dag.NodeWithData(ft.FilePBData([]byte(content), uint64(len(content))))
+		if err := dserv.Add(ctx, filend); err != nil {
+			t.Fatal(err)
+		}
+		if err := mfs.PutNode(root, path, filend); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rootCid := func() cid.Cid {
+		nd, err := root.GetDirectory().GetNode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return nd.Cid()
+	}
+
+	putFile("/f", "data")
+
+	fsn, err := mfs.Lookup(root, "/f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nd, err := fsn.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := statNode(nd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sc := &statCache{entries: make(map[statCacheKey]statCacheEntry)}
+	r1 := rootCid()
+	sc.put("/f", r1, o)
+
+	// a repeated stat of unchanged content is a hit
+	got, ok := sc.get("/f", r1)
+	if !ok || got != o {
+		t.Fatal("expected a cache hit for the unchanged root")
+	}
+
+	// a write moves the files root to a new cid and invalidates the entry
+	putFile("/g", "more")
+	r2 := rootCid()
+	if r2.Equals(r1) {
+		t.Fatal("expected the write to change the root cid")
+	}
+	if _, ok := sc.get("/f", r2); ok {
+		t.Fatal("expected a miss after the root cid changed")
+	}
+
+	// expired entries are dropped on lookup
+	sc.entries[statCacheKey{path: "/f", root: r1.String()}] = statCacheEntry{
+		out:   o,
+		added: time.Now().Add(-2 * statCacheTTL),
+	}
+	if _, ok := sc.get("/f", r1); ok {
+		t.Fatal("expected a miss for an expired entry")
+	}
+}
+
+func TestStatRecursive(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	dserv := dag.NewDAGService(bservice.New(bs, offline.Exchange(bs)))
+
+	root, err := mfs.NewRoot(ctx, dserv, ft.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	putFile := func(path, content string) {
+		filend := dag.NodeWithData(ft.FilePBData([]byte(content), uint64(len(content))))
+		if err := dserv.Add(ctx, filend); err != nil {
+			t.Fatal(err)
+		}
+		if err := mfs.PutNode(root, path, filend); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := mfs.Mkdir(root, "/a/b", mfs.MkdirOpts{Mkparents: true, Flush: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	putFile("/a/one", "1111")      // 4 bytes
+	putFile("/a/b/two", "22")      // 2 bytes
+	putFile("/a/b/three", "33333") // 5 bytes
+
+	fsn, err := mfs.Lookup(root, "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := statRecursive(ctx, fsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if o.Type != "directory" {
+		t.Errorf("expected directory type, got %s", o.Type)
+	}
+	if o.Size != 4+2+5 {
+		t.Errorf("expected aggregate size 11, got %d", o.Size)
+	}
+	if o.FileCount != 3 {
+		t.Errorf("expected 3 files, got %d", o.FileCount)
+	}
+	if o.DirCount != 2 {
+		t.Errorf("expected 2 directories (/a and /a/b), got %d", o.DirCount)
+	}
+
+	// /a links to 'one' and 'b', /a/b links to 'two' and 'three'; the
+	// single-block files have no child blocks of their own
+	if o.Blocks != 4 {
+		t.Errorf("expected 4 child blocks across the tree, got %d", o.Blocks)
+	}
+
+	// the cumulative size is taken from the subtree root and must cover
+	// every node below it
+	and, err := fsn.GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cumul, err := and.Size()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.CumulativeSize != cumul {
+		t.Errorf("expected cumulative size %d, got %d", cumul, o.CumulativeSize)
+	}
+
+	// stating a single file still counts it
+	fsn, err = mfs.Lookup(root, "/a/one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err = statRecursive(ctx, fsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Size != 4 || o.FileCount != 1 || o.DirCount != 0 {
+		t.Errorf("unexpected aggregate for a file: size=%d files=%d dirs=%d", o.Size, o.FileCount, o.DirCount)
+	}
+}
+
+func TestRmDryRun(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	dserv := dag.NewDAGService(bservice.New(bs, offline.Exchange(bs)))
+
+	root, err := mfs.NewRoot(ctx, dserv, ft.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mfs.Mkdir(root, "/a/b", mfs.MkdirOpts{Mkparents: true, Flush: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"/a/one", "/a/b/two"} {
+		filend := dag.NodeWithData(ft.FilePBData([]byte(p), uint64(len(p))))
+		if err := dserv.Add(ctx, filend); err != nil {
+			t.Fatal(err)
+		}
+		if err := mfs.PutNode(root, p, filend); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rootCid := func() cid.Cid {
+		nd, err := root.GetDirectory().GetNode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return nd.Cid()
+	}
+	before := rootCid()
+
+	var got []string
+	err = rmDryRun(ctx, root, "/a", true, false, func(p string) error {
+		got = append(got, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(got)
+	expected := []string{"/a", "/a/b", "/a/b/two", "/a/one"}
+	if len(got) != len(expected) {
+		t.Fatalf("unexpected paths: %v", got)
+	}
+	for i, p := range expected {
+		if got[i] != p {
+			t.Fatalf("unexpected path %d: %s", i, got[i])
+		}
+	}
+
+	// the tree is untouched
+	if !rootCid().Equals(before) {
+		t.Fatal("expected the dry run to leave the tree unchanged")
+	}
+	for _, p := range expected {
+		if _, err := mfs.Lookup(root, p); err != nil {
+			t.Fatalf("expected %s to survive the dry run: %s", p, err)
+		}
+	}
+
+	// a file reports just itself
+	got = nil
+	err = rmDryRun(ctx, root, "/a/one", false, false, func(p string) error {
+		got = append(got, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "/a/one" {
+		t.Fatalf("unexpected paths for a file: %v", got)
+	}
+
+	// directories still require -r, even on a dry run
+	if err := rmDryRun(ctx, root, "/a", false, false, func(string) error { return nil }); err == nil {
+		t.Fatal("expected an error removing a directory without -r")
+	}
+
+	// a missing entry fails like the real removal would
+	if err := rmDryRun(ctx, root, "/a/missing", false, false, func(string) error { return nil }); err == nil {
+		t.Fatal("expected an error for a missing path")
+	}
+}
+
+func TestWalkNodePath(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	dserv := dag.NewDAGService(bservice.New(bs, offline.Exchange(bs)))
+
+	root, err := mfs.NewRoot(ctx, dserv, ft.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mfs.Mkdir(root, "/docs/images", mfs.MkdirOpts{Mkparents: true, Flush: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filend := dag.NodeWithData(ft.FilePBData([]byte("logo"), 4))
+	if err := dserv.Add(ctx, filend); err != nil {
+		t.Fatal(err)
+	}
+	if err := mfs.PutNode(root, "/docs/images/logo.png", filend); err != nil {
+		t.Fatal(err)
+	}
+
+	start, err := root.GetDirectory().GetNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var steps []pathStep
+	visit := func(s pathStep) error {
+		steps = append(steps, s)
+		return nil
+	}
+
+	// a full mfs walk reports every component
+	nd, err := walkNodePath(ctx, dserv, nil, start, []string{"docs", "images", "logo.png"}, visit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !nd.Cid().Equals(filend.Cid()) {
+		t.Fatalf("resolved to the wrong node: %s", nd.Cid())
+	}
+	if len(steps) != 3 {
+		t.Fatalf("expected 3 steps, got %d", len(steps))
+	}
+	for i, typ := range []string{"directory", "directory", "file"} {
+		if !steps[i].Found {
+			t.Errorf("expected step %d to be found", i)
+		}
+		if steps[i].Type != typ {
+			t.Errorf("expected step %d to be a %s, got %q", i, typ, steps[i].Type)
+		}
+	}
+	if steps[0].Cid != start.Cid().String() {
+		t.Errorf("expected the first lookup in the start directory, got %s", steps[0].Cid)
+	}
+	if steps[2].Child != filend.Cid().String() {
+		t.Errorf("expected the last step to name the file, got %s", steps[2].Child)
+	}
+
+	// the walk stops at the failing component and still reports the step
+	steps = nil
+	_, err = walkNodePath(ctx, dserv, nil, start, []string{"docs", "missing", "logo.png"}, visit)
+	if err == nil {
+		t.Fatal("expected an error for a missing component")
+	}
+	if !strings.Contains(err.Error(), `"missing"`) {
+		t.Errorf("expected the error to name the component, got: %s", err)
+	}
+	if len(steps) != 2 || steps[1].Found {
+		t.Fatalf("expected the failing step to be reported as not found, got %v", steps)
+	}
+
+	// a file in the middle of the path is rejected
+	if _, err := walkNodePath(ctx, dserv, nil, start, []string{"docs", "images", "logo.png", "below"}, nil); err == nil {
+		t.Fatal("expected an error walking through a file")
+	}
+
+	// with a partial local store the missing child is reported as fetched
+	localbs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	localDserv := dag.NewDAGService(bservice.New(localbs, offline.Exchange(localbs)))
+	for _, path := range []string{"/", "/docs", "/docs/images"} {
+		fsn, err := mfs.Lookup(root, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dirnd, err := fsn.GetNode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := localDserv.Add(ctx, dirnd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	steps = nil
+	_, err = walkNodePath(ctx, dserv, localDserv, start, []string{"docs", "images", "logo.png"}, visit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !steps[0].Local || !steps[1].Local {
+		t.Errorf("expected the directory steps to be local: %v", steps)
+	}
+	if steps[2].Local {
+		t.Error("expected the file step to be reported as fetched")
+	}
+}
+
+func TestValidateStatFormat(t *testing.T) {
+	for _, good := range []string{
+		defaultStatFormat,
+		"<hash> <mode> <mtime>",
+		"no tokens at all",
+		"<cumulsize>",
+	} {
+		if err := validateStatFormat(good); err != nil {
+			t.Errorf("unexpected error for %q: %s", good, err)
+		}
+	}
+
+	for _, bad := range []string{"<bogus>", "<hash> <sizee>", "<MODE>"} {
+		if err := validateStatFormat(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestFilesQuietOutputGolden(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cases := []struct {
+		name string
+		cmd  *cmds.Command
+		opts cmdkit.OptMap
+		out  interface{}
+		want string
+	}{
+		{"cp quiet", filesCpCmd, cmdkit.OptMap{quietOptionName: true}, &filesQuietOutput{Path: "/foo"}, "/foo\n"},
+		{"mv quiet", filesMvCmd, cmdkit.OptMap{quietOptionName: true}, &filesQuietOutput{Path: "/bar"}, "/bar\n"},
+		{"mkdir quieter", filesMkdirCmd, cmdkit.OptMap{quieterOptionName: true}, &filesQuietOutput{Cid: "QmMkdirHash"}, "QmMkdirHash\n"},
+		{"flush quieter", filesFlushCmd, cmdkit.OptMap{quieterOptionName: true}, &filesQuietOutput{Cid: "QmFlushHash"}, "QmFlushHash\n"},
+		{"chcid quiet", filesChcidCmd, cmdkit.OptMap{quietOptionName: true}, &filesQuietOutput{Path: "/baz"}, "/baz\n"},
+		{"write quiet", filesWriteCmd, cmdkit.OptMap{quietOptionName: true}, &filesWriteOutput{Files: []string{"/file"}}, "/file\n"},
+		{"write quieter", filesWriteCmd, cmdkit.OptMap{quieterOptionName: true}, &filesWriteOutput{Cid: "QmWriteHash"}, "QmWriteHash\n"},
+		{"rm quiet", filesRmCmd, cmdkit.OptMap{quietOptionName: true}, &filesRmOutput{Path: "/gone"}, "/gone\n"},
+		{"rm quieter", filesRmCmd, cmdkit.OptMap{quieterOptionName: true}, &filesRmOutput{Path: "/gone"}, "/gone\n"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := cmds.NewRequest(ctx, []string{}, tc.opts, nil, nil, tc.cmd)
+			if err != nil {
+				t.Fatalf("error creating a command request: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := tc.cmd.Encoders[cmds.Text](req)(&buf).Encode(tc.out); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, buf.String())
+			}
+		})
+	}
+}
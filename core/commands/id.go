@@ -35,10 +35,12 @@ type IdOutput struct {
 	Addresses       []string
 	AgentVersion    string
 	ProtocolVersion string
+	Features        map[string]bool `json:",omitempty"`
 }
 
 const (
-	formatOptionName = "format"
+	formatOptionName     = "format"
+	idFeaturesOptionName = "features"
 )
 
 var IDCmd = &cmds.Command{
@@ -65,6 +67,7 @@ EXAMPLE:
 	},
 	Options: []cmdkit.Option{
 		cmdkit.StringOption(formatOptionName, "f", "Optional output format."),
+		cmdkit.BoolOption(idFeaturesOptionName, "Include the experimental feature flags of the local node."),
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		n, err := cmdenv.GetNode(env)
@@ -83,14 +86,34 @@ EXAMPLE:
 			id = n.Identity
 		}
 
+		withFeatures, _ := req.Options[idFeaturesOptionName].(bool)
+
 		if id == n.Identity {
 			output, err := printSelf(n)
 			if err != nil {
 				return err
 			}
+
+			if withFeatures {
+				api, err := cmdenv.GetApi(env, req)
+				if err != nil {
+					return err
+				}
+
+				info, err := api.NodeInfo(req.Context)
+				if err != nil {
+					return err
+				}
+				output.(*IdOutput).Features = info.Experimental
+			}
+
 			return cmds.EmitOnce(res, output)
 		}
 
+		if withFeatures {
+			return errors.New("--features is only available for the local node")
+		}
+
 		// TODO handle offline mode with polymorphism instead of conditionals
 		if !n.OnlineMode() {
 			return errors.New(offlineIdErrorMessage)
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"encoding/base64"
 	"fmt"
 	"io"
 	"text/tabwriter"
@@ -10,6 +11,7 @@ import (
 
 	cmds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmds"
 	cmdkit "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmdkit"
+	peer "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peer"
 )
 
 var KeyCmd = &cmds.Command{
@@ -34,6 +36,9 @@ publish'.
 		"list":   keyListCmd,
 		"rename": keyRenameCmd,
 		"rm":     keyRmCmd,
+		"sign":   keySignCmd,
+		"verify": keyVerifyCmd,
+		"import": keyImportCmd,
 	},
 }
 
@@ -231,6 +236,169 @@ var keyRmCmd = &cmds.Command{
 	Type: KeyOutputList{},
 }
 
+const (
+	keyStorePasswordOptionName = "password"
+)
+
+var keyImportCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Import a PEM-encoded private key",
+		ShortDescription: `
+Imports a PEM-encoded PKCS8 private key into the keystore under the given
+name. RSA, Ed25519 and secp256k1 keys are supported. Encrypted keys (PBES2
+with AES) are decrypted with the --password option.
+
+  > openssl genpkey -algorithm RSA -out key.pem
+  > ipfs key import mykey key.pem
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("name", true, false, "name to give the imported key"),
+		cmdkit.FileArg("key", true, false, "PEM-encoded PKCS8 private key").EnableStdin(),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption(keyStorePasswordOptionName, "Password for an encrypted key."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+
+		data, err := cmdenv.GetFileArg(req.Files.Entries())
+		if err != nil {
+			return err
+		}
+
+		var opts []options.KeyImportOption
+		if password, found := req.Options[keyStorePasswordOptionName].(string); found {
+			opts = append(opts, options.Key.Password(password))
+		}
+
+		key, err := api.Key().Import(req.Context, req.Arguments[0], data, opts...)
+		if err != nil {
+			return err
+		}
+
+		return cmds.EmitOnce(res, &KeyOutput{
+			Name: key.Name(),
+			Id:   key.ID().Pretty(),
+		})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, ko *KeyOutput) error {
+			_, err := w.Write([]byte(ko.Id + "\n"))
+			return err
+		}),
+	},
+	Type: KeyOutput{},
+}
+
+// KeySignOutput defines the output type of keySignCmd
+type KeySignOutput struct {
+	Signature string
+}
+
+var keySignCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Sign data with a keypair",
+		ShortDescription: `
+Signs the data read from stdin with the named private key and prints the
+base64 encoded signature. Use the name 'self' to sign with the node key.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("name", true, false, "name of key to sign with"),
+		cmdkit.FileArg("data", true, false, "data to sign").EnableStdin(),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+
+		data, err := cmdenv.GetFileArg(req.Files.Entries())
+		if err != nil {
+			return err
+		}
+
+		sig, err := api.Key().Sign(req.Context, req.Arguments[0], data)
+		if err != nil {
+			return err
+		}
+
+		return cmds.EmitOnce(res, &KeySignOutput{
+			Signature: base64.StdEncoding.EncodeToString(sig),
+		})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, kso *KeySignOutput) error {
+			_, err := w.Write([]byte(kso.Signature + "\n"))
+			return err
+		}),
+	},
+	Type: KeySignOutput{},
+}
+
+// KeyVerifyOutput defines the output type of keyVerifyCmd
+type KeyVerifyOutput struct {
+	Valid bool
+}
+
+var keyVerifyCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Verify a signature made by a peer",
+		ShortDescription: `
+Verifies the base64 encoded signature over the data read from stdin using
+the public key of the given peer.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("peer-id", true, false, "peer whose key made the signature"),
+		cmdkit.StringArg("signature", true, false, "base64 encoded signature to check"),
+		cmdkit.FileArg("data", true, false, "data the signature covers").EnableStdin(),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+
+		pid, err := peer.IDB58Decode(req.Arguments[0])
+		if err != nil {
+			return fmt.Errorf("invalid peer id: %s", err)
+		}
+
+		sig, err := base64.StdEncoding.DecodeString(req.Arguments[1])
+		if err != nil {
+			return fmt.Errorf("invalid signature: %s", err)
+		}
+
+		data, err := cmdenv.GetFileArg(req.Files.Entries())
+		if err != nil {
+			return err
+		}
+
+		valid, err := api.Key().Verify(req.Context, pid, sig, data)
+		if err != nil {
+			return err
+		}
+
+		return cmds.EmitOnce(res, &KeyVerifyOutput{Valid: valid})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, kvo *KeyVerifyOutput) error {
+			if kvo.Valid {
+				fmt.Fprintln(w, "signature valid")
+			} else {
+				fmt.Fprintln(w, "signature invalid")
+			}
+			return nil
+		}),
+	},
+	Type: KeyVerifyOutput{},
+}
+
 func keyOutputListEncoders() cmds.EncoderFunc {
 	return cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, list *KeyOutputList) error {
 		withID, _ := req.Options["l"].(bool)
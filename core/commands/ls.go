@@ -8,6 +8,7 @@ import (
 
 	cmdenv "github.com/ipsn/go-ipfs/core/commands/cmdenv"
 	iface "github.com/ipsn/go-ipfs/core/coreapi/interface"
+	options "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
 
 	blockservice "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-blockservice"
 	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
@@ -146,32 +147,50 @@ The JSON output contains type information.
 			return cmds.EmitOnce(res, &LsOutput{output})
 		}
 
-		for i, dagnode := range dagnodes {
-			dir, err := uio.NewDirectoryFromNode(ro, dagnode)
-			if err != nil && err != uio.ErrNotADir {
-				return fmt.Errorf("the data in %s (at %q) is not a UnixFS directory: %s", dagnode.Cid(), paths[i], err)
+		for i, fpath := range paths {
+			p, err := iface.ParsePath(fpath)
+			if err != nil {
+				return err
 			}
 
-			var linkResults <-chan unixfs.LinkResult
-			if dir == nil {
-				linkResults = makeDagNodeLinkResults(req, dagnode)
-			} else {
-				linkResults = dir.EnumLinksAsync(req.Context)
+			entries, err := api.Unixfs().LsEntries(req.Context, p, options.Unixfs.ResolveChildren(resolve))
+			switch err {
+			case nil:
+			case uio.ErrNotADir:
+				// not a directory, stream the node's raw links instead
+				for linkResult := range makeDagNodeLinkResults(req, dagnodes[i]) {
+					if linkResult.Err != nil {
+						return linkResult.Err
+					}
+					lsLink, err := makeLsLink(req, dserv, resolve, linkResult.Link)
+					if err != nil {
+						return err
+					}
+					output := []LsObject{{
+						Hash:  paths[i],
+						Links: []LsLink{*lsLink},
+					}}
+					if err = res.Emit(&LsOutput{output}); err != nil {
+						return err
+					}
+				}
+				continue
+			default:
+				return fmt.Errorf("the data in %s (at %q) is not a UnixFS directory: %s", dagnodes[i].Cid(), paths[i], err)
 			}
 
-			for linkResult := range linkResults {
-
-				if linkResult.Err != nil {
-					return linkResult.Err
-				}
-				link := linkResult.Link
-				lsLink, err := makeLsLink(req, dserv, resolve, link)
-				if err != nil {
-					return err
+			for entry := range entries {
+				if entry.Err != nil {
+					return entry.Err
 				}
 				output := []LsObject{{
-					Hash:  paths[i],
-					Links: []LsLink{*lsLink},
+					Hash: paths[i],
+					Links: []LsLink{{
+						Name: entry.Name,
+						Hash: entry.Cid.String(),
+						Size: entry.Size,
+						Type: lsEntryType(entry.Type),
+					}},
 				}}
 				if err = res.Emit(&LsOutput{output}); err != nil {
 					return err
@@ -211,6 +230,21 @@ The JSON output contains type information.
 	Type: LsOutput{},
 }
 
+// lsEntryType maps a CoreAPI entry type back to the unixfs wire type used in
+// ls output.
+func lsEntryType(t iface.FileType) unixfspb.Data_DataType {
+	switch t {
+	case iface.TFile:
+		return unixfs.TFile
+	case iface.TDirectory:
+		return unixfs.TDirectory
+	case iface.TSymlink:
+		return unixfs.TSymlink
+	default:
+		return unixfspb.Data_DataType(-1)
+	}
+}
+
 func makeDagNodeLinkResults(req *cmds.Request, dagnode ipld.Node) <-chan unixfs.LinkResult {
 	links := dagnode.Links()
 	linkResults := make(chan unixfs.LinkResult, len(links))
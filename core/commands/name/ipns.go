@@ -9,7 +9,6 @@ import (
 
 	cmdenv "github.com/ipsn/go-ipfs/core/commands/cmdenv"
 	options "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
-	nsopts "github.com/ipsn/go-ipfs/namesys/opts"
 
 	path "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-path"
 	cmds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmds"
@@ -21,6 +20,12 @@ var log = logging.Logger("core/commands/ipns")
 
 type ResolvedPath struct {
 	Path path.Path
+
+	// Source and TTL annotate streamed results with where the record came
+	// from and how long it may be cached. They are unset for non-streamed
+	// output, keeping it stable for scripts.
+	Source string        `json:",omitempty"`
+	TTL    time.Duration `json:",omitempty"`
 }
 
 const (
@@ -108,10 +113,10 @@ Resolve the value of a dnslink:
 		}
 
 		if !recursive {
-			opts = append(opts, options.Name.ResolveOption(nsopts.Depth(1)))
+			opts = append(opts, options.Name.Depth(1))
 		}
 		if rcok {
-			opts = append(opts, options.Name.ResolveOption(nsopts.DhtRecordCount(uint(rc))))
+			opts = append(opts, options.Name.DhtRecordCount(uint(rc)))
 		}
 		if dhttok {
 			d, err := time.ParseDuration(dhtt)
@@ -121,7 +126,7 @@ Resolve the value of a dnslink:
 			if d < 0 {
 				return errors.New("DHT timeout value must be >= 0")
 			}
-			opts = append(opts, options.Name.ResolveOption(nsopts.DhtTimeout(d)))
+			opts = append(opts, options.Name.DhtTimeout(d))
 		}
 
 		if !strings.HasPrefix(name, "/ipns/") {
@@ -134,7 +139,7 @@ Resolve the value of a dnslink:
 				return err
 			}
 
-			return cmds.EmitOnce(res, &ResolvedPath{path.FromString(output.String())})
+			return cmds.EmitOnce(res, &ResolvedPath{Path: path.FromString(output.String())})
 		}
 
 		output, err := api.Name().Search(req.Context, name, opts...)
@@ -144,9 +149,13 @@ Resolve the value of a dnslink:
 
 		for v := range output {
 			if v.Err != nil {
-				return err
+				return v.Err
 			}
-			if err := res.Emit(&ResolvedPath{path.FromString(v.Path.String())}); err != nil {
+			if err := res.Emit(&ResolvedPath{
+				Path:   path.FromString(v.Path.String()),
+				Source: v.Source,
+				TTL:    v.TTL,
+			}); err != nil {
 				return err
 			}
 
@@ -156,6 +165,10 @@ Resolve the value of a dnslink:
 	},
 	Encoders: cmds.EncoderMap{
 		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, rp *ResolvedPath) error {
+			if rp.Source != "" {
+				_, err := fmt.Fprintf(w, "%s (from %s, ttl %s)\n", rp.Path, rp.Source, rp.TTL)
+				return err
+			}
 			_, err := fmt.Fprintln(w, rp.Path)
 			return err
 		}),
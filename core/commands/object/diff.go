@@ -6,6 +6,7 @@ import (
 
 	cmdenv "github.com/ipsn/go-ipfs/core/commands/cmdenv"
 	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
+	options "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
 	"github.com/ipsn/go-ipfs/dagutils"
 
 	cmds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmds"
@@ -14,6 +15,7 @@ import (
 
 const (
 	verboseOptionName = "verbose"
+	depthOptionName   = "depth"
 )
 
 type Changes struct {
@@ -53,6 +55,7 @@ Example:
 	},
 	Options: []cmdkit.Option{
 		cmdkit.BoolOption(verboseOptionName, "v", "Print extra information."),
+		cmdkit.IntOption(depthOptionName, "Limit how deep changed subtrees are expanded, emitting a single change for a whole subtree at the cutoff. Values below 1 mean no limit.").WithDefault(-1),
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		api, err := cmdenv.GetApi(env, req)
@@ -73,7 +76,9 @@ Example:
 			return err
 		}
 
-		changes, err := api.Object().Diff(req.Context, pa, pb)
+		depth, _ := req.Options[depthOptionName].(int)
+
+		changes, err := api.Object().Diff(req.Context, pa, pb, options.Object.Depth(depth))
 		if err != nil {
 			return err
 		}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"text/tabwriter"
 
 	"github.com/ipsn/go-ipfs/core/commands/cmdenv"
@@ -44,16 +45,148 @@ var ObjectCmd = &cmds.Command{
 directly.`,
 	},
 
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption(objectQuietOptionName, "q", "Write minimal output."),
+		cmdkit.BoolOption(objectQuieterOptionName, "Q", "Write only the final hash."),
+	},
 	Subcommands: map[string]*cmds.Command{
-		"data":  ObjectDataCmd,
-		"diff":  ObjectDiffCmd,
-		"get":   ObjectGetCmd,
-		"links": ObjectLinksCmd,
-		"new":   ObjectNewCmd,
-		"patch": ObjectPatchCmd,
-		"put":   ObjectPutCmd,
-		"stat":  ObjectStatCmd,
+		"canonicalize": ObjectCanonicalizeCmd,
+		"data":         ObjectDataCmd,
+		"diff":         ObjectDiffCmd,
+		"get":          ObjectGetCmd,
+		"links":        ObjectLinksCmd,
+		"new":          ObjectNewCmd,
+		"patch":        ObjectPatchCmd,
+		"put":          ObjectPutCmd,
+		"stat":         ObjectStatCmd,
+	},
+}
+
+const (
+	objectQuietOptionName   = "quiet"
+	objectQuieterOptionName = "quieter"
+)
+
+const canonicalizePinsOptionName = "pins"
+
+// CanonicalizedObject reports the result of re-serializing an object in
+// canonical form.
+type CanonicalizedObject struct {
+	OldHash string
+	NewHash string
+}
+
+// ObjectCanonicalizeCmd object canonicalize command
+var ObjectCanonicalizeCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Store the canonical serialization of a DAG node.",
+		ShortDescription: `
+'ipfs object canonicalize' reads a DAG node, re-serializes it in canonical
+dag-pb form (links sorted by name) and stores the result, printing the old
+and new hash. Objects imported from other implementations may be encoded
+non-canonically; normalizing them helps interop. The original block is left
+in place until garbage collection, and MFS paths referencing the old hash
+are not rewritten. With '--pins' a recursive pin of the old object is moved
+to the canonical one.
+`,
+	},
+
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("key", true, false, "Key of the object to canonicalize, in base58-encoded multihash format.").EnableStdin(),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption(canonicalizePinsOptionName, "Move a recursive pin of the old object to the canonical one."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+		n, err := cmdenv.GetNode(env)
+		if err != nil {
+			return err
+		}
+
+		path, err := coreiface.ParsePath(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		nd, err := api.ResolveNode(req.Context, path)
+		if err != nil {
+			return err
+		}
+
+		pbnd, ok := nd.(*dag.ProtoNode)
+		if !ok {
+			return dag.ErrNotProtobuf
+		}
+
+		canon, err := canonicalizeNode(pbnd)
+		if err != nil {
+			return err
+		}
+
+		out := &CanonicalizedObject{
+			OldHash: pbnd.Cid().String(),
+			NewHash: canon.Cid().String(),
+		}
+
+		if !canon.Cid().Equals(pbnd.Cid()) {
+			if err := n.DAG.Add(req.Context, canon); err != nil {
+				return err
+			}
+
+			if updatePins, _ := req.Options[canonicalizePinsOptionName].(bool); updatePins {
+				mode, pinned, err := n.Pinning.IsPinned(pbnd.Cid())
+				if err != nil {
+					return err
+				}
+				if pinned && mode == "recursive" {
+					err = api.Pin().Update(req.Context,
+						coreiface.IpfsPath(pbnd.Cid()),
+						coreiface.IpfsPath(canon.Cid()))
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		return cmds.EmitOnce(res, out)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *CanonicalizedObject) error {
+			if out.OldHash == out.NewHash {
+				fmt.Fprintf(w, "%s is already canonical\n", out.OldHash)
+			} else {
+				fmt.Fprintf(w, "%s -> %s\n", out.OldHash, out.NewHash)
+			}
+			return nil
+		}),
 	},
+	Type: &CanonicalizedObject{},
+}
+
+// canonicalizeNode returns a copy of nd re-encoded in canonical dag-pb form
+// (links sorted by name), dropping any cached non-canonical encoding the
+// node was decoded from.
+func canonicalizeNode(nd *dag.ProtoNode) (*dag.ProtoNode, error) {
+	canon := dag.NodeWithData(nd.Data())
+	canon.SetCidBuilder(nd.CidBuilder())
+
+	for _, l := range nd.Links() {
+		lnk := *l
+		if err := canon.AddRawLink(l.Name, &lnk); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := canon.EncodeProtobuf(false); err != nil {
+		return nil, err
+	}
+
+	return canon, nil
 }
 
 // ObjectDataCmd object data command
@@ -134,21 +267,51 @@ multihash.
 			return err
 		}
 
-		outLinks := make([]Link, len(links))
-		for i, link := range links {
-			outLinks[i] = Link{
-				Hash: link.Cid.String(),
-				Name: link.Name,
-				Size: link.Size,
+		// stream one link per emitted object instead of buffering the
+		// whole link list, which can be huge for sharded directories
+		for link := range links {
+			out := &Object{
+				Hash: rp.Cid().String(),
+				Links: []Link{{
+					Hash: link.Cid.String(),
+					Name: link.Name,
+					Size: link.Size,
+				}},
+			}
+			if err := res.Emit(out); err != nil {
+				return err
 			}
 		}
 
-		out := &Object{
-			Hash:  rp.Cid().String(),
-			Links: outLinks,
-		}
+		return req.Context.Err()
+	},
+	PostRun: cmds.PostRunMap{
+		cmds.CLI: func(res cmds.Response, re cmds.ResponseEmitter) error {
+			req := res.Request()
+			headers, _ := req.Options["headers"].(bool)
 
-		return cmds.EmitOnce(res, out)
+			tw := tabwriter.NewWriter(os.Stdout, 10, 2, 1, ' ', 0)
+			if headers {
+				fmt.Fprintln(tw, "Hash\tSize\tName")
+			}
+
+			for {
+				v, err := res.Next()
+				if err != nil {
+					tw.Flush()
+					if err == io.EOF {
+						return nil
+					}
+					return err
+				}
+
+				out := v.(*Object)
+				for _, link := range out.Links {
+					fmt.Fprintf(tw, "%s\t%v\t%s\n", link.Hash, link.Size, link.Name)
+				}
+				tw.Flush()
+			}
+		},
 	},
 	Encoders: cmds.EncoderMap{
 		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *Object) error {
@@ -383,7 +546,6 @@ And then run:
 		cmdkit.StringOption("inputenc", "Encoding type of input data. One of: {\"protobuf\", \"json\"}.").WithDefault("json"),
 		cmdkit.StringOption("datafieldenc", "Encoding type of the data field, either \"text\" or \"base64\".").WithDefault("text"),
 		cmdkit.BoolOption("pin", "Pin this object when adding."),
-		cmdkit.BoolOption("quiet", "q", "Write minimal output."),
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		api, err := cmdenv.GetApi(env, req)
@@ -423,10 +585,11 @@ And then run:
 	},
 	Encoders: cmds.EncoderMap{
 		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *Object) error {
-			quiet, _ := req.Options["quiet"].(bool)
+			quiet, _ := req.Options[objectQuietOptionName].(bool)
+			quieter, _ := req.Options[objectQuieterOptionName].(bool)
 
 			o := out.Hash
-			if !quiet {
+			if !quiet && !quieter {
 				o = "added " + o
 			}
 
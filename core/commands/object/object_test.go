@@ -0,0 +1,110 @@
+package objectcmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmdkit"
+	"github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmds"
+	dag "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-merkledag"
+	pb "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-merkledag/pb"
+)
+
+func TestCanonicalizeNode(t *testing.T) {
+	a := dag.NodeWithData([]byte("a"))
+	b := dag.NodeWithData([]byte("b"))
+
+	// handcraft a dag-pb encoding with the links out of order, as another
+	// implementation might have produced it
+	nameZ, nameA := "z", "a"
+	size := uint64(1)
+	pbn := &pb.PBNode{
+		Links: []*pb.PBLink{
+			{Name: &nameZ, Tsize: &size, Hash: a.Cid().Bytes()},
+			{Name: &nameA, Tsize: &size, Hash: b.Cid().Bytes()},
+		},
+		Data: []byte("some data"),
+	}
+	raw, err := pbn.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nd, err := dag.DecodeProtobuf(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the decoded node keeps reporting the bytes it was decoded from
+	enc, err := nd.EncodeProtobuf(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(enc, raw) {
+		t.Fatal("expected the decoded node to keep its original encoding")
+	}
+
+	canon, err := canonicalizeNode(nd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if canon.Cid().Equals(nd.Cid()) {
+		t.Fatal("expected the canonical cid to differ from the non-canonical one")
+	}
+
+	cenc, err := canon.EncodeProtobuf(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(cenc, raw) {
+		t.Fatal("expected the canonical encoding to differ")
+	}
+
+	links := canon.Links()
+	if len(links) != 2 || links[0].Name != "a" || links[1].Name != "z" {
+		t.Fatalf("expected the canonical links to be sorted, got %v", links)
+	}
+
+	// canonicalizing a canonical node is a fixed point
+	again, err := canonicalizeNode(canon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.Cid().Equals(canon.Cid()) {
+		t.Fatal("expected canonicalization to be idempotent")
+	}
+}
+
+func TestPutOutputGolden(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cases := []struct {
+		name string
+		opts cmdkit.OptMap
+		want string
+	}{
+		{"default", cmdkit.OptMap{}, "added QmPutHash\n"},
+		{"quiet", cmdkit.OptMap{objectQuietOptionName: true}, "QmPutHash\n"},
+		{"quieter", cmdkit.OptMap{objectQuieterOptionName: true}, "QmPutHash\n"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := cmds.NewRequest(ctx, []string{}, tc.opts, nil, nil, ObjectPutCmd)
+			if err != nil {
+				t.Fatalf("error creating a command request: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := ObjectPutCmd.Encoders[cmds.Text](req)(&buf).Encode(&Object{Hash: "QmPutHash"}); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, buf.String())
+			}
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package objectcmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 
 	"github.com/ipsn/go-ipfs/core/commands/cmdenv"
 	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
 	"github.com/ipsn/go-ipfs/core/coreapi/interface/options"
+	"github.com/ipsn/go-ipfs/dagutils"
 
 	"github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmds"
 	"github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmdkit"
@@ -24,12 +28,202 @@ result. This is the Merkle-DAG version of modifying an object.
 	Arguments: []cmdkit.Argument{},
 	Subcommands: map[string]*cmds.Command{
 		"append-data": patchAppendDataCmd,
+		"splice-data": patchSpliceDataCmd,
 		"add-link":    patchAddLinkCmd,
 		"rm-link":     patchRmLinkCmd,
 		"set-data":    patchSetDataCmd,
+		"apply":       patchApplyCmd,
 	},
 }
 
+const (
+	patchSourceOffsetOptionName = "source-offset"
+	patchSourceLengthOptionName = "source-length"
+)
+
+var patchSpliceDataCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Append a slice of another node's data to the data segment of a dag node.",
+		ShortDescription: `
+Append part of the data segment of the source node to what already exists in
+the data segment of the root object. The bytes are copied server-side, so
+assembling an object from pieces already in the dag does not require
+downloading and re-uploading them.
+
+Example:
+
+	$ ipfs object patch $HASH splice-data $SRC --source-offset 16 --source-length 64
+
+NOTE: This does not operate on files - it modifies the actual raw data within
+an object. Objects have a max size of 1MB and objects larger than the limit
+will not be respected by the network.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("root", true, false, "The hash of the node to modify."),
+		cmdkit.StringArg("source", true, false, "The hash of the node to copy data from."),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.Int64Option(patchSourceOffsetOptionName, "Byte offset into the source data segment to copy from."),
+		cmdkit.Int64Option(patchSourceLengthOptionName, "Number of bytes to copy, everything to the end when unset."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+
+		root, err := coreiface.ParsePath(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		source, err := coreiface.ParsePath(req.Arguments[1])
+		if err != nil {
+			return err
+		}
+
+		offset, _ := req.Options[patchSourceOffsetOptionName].(int64)
+
+		length, found := req.Options[patchSourceLengthOptionName].(int64)
+		if !found {
+			length = -1
+		} else if length < 0 {
+			return fmt.Errorf("cannot have negative source length")
+		}
+
+		p, err := api.Object().SpliceData(req.Context, root, source, offset, length)
+		if err != nil {
+			return err
+		}
+
+		return cmds.EmitOnce(res, &Object{Hash: p.Cid().String()})
+	},
+	Type: &Object{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, obj *Object) error {
+			_, err := fmt.Fprintln(w, obj.Hash)
+			return err
+		}),
+	},
+}
+
+var patchApplyCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Apply a computed diff to a dag node.",
+		ShortDescription: `
+Read a JSON diff, as produced by 'ipfs object diff' (either the bare change
+array or the {"Changes": [...]} wrapper), and replay every change on top of
+the root object, returning the hash of the patched node.
+
+Example:
+
+	$ ipfs object diff --enc=json $OLD $NEW | ipfs object patch $BASE apply
+
+This enables a read-modify-write workflow where a diff is computed offline
+and applied in one go.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("root", true, false, "The hash of the node to patch."),
+		cmdkit.FileArg("diff", true, false, "JSON diff to apply.").EnableStdin(),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+
+		root, err := coreiface.ParsePath(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		file, err := cmdenv.GetFileArg(req.Files.Entries())
+		if err != nil {
+			return err
+		}
+
+		data, err := ioutil.ReadAll(file)
+		if err != nil {
+			return err
+		}
+
+		changes, err := decodeChanges(data)
+		if err != nil {
+			return err
+		}
+
+		p, err := applyChanges(req.Context, api, root, changes)
+		if err != nil {
+			return err
+		}
+
+		return cmds.EmitOnce(res, &Object{Hash: p.Cid().String()})
+	},
+	Type: &Object{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, obj *Object) error {
+			_, err := fmt.Fprintln(w, obj.Hash)
+			return err
+		}),
+	},
+}
+
+// decodeChanges accepts both the bare change array and the object wrapper
+// emitted by 'ipfs object diff'.
+func decodeChanges(data []byte) ([]*dagutils.Change, error) {
+	var wrapped Changes
+	if err := json.Unmarshal(data, &wrapped); err == nil && wrapped.Changes != nil {
+		return wrapped.Changes, nil
+	}
+
+	var changes []*dagutils.Change
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return nil, fmt.Errorf("invalid diff: %s", err)
+	}
+	return changes, nil
+}
+
+// applyChanges replays the diff on top of base through the object API and
+// returns the path of the patched node.
+func applyChanges(ctx context.Context, api coreiface.CoreAPI, base coreiface.Path, changes []*dagutils.Change) (coreiface.ResolvedPath, error) {
+	var (
+		cur coreiface.Path = base
+		err error
+	)
+
+	for _, change := range changes {
+		switch change.Type {
+		case dagutils.Add:
+			cur, err = api.Object().AddLink(ctx, cur, change.Path, coreiface.IpfsPath(change.After),
+				options.Object.Create(true))
+		case dagutils.Remove:
+			cur, err = api.Object().RmLink(ctx, cur, change.Path)
+		case dagutils.Mod:
+			if change.Path == "" {
+				// a whole-node change carries no path to patch under, the
+				// result simply is the new node
+				cur = coreiface.IpfsPath(change.After)
+				continue
+			}
+			cur, err = api.Object().RmLink(ctx, cur, change.Path)
+			if err != nil {
+				return nil, err
+			}
+			cur, err = api.Object().AddLink(ctx, cur, change.Path, coreiface.IpfsPath(change.After),
+				options.Object.Create(true))
+		default:
+			return nil, fmt.Errorf("unknown change type %d", change.Type)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return api.ResolvePath(ctx, cur)
+}
+
 var patchAppendDataCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Append data to the data segment of a dag node.",
@@ -0,0 +1,158 @@
+package objectcmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ipsn/go-ipfs/core"
+	"github.com/ipsn/go-ipfs/core/coreapi"
+	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
+	"github.com/ipsn/go-ipfs/dagutils"
+	"github.com/ipsn/go-ipfs/keystore"
+	"github.com/ipsn/go-ipfs/repo"
+
+	config "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-config"
+	datastore "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore"
+	syncds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore/sync"
+)
+
+const testPeerID = "QmTFauExutTsy4XP6JbMFcw2Wa9645HJt2bTqL6qYDCKfe"
+
+func makeAPI(ctx context.Context) (coreiface.CoreAPI, error) {
+	c := config.Config{}
+	c.Identity = config.Identity{PeerID: testPeerID}
+
+	node, err := core.NewNode(ctx, &core.BuildCfg{
+		Repo: &repo.Mock{
+			C: c,
+			D: syncds.MutexWrap(datastore.NewMapDatastore()),
+			K: keystore.NewMemKeystore(),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return coreapi.NewCoreAPI(node)
+}
+
+func TestApplyChanges(t *testing.T) {
+	ctx := context.Background()
+	api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	put := func(data string) coreiface.ResolvedPath {
+		p, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"`+data+`"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	addLink := func(base coreiface.Path, name string, child coreiface.Path) coreiface.ResolvedPath {
+		p, err := api.Object().AddLink(ctx, base, name, child)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	c1 := put("child1")
+	c2 := put("child2")
+	base := put("base")
+
+	// a: keep -> c1, drop -> c1
+	a := addLink(addLink(base, "keep", c1), "drop", c1)
+	// b: keep -> c2, add -> c2
+	b := addLink(addLink(base, "keep", c2), "add", c2)
+
+	diff, err := api.Object().Diff(ctx, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make([]*dagutils.Change, len(diff))
+	for i, change := range diff {
+		changes[i] = &dagutils.Change{
+			Type: change.Type,
+			Path: change.Path,
+		}
+		if change.Before != nil {
+			changes[i].Before = change.Before.Cid()
+		}
+		if change.After != nil {
+			changes[i].After = change.After.Cid()
+		}
+	}
+
+	// applying the computed diff to a yields b
+	p, err := applyChanges(ctx, api, a, changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Cid().Equals(b.Cid()) {
+		t.Fatalf("applying the diff produced %s, expected %s", p.Cid(), b.Cid())
+	}
+
+	// an empty diff leaves the node untouched
+	p, err = applyChanges(ctx, api, a, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Cid().Equals(a.Cid()) {
+		t.Fatalf("an empty diff changed the node to %s", p.Cid())
+	}
+}
+
+func TestDecodeChanges(t *testing.T) {
+	ctx := context.Background()
+	api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"foo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := []*dagutils.Change{{
+		Type:  dagutils.Add,
+		Path:  "thing",
+		After: p.Cid(),
+	}}
+
+	// the bare array decodes
+	bare, err := json.Marshal(changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeChanges(bare)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Path != "thing" || !got[0].After.Equals(p.Cid()) {
+		t.Fatalf("unexpected decoded changes: %v", got)
+	}
+
+	// so does the wrapper emitted by 'ipfs object diff'
+	wrapped, err := json.Marshal(&Changes{changes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = decodeChanges(wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Path != "thing" {
+		t.Fatalf("unexpected decoded changes: %v", got)
+	}
+
+	// garbage is rejected
+	if _, err := decodeChanges([]byte("not json")); err == nil {
+		t.Fatal("expected an error for an invalid diff")
+	}
+}
@@ -15,10 +15,7 @@ import (
 	corerepo "github.com/ipsn/go-ipfs/core/corerepo"
 	pin "github.com/ipsn/go-ipfs/pin"
 
-	bserv "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-blockservice"
 	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
-	"github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-verifcid"
-	offline "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-exchange-offline"
 	cmds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmds"
 	dag "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-merkledag"
 	cmdkit "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmdkit"
@@ -35,6 +32,9 @@ var PinCmd = &cmds.Command{
 		"ls":     listPinCmd,
 		"verify": verifyPinCmd,
 		"update": updatePinCmd,
+		"stats":  statsPinCmd,
+		"export": exportPinCmd,
+		"import": importPinCmd,
 	},
 }
 
@@ -50,6 +50,7 @@ type AddPinOutput struct {
 const (
 	pinRecursiveOptionName = "recursive"
 	pinProgressOptionName  = "progress"
+	pinNameOptionName      = "name"
 )
 
 var addPinCmd = &cmds.Command{
@@ -64,6 +65,7 @@ var addPinCmd = &cmds.Command{
 	Options: []cmdkit.Option{
 		cmdkit.BoolOption(pinRecursiveOptionName, "r", "Recursively pin the object linked to by the specified object(s).").WithDefault(true),
 		cmdkit.BoolOption(pinProgressOptionName, "Show progress"),
+		cmdkit.StringOption(pinNameOptionName, "n", "An optional name for the pin(s), shown by 'ipfs pin ls'."),
 	},
 	Type: AddPinOutput{},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
@@ -82,16 +84,32 @@ var addPinCmd = &cmds.Command{
 		// set recursive flag
 		recursive, _ := req.Options[pinRecursiveOptionName].(bool)
 		showProgress, _ := req.Options[pinProgressOptionName].(bool)
+		name, _ := req.Options[pinNameOptionName].(string)
 
 		if err := req.ParseBodyArgs(); err != nil {
 			return err
 		}
 
+		setNames := func(pins []cid.Cid) error {
+			if name == "" {
+				return nil
+			}
+			for _, c := range pins {
+				if err := n.Pinning.SetPinName(c, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
 		if !showProgress {
 			added, err := corerepo.Pin(n.Pinning, api, req.Context, req.Arguments, recursive)
 			if err != nil {
 				return err
 			}
+			if err := setNames(added); err != nil {
+				return err
+			}
 			return cmds.EmitOnce(res, &AddPinOutput{Pins: cidsToStrings(added)})
 		}
 
@@ -118,6 +136,9 @@ var addPinCmd = &cmds.Command{
 				if val.err != nil {
 					return val.err
 				}
+				if err := setNames(val.pins); err != nil {
+					return err
+				}
 
 				if pv := v.Value(); pv != 0 {
 					if err := res.Emit(&AddPinOutput{Progress: v.Value()}); err != nil {
@@ -333,6 +354,8 @@ Example:
 			for k, v := range out.Keys {
 				if quiet {
 					fmt.Fprintf(w, "%s\n", k)
+				} else if v.Name != "" {
+					fmt.Fprintf(w, "%s %s %s\n", k, v.Type, v.Name)
 				} else {
 					fmt.Fprintf(w, "%s %s\n", k, v.Type)
 				}
@@ -411,7 +434,7 @@ var verifyPinCmd = &cmds.Command{
 		cmdkit.BoolOption(pinQuietOptionName, "q", "Write just hashes of broken pins."),
 	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
-		n, err := cmdenv.GetNode(env)
+		api, err := cmdenv.GetApi(env, req)
 		if err != nil {
 			return err
 		}
@@ -423,13 +446,32 @@ var verifyPinCmd = &cmds.Command{
 			return fmt.Errorf("the --verbose and --quiet options can not be used at the same time")
 		}
 
-		opts := pinVerifyOpts{
-			explain:   !quiet,
-			includeOk: verbose,
+		out, err := api.Pin().Verify(req.Context)
+		if err != nil {
+			return err
 		}
-		out := pinVerify(req.Context, n, opts)
 
-		return res.Emit(out)
+		for status := range out {
+			if status.Ok() && !verbose {
+				continue
+			}
+
+			pinStatus := PinStatus{Ok: status.Ok()}
+			if !quiet {
+				for _, n := range status.BadNodes() {
+					pinStatus.BadNodes = append(pinStatus.BadNodes, BadNode{
+						Cid: n.Path().Cid().String(),
+						Err: n.Err().Error(),
+					})
+				}
+			}
+
+			if err := res.Emit(&PinVerifyRes{status.Cid().String(), pinStatus}); err != nil {
+				return err
+			}
+		}
+
+		return req.Context.Err()
 	},
 	Type: PinVerifyRes{},
 	Encoders: cmds.EncoderMap{
@@ -447,8 +489,111 @@ var verifyPinCmd = &cmds.Command{
 	},
 }
 
+var statsPinCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show statistics about objects pinned to local storage.",
+		ShortDescription: `
+'ipfs pin stats' prints aggregate counts and the cumulative size of the
+local pin set. Results may be cached for a short period of time.
+`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+
+		stats, err := api.Pin().Stats(req.Context)
+		if err != nil {
+			return err
+		}
+
+		return cmds.EmitOnce(res, &stats)
+	},
+	Type: iface.PinStats{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *iface.PinStats) error {
+			fmt.Fprintf(w, "Direct pins:    %d\n", out.DirectCount)
+			fmt.Fprintf(w, "Recursive pins: %d\n", out.RecursiveCount)
+			fmt.Fprintf(w, "Indirect pins:  %d\n", out.IndirectCount)
+			fmt.Fprintf(w, "Total objects:  %d\n", out.TotalCount)
+			fmt.Fprintf(w, "Total size:     %d\n", out.TotalSize)
+			return nil
+		}),
+	},
+}
+
+var exportPinCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Export the pin set as a portable stream.",
+		ShortDescription: `
+'ipfs pin export' writes the direct and recursive pin set to stdout as a
+newline-delimited JSON stream of {Cid, Type} entries, suitable for feeding
+to 'ipfs pin import' on another node.
+`,
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+
+		r, w := io.Pipe()
+		go func() {
+			w.CloseWithError(api.Pin().Export(req.Context, w))
+		}()
+
+		return res.Emit(r)
+	},
+}
+
+type PinImportOutput struct {
+	Count int
+}
+
+var importPinCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Import a pin set exported from another node.",
+		ShortDescription: `
+'ipfs pin import' reads a stream in the format written by 'ipfs pin export'
+from stdin and adds each entry to the local pin set. Note that it does not
+fetch the pinned blocks.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.FileArg("data", true, false, "The pin stream to import.").EnableStdin(),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+
+		file, err := cmdenv.GetFileArg(req.Files.Entries())
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		count, err := api.Pin().Import(req.Context, file)
+		if err != nil {
+			return err
+		}
+
+		return cmds.EmitOnce(res, &PinImportOutput{Count: count})
+	},
+	Type: PinImportOutput{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *PinImportOutput) error {
+			fmt.Fprintf(w, "imported %d pins\n", out.Count)
+			return nil
+		}),
+	},
+}
+
 type RefKeyObject struct {
 	Type string
+	Name string `json:",omitempty"`
 }
 
 type RefKeyList struct {
@@ -491,6 +636,7 @@ func pinLsKeys(ctx context.Context, args []string, typeStr string, n *core.IpfsN
 		}
 		keys[c.Cid().String()] = RefKeyObject{
 			Type: pinType,
+			Name: n.Pinning.PinName(c.Cid()),
 		}
 	}
 
@@ -505,6 +651,7 @@ func pinLsAll(ctx context.Context, typeStr string, n *core.IpfsNode) (map[string
 		for _, c := range keyList {
 			keys[c.String()] = RefKeyObject{
 				Type: typeStr,
+				Name: n.Pinning.PinName(c),
 			}
 		}
 	}
@@ -547,76 +694,6 @@ type BadNode struct {
 	Err string
 }
 
-type pinVerifyOpts struct {
-	explain   bool
-	includeOk bool
-}
-
-func pinVerify(ctx context.Context, n *core.IpfsNode, opts pinVerifyOpts) <-chan interface{} {
-	visited := make(map[string]PinStatus)
-
-	bs := n.Blocks.Blockstore()
-	DAG := dag.NewDAGService(bserv.New(bs, offline.Exchange(bs)))
-	getLinks := dag.GetLinksWithDAG(DAG)
-	recPins := n.Pinning.RecursiveKeys()
-
-	var checkPin func(root cid.Cid) PinStatus
-	checkPin = func(root cid.Cid) PinStatus {
-		key := root.String()
-		if status, ok := visited[key]; ok {
-			return status
-		}
-
-		if err := verifcid.ValidateCid(root); err != nil {
-			status := PinStatus{Ok: false}
-			if opts.explain {
-				status.BadNodes = []BadNode{BadNode{Cid: key, Err: err.Error()}}
-			}
-			visited[key] = status
-			return status
-		}
-
-		links, err := getLinks(ctx, root)
-		if err != nil {
-			status := PinStatus{Ok: false}
-			if opts.explain {
-				status.BadNodes = []BadNode{BadNode{Cid: key, Err: err.Error()}}
-			}
-			visited[key] = status
-			return status
-		}
-
-		status := PinStatus{Ok: true}
-		for _, lnk := range links {
-			res := checkPin(lnk.Cid)
-			if !res.Ok {
-				status.Ok = false
-				status.BadNodes = append(status.BadNodes, res.BadNodes...)
-			}
-		}
-
-		visited[key] = status
-		return status
-	}
-
-	out := make(chan interface{})
-	go func() {
-		defer close(out)
-		for _, cid := range recPins {
-			pinStatus := checkPin(cid)
-			if !pinStatus.Ok || opts.includeOk {
-				select {
-				case out <- &PinVerifyRes{cid.String(), pinStatus}:
-				case <-ctx.Done():
-					return
-				}
-			}
-		}
-	}()
-
-	return out
-}
-
 // Format formats PinVerifyRes
 func (r PinVerifyRes) Format(out io.Writer) {
 	if r.Ok {
@@ -38,6 +38,7 @@ To use, the daemon must be run with '--enable-pubsub-experiment'.
 
 const (
 	pubsubDiscoverOptionName = "discover"
+	pubsubVerboseOptionName  = "verbose"
 )
 
 type pubsubMessage struct {
@@ -187,6 +188,9 @@ to be used in a production environment.
 To use, the daemon must be run with '--enable-pubsub-experiment'.
 `,
 	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption(pubsubVerboseOptionName, "v", "Also list the number of connected peers subscribed to each topic."),
+	},
 	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
 		api, err := cmdenv.GetApi(env, req)
 		if err != nil {
@@ -198,14 +202,51 @@ To use, the daemon must be run with '--enable-pubsub-experiment'.
 			return err
 		}
 
-		return cmds.EmitOnce(res, stringList{l})
+		verbose, _ := req.Options[pubsubVerboseOptionName].(bool)
+
+		list := &pubsubTopicList{Topics: make([]pubsubTopic, 0, len(l))}
+		for _, topic := range l {
+			entry := pubsubTopic{Name: topic}
+			if verbose {
+				peers, err := api.PubSub().Peers(req.Context, options.PubSub.Topic(topic))
+				if err != nil {
+					return err
+				}
+				entry.Peers = len(peers)
+			}
+			list.Topics = append(list.Topics, entry)
+		}
+		return cmds.EmitOnce(res, list)
 	},
-	Type: stringList{},
+	Type: pubsubTopicList{},
 	Encoders: cmds.EncoderMap{
-		cmds.Text: cmds.MakeTypedEncoder(stringListEncoder),
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, list *pubsubTopicList) error {
+			verbose, _ := req.Options[pubsubVerboseOptionName].(bool)
+			for _, topic := range list.Topics {
+				if verbose {
+					if _, err := fmt.Fprintf(w, "%s: %d peers\n", topic.Name, topic.Peers); err != nil {
+						return err
+					}
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "%s\n", topic.Name); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
 	},
 }
 
+type pubsubTopic struct {
+	Name  string
+	Peers int
+}
+
+type pubsubTopicList struct {
+	Topics []pubsubTopic
+}
+
 func stringListEncoder(req *cmds.Request, w io.Writer, list *stringList) error {
 	for _, str := range list.Strings {
 		_, err := fmt.Fprintf(w, "%s\n", str)
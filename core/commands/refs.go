@@ -1,19 +1,17 @@
 package commands
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"io"
 	"strings"
 
-	core "github.com/ipsn/go-ipfs/core"
 	cmdenv "github.com/ipsn/go-ipfs/core/commands/cmdenv"
+	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
+	options "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
 
 	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
-	path "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-path"
 	cmds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmds"
-	ipld "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipld-format"
 	cmdkit "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmdkit"
 )
 
@@ -61,7 +59,7 @@ NOTE: List all references recursively by using the flag '-r'.
 		cmdkit.StringArg("ipfs-path", true, true, "Path to the object(s) to list refs from.").EnableStdin(),
 	},
 	Options: []cmdkit.Option{
-		cmdkit.StringOption(refsFormatOptionName, "Emit edges with given format. Available tokens: <src> <dst> <linkname>.").WithDefault("<dst>"),
+		cmdkit.StringOption(refsFormatOptionName, "Emit edges with given format. Available tokens: <src> <dst> <name> <linkname>.").WithDefault("<dst>"),
 		cmdkit.BoolOption(refsEdgesOptionName, "e", "Emit edge format: `<from> -> <to>`."),
 		cmdkit.BoolOption(refsUniqueOptionName, "u", "Omit duplicate refs from output."),
 		cmdkit.BoolOption(refsRecursiveOptionName, "r", "Recursively list links of child nodes."),
@@ -74,7 +72,7 @@ NOTE: List all references recursively by using the flag '-r'.
 		}
 
 		ctx := req.Context
-		n, err := cmdenv.GetNode(env)
+		api, err := cmdenv.GetApi(env, req)
 		if err != nil {
 			return err
 		}
@@ -97,23 +95,29 @@ NOTE: List all references recursively by using the flag '-r'.
 			format = "<src> -> <dst>"
 		}
 
-		objs, err := objectsForPaths(ctx, n, req.Arguments)
-		if err != nil {
-			return err
-		}
+		for _, sp := range req.Arguments {
+			p, err := coreiface.ParsePath(sp)
+			if err != nil {
+				return err
+			}
 
-		rw := RefWriter{
-			res:      res,
-			DAG:      n.DAG,
-			Ctx:      ctx,
-			Unique:   unique,
-			PrintFmt: format,
-			MaxDepth: maxDepth,
-		}
+			refs, err := api.Dag().Refs(ctx, p,
+				options.Dag.MaxDepth(maxDepth),
+				options.Dag.Unique(unique),
+			)
+			if err != nil {
+				return err
+			}
 
-		for _, o := range objs {
-			if _, err := rw.WriteRefs(o); err != nil {
-				if err := res.Emit(&RefWrapper{Err: err.Error()}); err != nil {
+			for edge := range refs {
+				if edge.Err != nil {
+					if err := res.Emit(&RefWrapper{Err: edge.Err.Error()}); err != nil {
+						return err
+					}
+					break
+				}
+
+				if err := res.Emit(&RefWrapper{Ref: formatEdge(format, edge)}); err != nil {
 					return err
 				}
 			}
@@ -125,6 +129,17 @@ NOTE: List all references recursively by using the flag '-r'.
 	Type:     RefWrapper{},
 }
 
+// formatEdge renders a single DAG edge with the given format string. The
+// <linkname> token predates <name> and is kept for compatibility.
+func formatEdge(format string, edge coreiface.DagEdge) string {
+	s := format
+	s = strings.Replace(s, "<src>", edge.Parent.String(), -1)
+	s = strings.Replace(s, "<dst>", edge.Child.String(), -1)
+	s = strings.Replace(s, "<name>", edge.Name, -1)
+	s = strings.Replace(s, "<linkname>", edge.Name, -1)
+	return s
+}
+
 var RefsLocalCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "List all local references.",
@@ -159,175 +174,7 @@ Displays the hashes of all local objects.
 	Type:     RefWrapper{},
 }
 
-func objectsForPaths(ctx context.Context, n *core.IpfsNode, paths []string) ([]ipld.Node, error) {
-	objects := make([]ipld.Node, len(paths))
-	for i, sp := range paths {
-		p, err := path.ParsePath(sp)
-		if err != nil {
-			return nil, err
-		}
-
-		o, err := core.Resolve(ctx, n.Namesys, n.Resolver, p)
-		if err != nil {
-			return nil, err
-		}
-		objects[i] = o
-	}
-	return objects, nil
-}
-
 type RefWrapper struct {
 	Ref string
 	Err string
 }
-
-type RefWriter struct {
-	res cmds.ResponseEmitter
-	DAG ipld.DAGService
-	Ctx context.Context
-
-	Unique   bool
-	MaxDepth int
-	PrintFmt string
-
-	seen map[string]int
-}
-
-// WriteRefs writes refs of the given object to the underlying writer.
-func (rw *RefWriter) WriteRefs(n ipld.Node) (int, error) {
-	return rw.writeRefsRecursive(n, 0)
-}
-
-func (rw *RefWriter) writeRefsRecursive(n ipld.Node, depth int) (int, error) {
-	nc := n.Cid()
-
-	var count int
-	for i, ng := range ipld.GetDAG(rw.Ctx, rw.DAG, n) {
-		lc := n.Links()[i].Cid
-		goDeeper, shouldWrite := rw.visit(lc, depth+1) // The children are at depth+1
-
-		// Avoid "Get()" on the node and continue with next Link.
-		// We can do this if:
-		// - We printed it before (thus it was already seen and
-		//   fetched with Get()
-		// - AND we must not go deeper.
-		// This is an optimization for pruned branches which have been
-		// visited before.
-		if !shouldWrite && !goDeeper {
-			continue
-		}
-
-		// We must Get() the node because:
-		// - it is new (never written)
-		// - OR we need to go deeper.
-		// This ensures printed refs are always fetched.
-		nd, err := ng.Get(rw.Ctx)
-		if err != nil {
-			return count, err
-		}
-
-		// Write this node if not done before (or !Unique)
-		if shouldWrite {
-			if err := rw.WriteEdge(nc, lc, n.Links()[i].Name); err != nil {
-				return count, err
-			}
-			count++
-		}
-
-		// Keep going deeper. This happens:
-		// - On unexplored branches
-		// - On branches not explored deep enough
-		// Note when !Unique, branches are always considered
-		// unexplored and only depth limits apply.
-		if goDeeper {
-			c, err := rw.writeRefsRecursive(nd, depth+1)
-			count += c
-			if err != nil {
-				return count, err
-			}
-		}
-	}
-
-	return count, nil
-}
-
-// visit returns two values:
-// - the first boolean is true if we should keep traversing the DAG
-// - the second boolean is true if we should print the CID
-//
-// visit will do branch pruning depending on rw.MaxDepth, previously visited
-// cids and whether rw.Unique is set. i.e. rw.Unique = false and
-// rw.MaxDepth = -1 disables any pruning. But setting rw.Unique to true will
-// prune already visited branches at the cost of keeping as set of visited
-// CIDs in memory.
-func (rw *RefWriter) visit(c cid.Cid, depth int) (bool, bool) {
-	atMaxDepth := rw.MaxDepth >= 0 && depth == rw.MaxDepth
-	overMaxDepth := rw.MaxDepth >= 0 && depth > rw.MaxDepth
-
-	// Shortcut when we are over max depth. In practice, this
-	// only applies when calling refs with --maxDepth=0, as root's
-	// children are already over max depth. Otherwise nothing should
-	// hit this.
-	if overMaxDepth {
-		return false, false
-	}
-
-	// We can shortcut right away if we don't need unique output:
-	//   - we keep traversing when not atMaxDepth
-	//   - always print
-	if !rw.Unique {
-		return !atMaxDepth, true
-	}
-
-	// Unique == true from this point.
-	// Thus, we keep track of seen Cids, and their depth.
-	if rw.seen == nil {
-		rw.seen = make(map[string]int)
-	}
-	key := string(c.Bytes())
-	oldDepth, ok := rw.seen[key]
-
-	// Unique == true && depth < MaxDepth (or unlimited) from this point
-
-	// Branch pruning cases:
-	// - We saw the Cid before and either:
-	//   - Depth is unlimited (MaxDepth = -1)
-	//   - We saw it higher (smaller depth) in the DAG (means we must have
-	//     explored deep enough before)
-	// Because we saw the CID, we don't print it again.
-	if ok && (rw.MaxDepth < 0 || oldDepth <= depth) {
-		return false, false
-	}
-
-	// Final case, we must keep exploring the DAG from this CID
-	// (unless we hit the depth limit).
-	// We note down its depth because it was either not seen
-	// or is lower than last time.
-	// We print if it was not seen.
-	rw.seen[key] = depth
-	return !atMaxDepth, !ok
-}
-
-// Write one edge
-func (rw *RefWriter) WriteEdge(from, to cid.Cid, linkname string) error {
-	if rw.Ctx != nil {
-		select {
-		case <-rw.Ctx.Done(): // just in case.
-			return rw.Ctx.Err()
-		default:
-		}
-	}
-
-	var s string
-	switch {
-	case rw.PrintFmt != "":
-		s = rw.PrintFmt
-		s = strings.Replace(s, "<src>", from.String(), -1)
-		s = strings.Replace(s, "<dst>", to.String(), -1)
-		s = strings.Replace(s, "<linkname>", linkname, -1)
-	default:
-		s += to.String()
-	}
-
-	return rw.res.Emit(&RefWrapper{Ref: s})
-}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	cmdenv "github.com/ipsn/go-ipfs/core/commands/cmdenv"
+	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
 
 	humanize "github.com/dustin/go-humanize"
 	peer "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peer"
@@ -27,12 +28,57 @@ for your IPFS node.`,
 	},
 
 	Subcommands: map[string]*cmds.Command{
+		"all":     statAllCmd,
 		"bw":      statBwCmd,
 		"repo":    repoStatCmd,
 		"bitswap": bitswapStatCmd,
 	},
 }
 
+var statAllCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Print a node-wide statistics snapshot.",
+		ShortDescription: `'ipfs stats all' returns identity, connectivity, repo usage, bitswap
+counters and bandwidth totals in a single dashboard-friendly snapshot.
+Fields backed by unavailable subsystems are left at their zero values.
+`,
+	},
+
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+
+		st, err := api.Stats().Node(req.Context)
+		if err != nil {
+			return err
+		}
+
+		return cmds.EmitOnce(res, &st)
+	},
+	Type: coreiface.NodeStats{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, st *coreiface.NodeStats) error {
+			fmt.Fprintf(w, "PeerID: %s\n", st.PeerID)
+			fmt.Fprintf(w, "AgentVersion: %s\n", st.AgentVersion)
+			for _, a := range st.Addresses {
+				fmt.Fprintf(w, "Address: %s\n", a)
+			}
+			fmt.Fprintf(w, "Peers: %d\n", st.NumPeers)
+			fmt.Fprintf(w, "RepoSize: %s\n", humanize.Bytes(st.RepoSize))
+			fmt.Fprintf(w, "RepoObjects: %d\n", st.RepoNumObjects)
+			fmt.Fprintf(w, "BlocksSent: %d\n", st.BitswapStats.BlocksSent)
+			fmt.Fprintf(w, "DataSent: %s\n", humanize.Bytes(st.BitswapStats.DataSent))
+			fmt.Fprintf(w, "BlocksReceived: %d\n", st.BitswapStats.BlocksReceived)
+			fmt.Fprintf(w, "DataReceived: %s\n", humanize.Bytes(st.BitswapStats.DataReceived))
+			fmt.Fprintf(w, "BandwidthIn: %s\n", humanize.Bytes(st.BandwidthIn))
+			fmt.Fprintf(w, "BandwidthOut: %s\n", humanize.Bytes(st.BandwidthOut))
+			return nil
+		}),
+	},
+}
+
 const (
 	statPeerOptionName     = "peer"
 	statProtoOptionName    = "proto"
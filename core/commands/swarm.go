@@ -6,12 +6,15 @@ import (
 	"io"
 	"path"
 	"sort"
+	"time"
 
 	commands "github.com/ipsn/go-ipfs/commands"
 	cmdenv "github.com/ipsn/go-ipfs/core/commands/cmdenv"
+	options "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
 	repo "github.com/ipsn/go-ipfs/repo"
 	fsrepo "github.com/ipsn/go-ipfs/repo/fsrepo"
 
+	humanize "github.com/dustin/go-humanize"
 	inet "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-net"
 	swarm "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-swarm"
 	ma "github.com/ipsn/go-ipfs/gxlibs/github.com/multiformats/go-multiaddr"
@@ -43,13 +46,102 @@ ipfs peers in the internet.
 	},
 	Subcommands: map[string]*cmds.Command{
 		"addrs":      swarmAddrsCmd,
+		"bandwidth":  swarmBandwidthCmd,
 		"connect":    swarmConnectCmd,
 		"disconnect": swarmDisconnectCmd,
 		"filters":    swarmFiltersCmd,
 		"peers":      swarmPeersCmd,
+		"protect":    swarmProtectCmd,
+		"unprotect":  swarmUnprotectCmd,
 	},
 }
 
+const (
+	swarmBwPeerOptionName  = "peer"
+	swarmBwProtoOptionName = "proto"
+	swarmBwPollOptionName  = "poll"
+)
+
+type bandwidthStat struct {
+	Peer    string
+	In      uint64
+	Out     uint64
+	RateIn  float64
+	RateOut float64
+}
+
+var swarmBandwidthCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show per-peer bandwidth statistics.",
+		ShortDescription: `
+'ipfs swarm bandwidth' lists the traffic exchanged with each connected
+peer. Use '--peer' to limit the output to one peer, '--proto' to report
+the traffic of a single protocol across all peers, and '--poll' to keep
+emitting fresh snapshots at the given interval.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption(swarmBwPeerOptionName, "Only show traffic exchanged with this peer."),
+		cmdkit.StringOption(swarmBwProtoOptionName, "Show traffic for this protocol id instead of per-peer stats."),
+		cmdkit.StringOption(swarmBwPollOptionName, "Keep emitting snapshots at this interval (e.g. '1s')."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+
+		var opts []options.SwarmBandwidthOption
+		if p, ok := req.Options[swarmBwPeerOptionName].(string); ok {
+			opts = append(opts, options.Swarm.Peer(p))
+		}
+		if proto, ok := req.Options[swarmBwProtoOptionName].(string); ok {
+			opts = append(opts, options.Swarm.Proto(proto))
+		}
+		if poll, ok := req.Options[swarmBwPollOptionName].(string); ok {
+			interval, err := time.ParseDuration(poll)
+			if err != nil {
+				return fmt.Errorf("bandwidth: invalid poll interval: %s", err)
+			}
+			opts = append(opts, options.Swarm.Poll(interval))
+		}
+
+		ch, err := api.Swarm().Bandwidth(req.Context, opts...)
+		if err != nil {
+			return err
+		}
+
+		for s := range ch {
+			err = res.Emit(&bandwidthStat{
+				Peer:    s.Peer.Pretty(),
+				In:      s.In,
+				Out:     s.Out,
+				RateIn:  s.RateIn,
+				RateOut: s.RateOut,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, s *bandwidthStat) error {
+			name := s.Peer
+			if name == "" {
+				proto, _ := req.Options[swarmBwProtoOptionName].(string)
+				name = proto
+			}
+			fmt.Fprintf(w, "%s: in %s (%s/s), out %s (%s/s)\n", name,
+				humanize.Bytes(s.In), humanize.Bytes(uint64(s.RateIn)),
+				humanize.Bytes(s.Out), humanize.Bytes(uint64(s.RateOut)))
+			return nil
+		}),
+	},
+	Type: bandwidthStat{},
+}
+
 const (
 	swarmVerboseOptionName   = "verbose"
 	swarmStreamsOptionName   = "streams"
@@ -431,6 +523,85 @@ it will reconnect.
 	Type: stringList{},
 }
 
+var swarmProtectCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Protect a peer's connections from being closed.",
+		ShortDescription: `
+'ipfs swarm protect' marks a peer as important under the given tag, so
+the connection manager will not close its connections under resource
+pressure. The protection lasts until every tag is removed again with
+'ipfs swarm unprotect'.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("peer-id", true, false, "ID of peer to protect."),
+		cmdkit.StringArg("tag", true, false, "Tag to protect the peer under."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+
+		pid, err := peer.IDB58Decode(req.Arguments[0])
+		if err != nil {
+			return fmt.Errorf("invalid peer id: %s", err)
+		}
+
+		if err := api.Swarm().Protect(req.Context, pid, req.Arguments[1]); err != nil {
+			return err
+		}
+
+		return cmds.EmitOnce(res, &stringList{[]string{"protect " + pid.Pretty() + " success"}})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(stringListEncoder),
+	},
+	Type: stringList{},
+}
+
+var swarmUnprotectCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Remove a protection tag from a peer.",
+		ShortDescription: `
+'ipfs swarm unprotect' removes the given protection tag from a peer. The
+peer becomes subject to connection trimming again once all of its tags
+have been removed.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("peer-id", true, false, "ID of peer to unprotect."),
+		cmdkit.StringArg("tag", true, false, "Tag to remove from the peer."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+
+		pid, err := peer.IDB58Decode(req.Arguments[0])
+		if err != nil {
+			return fmt.Errorf("invalid peer id: %s", err)
+		}
+
+		protected, err := api.Swarm().Unprotect(req.Context, pid, req.Arguments[1])
+		if err != nil {
+			return err
+		}
+
+		out := "unprotect " + pid.Pretty() + " success"
+		if protected {
+			out += " (still protected under other tags)"
+		}
+
+		return cmds.EmitOnce(res, &stringList{[]string{out}})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(stringListEncoder),
+	},
+	Type: stringList{},
+}
+
 // parseAddresses is a function that takes in a slice of string peer addresses
 // (multiaddr + peerid) and returns slices of multiaddrs and peerids.
 func parseAddresses(addrs []string) (iaddrs []iaddr.IPFSAddr, err error) {
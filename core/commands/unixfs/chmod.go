@@ -0,0 +1,69 @@
+package unixfs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	cmdenv "github.com/ipsn/go-ipfs/core/commands/cmdenv"
+	iface "github.com/ipsn/go-ipfs/core/coreapi/interface"
+
+	cmds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmds"
+	cmdkit "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-cmdkit"
+)
+
+type ChmodOutput struct {
+	Cid string
+}
+
+var ChmodCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Set the unix permission bits on a unixfs object.",
+		ShortDescription: `
+Stores the given octal permission bits in the unixfs metadata of the object
+at the given path. As IPFS objects are immutable, a new object is created
+and its CID printed; the original object is left untouched.
+
+Example:
+
+    > ipfs unixfs chmod /ipfs/QmW2WQi7j6c7UgJTarActp7tDNikE4B2qXtFCfLPdsgaTQ 755
+    QmaCpDMGvV2BGHeYERUEnRQAwe3N8SzbUtfsmvsqQLuvuJ
+`,
+	},
+
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("ipfs-path", true, false, "The path to the object to update."),
+		cmdkit.StringArg("mode", true, false, "Octal unix permission bits to store, e.g. 755."),
+	},
+	Run: func(req *cmds.Request, res cmds.ResponseEmitter, env cmds.Environment) error {
+		api, err := cmdenv.GetApi(env, req)
+		if err != nil {
+			return err
+		}
+
+		p, err := iface.ParsePath(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		m, err := strconv.ParseUint(req.Arguments[1], 8, 32)
+		if err != nil || m > 07777 {
+			return fmt.Errorf("invalid mode %q", req.Arguments[1])
+		}
+
+		rp, err := api.Unixfs().Chmod(req.Context, p, os.FileMode(m))
+		if err != nil {
+			return err
+		}
+
+		return cmds.EmitOnce(res, &ChmodOutput{Cid: rp.Cid().String()})
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, out *ChmodOutput) error {
+			fmt.Fprintln(w, out.Cid)
+			return nil
+		}),
+	},
+	Type: ChmodOutput{},
+}
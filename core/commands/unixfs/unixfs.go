@@ -21,6 +21,7 @@ objects (e.g. fanout and chunking).
 	},
 
 	Subcommands: map[string]*cmds.Command{
-		"ls": LsCmd,
+		"ls":    LsCmd,
+		"chmod": ChmodCmd,
 	},
 }
@@ -18,6 +18,7 @@ import (
 	"io/ioutil"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	version "github.com/ipsn/go-ipfs"
@@ -61,6 +62,7 @@ import (
 	peer "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peer"
 	smux "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-stream-muxer"
 	pstore "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peerstore"
+	path "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-path"
 	resolver "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-path/resolver"
 	mplex "github.com/ipsn/go-ipfs/gxlibs/github.com/whyrusleeping/go-smux-multiplex"
 	pubsub "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-pubsub"
@@ -127,6 +129,11 @@ type IpfsNode struct {
 	FilesRoot       *mfs.Root
 	RecordValidator record.Validator
 
+	// filesPubKey is the keystore key, if any, under which the MFS files
+	// root is additionally published over IPNS
+	filesPubKeyLk sync.Mutex
+	filesPubKey   ic.PrivKey
+
 	// Online
 	PeerHost     p2phost.Host        // the network host (server+client)
 	Bootstrapper io.Closer           // the periodic bootstrapper
@@ -579,7 +586,11 @@ func (n *IpfsNode) startOnlineServicesWithHost(ctx context.Context, routingOptio
 
 	// setup exchange service
 	bitswapNetwork := bsnet.NewFromIpfsHost(n.PeerHost, n.Routing)
-	n.Exchange = bitswap.New(ctx, bitswapNetwork, n.Blockstore)
+	var bsOpts []bitswap.Option
+	if cfg.Swarm.BitswapTaskWorkers > 0 {
+		bsOpts = append(bsOpts, bitswap.WithTaskWorkerCount(cfg.Swarm.BitswapTaskWorkers))
+	}
+	n.Exchange = bitswap.New(ctx, bitswapNetwork, n.Blockstore, bsOpts...)
 
 	size, err := n.getCacheSize()
 	if err != nil {
@@ -855,10 +866,53 @@ func (n *IpfsNode) loadBootstrapPeers() ([]pstore.PeerInfo, error) {
 	return toPeerInfos(parsed), nil
 }
 
+// SetFilesPublishKey selects the keystore key under which the MFS files root
+// is republished over IPNS, in addition to being persisted locally. The
+// change takes effect on the next publish, without a daemon restart. An
+// empty name disables IPNS publishing of the files root.
+func (n *IpfsNode) SetFilesPublishKey(name string) error {
+	var k ic.PrivKey
+	if name != "" {
+		var err error
+		k, err = n.GetKey(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	n.filesPubKeyLk.Lock()
+	n.filesPubKey = k
+	n.filesPubKeyLk.Unlock()
+	return nil
+}
+
+// publishFilesRoot publishes the files root under the configured publish
+// key, if one is set and the name system is available.
+func (n *IpfsNode) publishFilesRoot(ctx context.Context, c cid.Cid) error {
+	n.filesPubKeyLk.Lock()
+	k := n.filesPubKey
+	n.filesPubKeyLk.Unlock()
+
+	if k == nil || n.Namesys == nil {
+		return nil
+	}
+
+	return n.Namesys.Publish(ctx, k, path.FromCid(c))
+}
+
 func (n *IpfsNode) loadFilesRoot() error {
 	dsk := ds.NewKey("/local/filesroot")
 	pf := func(ctx context.Context, c cid.Cid) error {
-		return n.Repo.Datastore().Put(dsk, c.Bytes())
+		if err := n.Repo.Datastore().Put(dsk, c.Bytes()); err != nil {
+			return err
+		}
+		return n.publishFilesRoot(ctx, c)
+	}
+
+	if cfg, err := n.Repo.Config(); err == nil && cfg.Ipns.FilesPublishKey != "" {
+		if err := n.SetFilesPublishKey(cfg.Ipns.FilesPublishKey); err != nil {
+			log.Warningf("files publish key %q not usable: %s", cfg.Ipns.FilesPublishKey, err)
+		}
 	}
 
 	var nd *merkledag.ProtoNode
@@ -6,13 +6,20 @@ import (
 	"errors"
 	"io"
 	"io/ioutil"
+	"sync"
 
 	util "github.com/ipsn/go-ipfs/blocks/blockstoreutil"
 	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
 	caopts "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
+	"github.com/ipsn/go-ipfs/pin"
 
 	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
 	blocks "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-block-format"
+	bserv "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-blockservice"
+	blockstore "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-blockstore"
+	offlinexch "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-exchange-offline"
+	ipld "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipld-format"
+	dag "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-merkledag"
 )
 
 type BlockAPI CoreAPI
@@ -23,7 +30,7 @@ type BlockStat struct {
 }
 
 func (api *BlockAPI) Put(ctx context.Context, src io.Reader, opts ...caopts.BlockPutOption) (coreiface.BlockStat, error) {
-	_, pref, err := caopts.BlockPutOptions(opts...)
+	settings, pref, err := caopts.BlockPutOptions(opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -43,9 +50,38 @@ func (api *BlockAPI) Put(ctx context.Context, src io.Reader, opts ...caopts.Bloc
 		return nil, err
 	}
 
-	err = api.blocks.AddBlock(b)
-	if err != nil {
-		return nil, err
+	if settings.Pin {
+		defer api.blockstore.PinLock().Unlock()
+	}
+
+	if settings.Compression != "" && settings.Compression != blockstore.CompressionNone {
+		// store the compressed representation directly, then announce the
+		// uncompressed block like blockservice would, so bitswap and the
+		// notification system only ever see the real content
+		cb, err := blockstore.NewCompressedBlock(b, settings.Compression)
+		if err != nil {
+			return nil, err
+		}
+		if err := api.blockstore.Put(cb); err != nil {
+			return nil, err
+		}
+		if api.exchange != nil {
+			if err := api.exchange.HasBlock(b); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		err = api.blocks.AddBlock(b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if settings.Pin {
+		api.pinning.PinWithMode(b.Cid(), pin.Recursive)
+		if err := api.pinning.Flush(); err != nil {
+			return nil, err
+		}
 	}
 
 	return &BlockStat{path: coreiface.IpldPath(b.Cid()), size: len(data)}, nil
@@ -65,6 +101,59 @@ func (api *BlockAPI) Get(ctx context.Context, p coreiface.Path) (io.Reader, erro
 	return bytes.NewReader(b.RawData()), nil
 }
 
+// Warm walks the DAG under the path and reads every reachable local block
+// through the caching blockstore, populating its ARC/bloom layers so
+// repeated scans of the same content skip the backing datastore. It never
+// fetches from the network; absent blocks are counted instead of fetched.
+func (api *BlockAPI) Warm(ctx context.Context, p coreiface.Path, depth int) (*coreiface.BlockWarmStat, error) {
+	rp, err := api.core().ResolvePath(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	dserv := dag.NewDAGService(bserv.New(api.blockstore, offlinexch.Exchange(api.blockstore)))
+
+	st := new(coreiface.BlockWarmStat)
+	visited := cid.NewSet()
+
+	var walk func(c cid.Cid, level int) error
+	walk = func(c cid.Cid, level int) error {
+		if !visited.Visit(c) {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		nd, err := dserv.Get(ctx, c)
+		if err == ipld.ErrNotFound {
+			st.Missing++
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		st.Blocks++
+		st.Bytes += uint64(len(nd.RawData()))
+
+		if depth > 0 && level >= depth {
+			return nil
+		}
+		for _, lnk := range nd.Links() {
+			if err := walk(lnk.Cid, level+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(rp.Cid(), 1); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
 func (api *BlockAPI) Rm(ctx context.Context, p coreiface.Path, opts ...caopts.BlockRmOption) error {
 	rp, err := api.core().ResolvePath(ctx, p)
 	if err != nil {
@@ -120,6 +209,55 @@ func (api *BlockAPI) Stat(ctx context.Context, p coreiface.Path) (coreiface.Bloc
 	}, nil
 }
 
+// StatAll streams a stat for every block in the local blockstore. Sizes come
+// from the blockstore index via GetSize, so no block data is ever fetched.
+func (api *BlockAPI) StatAll(ctx context.Context) (<-chan coreiface.BlockStat, error) {
+	cids, err := api.blockstore.AllKeysChan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan coreiface.BlockStat)
+	go func() {
+		defer close(out)
+		for c := range cids {
+			size, err := api.blockstore.GetSize(c)
+			if err != nil {
+				// likely racing a concurrent delete, skip the block
+				continue
+			}
+			select {
+			case out <- &BlockStat{path: coreiface.IpldPath(c), size: size}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (api *BlockAPI) List(ctx context.Context) (<-chan coreiface.BlockStat, error) {
+	cids, err := api.blockstore.AllKeysChan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan coreiface.BlockStat)
+	go func() {
+		defer close(out)
+		for c := range cids {
+			select {
+			case out <- &lazyBlockStat{path: coreiface.IpldPath(c), bs: api.blockstore}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
 func (bs *BlockStat) Size() int {
 	return bs.size
 }
@@ -128,6 +266,32 @@ func (bs *BlockStat) Path() coreiface.ResolvedPath {
 	return bs.path
 }
 
+// lazyBlockStat is a BlockStat which defers looking up the block size in the
+// blockstore until Size is called, allowing List to stream keys without
+// touching every block up front
+type lazyBlockStat struct {
+	path coreiface.ResolvedPath
+	bs   blockstore.Blockstore
+
+	once sync.Once
+	size int
+}
+
+func (bs *lazyBlockStat) Size() int {
+	bs.once.Do(func() {
+		size, err := bs.bs.GetSize(bs.path.Cid())
+		if err != nil {
+			size = -1
+		}
+		bs.size = size
+	})
+	return bs.size
+}
+
+func (bs *lazyBlockStat) Path() coreiface.ResolvedPath {
+	return bs.path
+}
+
 func (api *BlockAPI) core() coreiface.CoreAPI {
 	return (*CoreAPI)(api)
 }
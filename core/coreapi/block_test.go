@@ -2,13 +2,23 @@ package coreapi_test
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"strings"
+	"sync/atomic"
 	"testing"
 
+	"github.com/ipsn/go-ipfs/core"
+	"github.com/ipsn/go-ipfs/core/coreapi"
 	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
 	opt "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
+	"github.com/ipsn/go-ipfs/keystore"
+	"github.com/ipsn/go-ipfs/repo"
 
+	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
+	config "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-config"
+	datastore "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore"
+	syncds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore/sync"
 	mh "github.com/ipsn/go-ipfs/gxlibs/github.com/multiformats/go-multihash"
 )
 
@@ -46,6 +56,49 @@ func TestBlockPutFormat(t *testing.T) {
 	}
 }
 
+func TestBlockPutCodecPreserved(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := api.Block().Put(ctx, strings.NewReader(`Hello`), opt.Block.Format("dag-cbor"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the canonical name maps to the same codec as the historical one
+	if res.Path().Cid().Type() != cid.DagCBOR {
+		t.Errorf("expected the cid to declare the dag-cbor codec, got %d", res.Path().Cid().Type())
+	}
+
+	// the declared codec survives a round trip through Get
+	r, err := api.Block().Get(ctx, res.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(d) != "Hello" {
+		t.Errorf("unexpected block content: %q", d)
+	}
+
+	stat, err := api.Block().Stat(ctx, res.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Path().Cid().Type() != cid.DagCBOR {
+		t.Errorf("expected stat to report the dag-cbor codec, got %d", stat.Path().Cid().Type())
+	}
+
+	if _, err := api.Block().Put(ctx, strings.NewReader(`Hello`), opt.Block.Format("not-a-codec")); err == nil {
+		t.Error("expected an unrecognized format to be rejected")
+	}
+}
+
 func TestBlockPutHash(t *testing.T) {
 	ctx := context.Background()
 	_, api, err := makeAPI(ctx)
@@ -156,6 +209,82 @@ func TestBlockRm(t *testing.T) {
 	}
 }
 
+func TestBlockList(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	want := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		data := fmt.Sprintf("block %d", i)
+		res, err := api.Block().Put(ctx, strings.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[res.Path().Cid().String()] = len(data)
+	}
+
+	blocks, err := api.Block().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for b := range blocks {
+		size, ok := want[b.Path().Cid().String()]
+		if !ok {
+			continue // the mock node may have other blocks in its blockstore
+		}
+		if b.Size() != size {
+			t.Errorf("wrong size for %s: expected %d, got %d", b.Path().Cid().String(), size, b.Size())
+		}
+		delete(want, b.Path().Cid().String())
+	}
+
+	if len(want) != 0 {
+		t.Errorf("%d blocks missing from listing", len(want))
+	}
+}
+
+func TestBlockStatAll(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	want := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		data := fmt.Sprintf("block %d", i)
+		res, err := api.Block().Put(ctx, strings.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[res.Path().Cid().String()] = len(data)
+	}
+
+	stats, err := api.Block().StatAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for b := range stats {
+		size, ok := want[b.Path().Cid().String()]
+		if !ok {
+			continue // the mock node may have other blocks in its blockstore
+		}
+		if b.Size() != size {
+			t.Errorf("wrong size for %s: expected %d, got %d", b.Path().Cid().String(), size, b.Size())
+		}
+		delete(want, b.Path().Cid().String())
+	}
+
+	if len(want) != 0 {
+		t.Errorf("%d blocks missing from stat stream", len(want))
+	}
+}
+
 func TestBlockStat(t *testing.T) {
 	ctx := context.Background()
 	_, api, err := makeAPI(ctx)
@@ -181,3 +310,254 @@ func TestBlockStat(t *testing.T) {
 		t.Error("length doesn't match")
 	}
 }
+
+func TestBlockPutPin(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := api.Block().Put(ctx, strings.NewReader(`Hello`), opt.Block.Pin(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := api.Pin().Ls(ctx, opt.Pin.Type.Recursive())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 {
+		t.Fatalf("expected 1 recursive pin, got %d", len(list))
+	}
+
+	if list[0].Path().Cid().String() != res.Path().Cid().String() {
+		t.Errorf("expected %s to be pinned, got %s", res.Path(), list[0].Path())
+	}
+
+	err = api.Pin().Rm(ctx, res.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err = api.Pin().Ls(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 0 {
+		t.Errorf("expected the pin to be removed, got %d pins", len(list))
+	}
+}
+
+func TestObjectPutPinStatus(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stages []string
+	_, err = api.Object().Put(ctx, strings.NewReader(`{"Data":"foo"}`),
+		opt.Object.Pin(true),
+		opt.Object.PinStatus(func(status string) {
+			stages = append(stages, status)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stages) != 3 || stages[0] != "pinning" || stages[1] != "flushing" || stages[2] != "flushed" {
+		t.Errorf("got unexpected pin status sequence: %v", stages)
+	}
+}
+
+// countingDatastore tallies reads hitting the blockstore namespace of the
+// backing datastore, letting tests observe which lookups the blockstore
+// cache absorbed
+type countingDatastore struct {
+	repo.Datastore
+
+	blockReads  int64
+	blockWrites int64
+}
+
+func (d *countingDatastore) countKey(k datastore.Key) {
+	if strings.HasPrefix(k.String(), "/blocks") {
+		atomic.AddInt64(&d.blockReads, 1)
+	}
+}
+
+func (d *countingDatastore) Get(k datastore.Key) ([]byte, error) {
+	d.countKey(k)
+	return d.Datastore.Get(k)
+}
+
+func (d *countingDatastore) Has(k datastore.Key) (bool, error) {
+	d.countKey(k)
+	return d.Datastore.Has(k)
+}
+
+func (d *countingDatastore) GetSize(k datastore.Key) (int, error) {
+	d.countKey(k)
+	return d.Datastore.GetSize(k)
+}
+
+func (d *countingDatastore) Put(k datastore.Key, value []byte) error {
+	if strings.HasPrefix(k.String(), "/blocks") {
+		atomic.AddInt64(&d.blockWrites, 1)
+	}
+	return d.Datastore.Put(k, value)
+}
+
+// Batch routes batched writes back through this wrapper so they are counted
+// like direct ones
+func (d *countingDatastore) Batch() (datastore.Batch, error) {
+	return datastore.NewBasicBatch(d), nil
+}
+
+func (d *countingDatastore) reads() int64 {
+	return atomic.LoadInt64(&d.blockReads)
+}
+
+func (d *countingDatastore) writes() int64 {
+	return atomic.LoadInt64(&d.blockWrites)
+}
+
+func makeCountingAPI(ctx context.Context) (*core.IpfsNode, coreiface.CoreAPI, *countingDatastore, error) {
+	cds := &countingDatastore{Datastore: syncds.MutexWrap(datastore.NewMapDatastore())}
+
+	c := config.Config{}
+	c.Identity = config.Identity{PeerID: testPeerID}
+
+	node, err := core.NewNode(ctx, &core.BuildCfg{
+		Repo: &repo.Mock{
+			C: c,
+			D: cds,
+			K: keystore.NewMemKeystore(),
+		},
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	api, err := coreapi.NewCoreAPI(node)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return node, api, cds, nil
+}
+
+func TestBlockWarm(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	node, api, cds, err := makeCountingAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := api.Unixfs().Add(ctx, flatDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := api.ResolveNode(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cids := []cid.Cid{root.Cid()}
+	for _, lnk := range root.Links() {
+		cids = append(cids, lnk.Cid)
+	}
+
+	before := cds.reads()
+	st, err := api.Block().Warm(ctx, p, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if st.Blocks != len(cids) {
+		t.Errorf("expected %d warmed blocks, got %d", len(cids), st.Blocks)
+	}
+	if st.Missing != 0 {
+		t.Errorf("expected no missing blocks, got %d", st.Missing)
+	}
+	if st.Bytes == 0 {
+		t.Error("expected warmed bytes to be non-zero")
+	}
+	if cds.reads() == before {
+		t.Error("expected the warm-up walk to read through to the datastore")
+	}
+
+	// the walk read every block through the caching blockstore, so
+	// existence and size checks must now be served without touching the
+	// backing datastore
+	warmed := cds.reads()
+	for _, c := range cids {
+		has, err := node.Blockstore.Has(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !has {
+			t.Errorf("expected %s to be present after warm-up", c)
+		}
+		if _, err := node.Blockstore.GetSize(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := cds.reads(); got != warmed {
+		t.Errorf("expected post-warm Has/GetSize to hit the cache, got %d extra datastore reads", got-warmed)
+	}
+}
+
+func TestBlockWarmDepth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, api, _, err := makeCountingAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := api.Unixfs().Add(ctx, flatDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := api.Block().Warm(ctx, p, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if st.Blocks != 1 {
+		t.Errorf("expected a depth-1 warm-up to touch only the root, got %d blocks", st.Blocks)
+	}
+}
+
+func TestBlockWarmMissing(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, api, _, err := makeCountingAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := cid.Decode(strings.TrimPrefix(hello, "/ipfs/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := api.Block().Warm(ctx, coreiface.IpfsPath(c), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if st.Blocks != 0 || st.Missing != 1 {
+		t.Errorf("expected 0 blocks and 1 missing for an absent cid, got %d/%d", st.Blocks, st.Missing)
+	}
+}
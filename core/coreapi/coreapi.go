@@ -17,12 +17,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
+
 	"github.com/ipsn/go-ipfs/core"
 	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
 	"github.com/ipsn/go-ipfs/core/coreapi/interface/options"
 	"github.com/ipsn/go-ipfs/namesys"
 	"github.com/ipsn/go-ipfs/pin"
 	"github.com/ipsn/go-ipfs/repo"
+	"github.com/ipsn/go-ipfs/repo/fsrepo"
 
 	ci "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-crypto"
 	"github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-exchange-interface"
@@ -35,10 +38,13 @@ import (
 	pubsub "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-pubsub"
 	ipld "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipld-format"
 	logging "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-log"
+	metrics "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-metrics"
 	dag "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-merkledag"
 	offlineroute "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-routing/offline"
+	uio "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs/io"
 	record "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-record"
 	p2phost "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-host"
+	identify "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p/p2p/protocol/identify"
 )
 
 var log = logging.Logger("core/coreapi")
@@ -61,12 +67,15 @@ type CoreAPI struct {
 	peerHost        p2phost.Host
 	recordValidator record.Validator
 	exchange        exchange.Interface
+	reporter        metrics.Reporter
 
 	namesys namesys.NameSystem
 	routing routing.IpfsRouting
 
 	pubSub *pubsub.PubSub
 
+	pinStatsCache *pinStatsCache
+
 	checkPublishAllowed func() error
 	checkOnline         func(allowOffline bool) error
 
@@ -135,6 +144,69 @@ func (api *CoreAPI) PubSub() coreiface.PubSubAPI {
 	return (*PubSubAPI)(api)
 }
 
+// Stats returns the StatsAPI interface implementation backed by the go-ipfs node
+func (api *CoreAPI) Stats() coreiface.StatsAPI {
+	return (*StatsAPI)(api)
+}
+
+// Subsystems reports whether each CoreAPI subsystem is available and online
+// in the current API configuration. APIs constructed with the Offline option
+// report network-backed subsystems as unavailable.
+func (api *CoreAPI) Subsystems() map[string]coreiface.SubsystemStatus {
+	online := !api.parentOpts.Offline && api.nd.OnlineMode()
+
+	local := func(available bool) coreiface.SubsystemStatus {
+		return coreiface.SubsystemStatus{Available: available, Online: available && online}
+	}
+	networked := func(available bool) coreiface.SubsystemStatus {
+		// network-backed subsystems are only usable while online
+		return coreiface.SubsystemStatus{Available: available && online, Online: available && online}
+	}
+
+	return map[string]coreiface.SubsystemStatus{
+		"block":    local(api.blocks != nil),
+		"dag":      local(api.dag != nil),
+		"pinning":  local(api.pinning != nil),
+		"exchange": local(api.exchange != nil),
+		"dht":      networked(api.routing != nil && api.peerHost != nil),
+		"namesys":  networked(api.namesys != nil),
+		"pubsub":   networked(api.pubSub != nil),
+	}
+}
+
+// NodeInfo reports identity and configuration details of the node backing
+// this API instance. Experimental feature flags are reported with their
+// effective values: flags wired through package globals at construction time
+// reflect that global, the rest come straight from the repo config.
+func (api *CoreAPI) NodeInfo(ctx context.Context) (*coreiface.NodeInfo, error) {
+	cfg, err := api.repo.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := fsrepo.BestKnownPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return &coreiface.NodeInfo{
+		ID:           api.identity.Pretty(),
+		AgentVersion: identify.ClientVersion,
+		RepoPath:     path,
+		Online:       !api.parentOpts.Offline && api.nd.OnlineMode(),
+		Experimental: map[string]bool{
+			"FilestoreEnabled": cfg.Experimental.FilestoreEnabled,
+			"UrlstoreEnabled":  cfg.Experimental.UrlstoreEnabled,
+			// sharding is applied to a package global when the node is
+			// built, report what is actually in effect
+			"ShardingEnabled":      uio.UseHAMTSharding,
+			"Libp2pStreamMounting": cfg.Experimental.Libp2pStreamMounting,
+			"P2pHttpProxy":         cfg.Experimental.P2pHttpProxy,
+			"QUIC":                 cfg.Experimental.QUIC,
+		},
+	}, nil
+}
+
 // WithOptions returns api with global options applied
 func (api *CoreAPI) WithOptions(opts ...options.ApiOption) (coreiface.CoreAPI, error) {
 	settings := api.parentOpts // make sure to copy
@@ -168,10 +240,13 @@ func (api *CoreAPI) WithOptions(opts ...options.ApiOption) (coreiface.CoreAPI, e
 		namesys:         n.Namesys,
 		recordValidator: n.RecordValidator,
 		exchange:        n.Exchange,
+		reporter:        n.Reporter,
 		routing:         n.Routing,
 
 		pubSub: n.PubSub,
 
+		pinStatsCache: &pinStatsCache{},
+
 		nd:         n,
 		parentOpts: settings,
 	}
@@ -190,6 +265,23 @@ func (api *CoreAPI) WithOptions(opts ...options.ApiOption) (coreiface.CoreAPI, e
 		return nil
 	}
 
+	if routers := extraContentRouters(n.Routing); len(routers) > 1 {
+		cfg, err := n.Repo.Config()
+		if err != nil {
+			return nil, err
+		}
+
+		var timeout time.Duration
+		if cfg.Routing.DelegateTimeout != "" {
+			timeout, err = time.ParseDuration(cfg.Routing.DelegateTimeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Routing.DelegateTimeout: %s", err)
+			}
+		}
+
+		subApi.routing = newCompositeRouting(n.Routing, routers, timeout)
+	}
+
 	if settings.Offline {
 		cfg, err := n.Repo.Config()
 		if err != nil {
@@ -204,23 +296,49 @@ func (api *CoreAPI) WithOptions(opts ...options.ApiOption) (coreiface.CoreAPI, e
 			return nil, fmt.Errorf("cannot specify negative resolve cache size")
 		}
 
+		// keep name resolution working against locally cached records, the
+		// offline router only ever consults the datastore
 		subApi.routing = offlineroute.NewOfflineRouter(subApi.repo.Datastore(), subApi.recordValidator)
 		subApi.namesys = namesys.NewNameSystem(subApi.routing, subApi.repo.Datastore(), cs)
 
 		subApi.peerstore = nil
 		subApi.peerHost = nil
-		subApi.namesys = nil
 		subApi.recordValidator = nil
+		subApi.reporter = nil
 
 		subApi.exchange = offlinexch.Exchange(subApi.blockstore)
-		subApi.blocks = bserv.New(api.blockstore, subApi.exchange)
+		subApi.blocks = bserv.New(subApi.blockstore, subApi.exchange)
 		subApi.dag = dag.NewDAGService(subApi.blocks)
 
 	}
 
+	if settings.LocalOnly {
+		// serve DAG reads from the local blockstore only, everything that
+		// would have to reach the network is rejected with ErrOffline
+		subApi.exchange = offlinexch.Exchange(subApi.blockstore)
+		subApi.blocks = bserv.New(subApi.blockstore, subApi.exchange)
+		subApi.dag = dag.NewDAGService(subApi.blocks)
+
+		subApi.checkOnline = func(allowOffline bool) error {
+			if !allowOffline {
+				return coreiface.ErrOffline
+			}
+			return nil
+		}
+	}
+
 	return subApi, nil
 }
 
+// WithSession returns a read-only version of the api backed by a session DAG.
+// Blocks fetched while resolving through the returned api are shared between
+// calls, making bursts of related lookups (like resolving all children of a
+// directory) cheaper. The session is scoped to ctx and meant to be
+// short-lived; write operations through it will fail.
+func (api *CoreAPI) WithSession(ctx context.Context) coreiface.CoreAPI {
+	return api.getSession(ctx)
+}
+
 // getSession returns new api backed by the same node with a read-only session DAG
 func (api *CoreAPI) getSession(ctx context.Context) *CoreAPI {
 	sesApi := *api
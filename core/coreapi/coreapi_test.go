@@ -0,0 +1,240 @@
+package coreapi_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
+	"github.com/ipsn/go-ipfs/core/coreapi/interface/options"
+
+	files "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-files"
+)
+
+func TestSubsystemsOffline(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offline, err := api.WithOptions(options.Api.Offline(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subs := offline.Subsystems()
+
+	for _, name := range []string{"dht", "namesys", "pubsub"} {
+		if subs[name].Available {
+			t.Errorf("expected %s to be unavailable on an offline api", name)
+		}
+		if subs[name].Online {
+			t.Errorf("expected %s to be offline on an offline api", name)
+		}
+	}
+
+	for _, name := range []string{"block", "dag", "pinning"} {
+		if !subs[name].Available {
+			t.Errorf("expected %s to remain available on an offline api", name)
+		}
+		if subs[name].Online {
+			t.Errorf("expected %s to report offline on an offline api", name)
+		}
+	}
+}
+
+func TestNodeInfo(t *testing.T) {
+	ctx := context.Background()
+	node, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := node.Repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Experimental.FilestoreEnabled = true
+
+	info, err := api.NodeInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.ID != node.Identity.Pretty() {
+		t.Errorf("expected id %s, got %s", node.Identity.Pretty(), info.ID)
+	}
+	if info.AgentVersion == "" {
+		t.Error("expected an agent version")
+	}
+	if info.Online {
+		t.Error("expected the mock node to report offline")
+	}
+	if !info.Experimental["FilestoreEnabled"] {
+		t.Error("expected FilestoreEnabled to reflect the config")
+	}
+	if info.Experimental["QUIC"] {
+		t.Error("expected QUIC to stay at its default")
+	}
+
+	cfg.Experimental.FilestoreEnabled = false
+	info, err = api.NodeInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Experimental["FilestoreEnabled"] {
+		t.Error("expected FilestoreEnabled to track config changes")
+	}
+}
+
+func TestOfflineSubApis(t *testing.T) {
+	ctx := context.Background()
+	node, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offline, err := api.WithOptions(options.Api.Offline(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every sub-api must be callable without nil-pointer panics; calls that
+	// need the network fail with an error instead
+
+	p, err := offline.Unixfs().Add(ctx, files.NewBytesFile([]byte("offline data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := offline.Unixfs().Get(ctx, p); err != nil {
+		t.Error(err)
+	}
+	if _, err := offline.Block().Get(ctx, p); err != nil {
+		t.Error(err)
+	}
+	if _, err := offline.Object().Stat(ctx, p); err != nil {
+		t.Error(err)
+	}
+	if _, err := offline.Dag().Get(ctx, p); err != nil {
+		t.Error(err)
+	}
+	if _, err := offline.Pin().Ls(ctx); err != nil {
+		t.Error(err)
+	}
+	if _, err := offline.Key().List(ctx); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := offline.Name().Resolve(ctx, "/ipns/"+testPeerID); err == nil {
+		t.Error("expected resolving an unknown name offline to fail")
+	}
+	if _, err := offline.Swarm().Peers(ctx); err != coreiface.ErrOffline {
+		t.Errorf("expected ErrOffline from Swarm().Peers, got %s", err)
+	}
+	if _, err := offline.Dht().FindPeer(ctx, node.Identity); err == nil {
+		t.Error("expected finding peers offline to fail")
+	}
+	if _, err := offline.PubSub().Ls(ctx); err == nil {
+		t.Error("expected pubsub to be unavailable offline")
+	}
+}
+
+func TestLocalOnly(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := api.Unixfs().Add(ctx, files.NewBytesFile([]byte("local data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local, err := api.WithOptions(options.Api.LocalOnly(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// local blocks stay readable
+	if _, err := local.Unixfs().Get(ctx, p); err != nil {
+		t.Error(err)
+	}
+	if _, err := local.ResolveNode(ctx, p); err != nil {
+		t.Error(err)
+	}
+
+	// operations that have to reach the network are rejected
+	if _, err := local.Name().Publish(ctx, p); err != coreiface.ErrOffline {
+		t.Errorf("expected ErrOffline from Name().Publish, got %s", err)
+	}
+}
+
+func setupResolveBench(b *testing.B, api coreiface.CoreAPI) []coreiface.Path {
+	ctx := context.Background()
+
+	links := make([]string, 50)
+	for i := range links {
+		p, err := api.Object().Put(ctx, strings.NewReader(fmt.Sprintf(`{"Data":"entry%d"}`, i)))
+		if err != nil {
+			b.Fatal(err)
+		}
+		links[i] = fmt.Sprintf(`{"Name":"e%d", "Hash":"%s", "Size":8}`, i, p.Cid().String())
+	}
+
+	dir, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"dir", "Links":[`+strings.Join(links, ",")+`]}`))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	paths := make([]coreiface.Path, len(links))
+	for i := range paths {
+		p, err := coreiface.ParsePath(fmt.Sprintf("%s/e%d", dir.String(), i))
+		if err != nil {
+			b.Fatal(err)
+		}
+		paths[i] = p
+	}
+	return paths
+}
+
+func BenchmarkResolveNode(b *testing.B) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	paths := setupResolveBench(b, api)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range paths {
+			if _, err := api.ResolveNode(ctx, p); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkResolveNodeSession(b *testing.B) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	paths := setupResolveBench(b, api)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ses := api.WithSession(ctx)
+		for _, p := range paths {
+			if _, err := ses.ResolveNode(ctx, p); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
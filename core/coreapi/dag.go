@@ -2,6 +2,7 @@ package coreapi
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"sync"
@@ -67,6 +68,137 @@ func (api *DagAPI) Tree(ctx context.Context, p coreiface.Path, opts ...caopts.Da
 	return out, nil
 }
 
+// Refs streams the edges of the DAG below the path `p`. Traversal depth,
+// uniqueness and the edge limit are controlled with `MaxDepth`, `Unique` and
+// `MaxEdges` options.
+func (api *DagAPI) Refs(ctx context.Context, p coreiface.Path, opts ...caopts.DagRefsOption) (<-chan coreiface.DagEdge, error) {
+	settings, err := caopts.DagRefsOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	nd, err := api.Get(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan coreiface.DagEdge)
+
+	go func() {
+		defer close(out)
+
+		rt := &refsTraversal{
+			dag:      api.dag,
+			unique:   settings.Unique,
+			maxDepth: settings.MaxDepth,
+			maxEdges: settings.MaxEdges,
+			out:      out,
+		}
+		if err := rt.walk(ctx, nd, 0); err != nil && err != errRefsEdgeLimit {
+			select {
+			case out <- coreiface.DagEdge{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// errRefsEdgeLimit unwinds a refs traversal that hit the MaxEdges limit
+var errRefsEdgeLimit = errors.New("edge limit reached")
+
+type refsTraversal struct {
+	dag ipld.DAGService
+	out chan<- coreiface.DagEdge
+
+	unique   bool
+	maxDepth int
+	maxEdges int
+
+	edges int
+	seen  map[string]int
+}
+
+func (rt *refsTraversal) walk(ctx context.Context, n ipld.Node, depth int) error {
+	nc := n.Cid()
+
+	for i, ng := range ipld.GetDAG(ctx, rt.dag, n) {
+		lc := n.Links()[i].Cid
+		goDeeper, shouldEmit := rt.visit(lc, depth+1) // the children are at depth+1
+
+		// avoid Get() on nodes of pruned branches which have been both
+		// emitted and explored before
+		if !shouldEmit && !goDeeper {
+			continue
+		}
+
+		// the node is either new or needs exploring, so emitted refs are
+		// always fetched
+		nd, err := ng.Get(ctx)
+		if err != nil {
+			return err
+		}
+
+		if shouldEmit {
+			if rt.maxEdges >= 0 && rt.edges >= rt.maxEdges {
+				return errRefsEdgeLimit
+			}
+			select {
+			case rt.out <- coreiface.DagEdge{Parent: nc, Child: lc, Name: n.Links()[i].Name}:
+				rt.edges++
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if goDeeper {
+			if err := rt.walk(ctx, nd, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// visit returns whether to keep traversing below the cid, and whether to emit
+// the edge leading to it. With unique unset and no depth limit nothing is
+// pruned; unique prunes already explored branches at the cost of keeping a
+// set of visited cids (and the depth they were seen at) in memory.
+func (rt *refsTraversal) visit(c cid.Cid, depth int) (bool, bool) {
+	atMaxDepth := rt.maxDepth >= 0 && depth == rt.maxDepth
+	overMaxDepth := rt.maxDepth >= 0 && depth > rt.maxDepth
+
+	// in practice this only applies with MaxDepth=0, as the root's children
+	// are already over the limit then
+	if overMaxDepth {
+		return false, false
+	}
+
+	if !rt.unique {
+		return !atMaxDepth, true
+	}
+
+	if rt.seen == nil {
+		rt.seen = make(map[string]int)
+	}
+	key := string(c.Bytes())
+	oldDepth, ok := rt.seen[key]
+
+	// prune the branch if the cid was seen before, either with unlimited
+	// depth or higher up the DAG (meaning it was already explored deep
+	// enough)
+	if ok && (rt.maxDepth < 0 || oldDepth <= depth) {
+		return false, false
+	}
+
+	// keep exploring from here (unless at the depth limit), noting the new
+	// shallower depth, and emit the edge if the cid is new
+	rt.seen[key] = depth
+	return !atMaxDepth, !ok
+}
+
 // Batch creates new DagBatch
 func (api *DagAPI) Batch(ctx context.Context) coreiface.DagBatch {
 	return &dagBatch{api: api}
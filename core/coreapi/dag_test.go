@@ -2,6 +2,7 @@ package coreapi_test
 
 import (
 	"context"
+	"fmt"
 	"path"
 	"strings"
 	"testing"
@@ -149,3 +150,96 @@ func TestBatch(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestRefs(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a diamond: both mid nodes link to the same leaf
+	leaf, err := api.Dag().Put(ctx, strings.NewReader(`"leaf"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mid1, err := api.Dag().Put(ctx, strings.NewReader(fmt.Sprintf(`{"l": {"/": "%s"}}`, leaf.Cid())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mid2, err := api.Dag().Put(ctx, strings.NewReader(fmt.Sprintf(`{"l": {"/": "%s"}, "tag": "two"}`, leaf.Cid())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := api.Dag().Put(ctx, strings.NewReader(fmt.Sprintf(`{"a": {"/": "%s"}, "b": {"/": "%s"}}`, mid1.Cid(), mid2.Cid())))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collect := func(opts ...opt.DagRefsOption) []coreiface.DagEdge {
+		refs, err := api.Dag().Refs(ctx, root, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var edges []coreiface.DagEdge
+		for edge := range refs {
+			if edge.Err != nil {
+				t.Fatal(edge.Err)
+			}
+			edges = append(edges, edge)
+		}
+		return edges
+	}
+
+	leafCount := func(edges []coreiface.DagEdge) int {
+		n := 0
+		for _, e := range edges {
+			if e.Child.Equals(leaf.Cid()) {
+				n++
+			}
+		}
+		return n
+	}
+
+	// default lists direct links only
+	edges := collect()
+	if len(edges) != 2 {
+		t.Fatalf("expected 2 direct refs, got %d", len(edges))
+	}
+	for _, e := range edges {
+		if !e.Parent.Equals(root.Cid()) {
+			t.Errorf("direct ref with parent %s, expected root", e.Parent)
+		}
+	}
+
+	// a full traversal visits the shared leaf through both branches
+	edges = collect(opt.Dag.MaxDepth(-1))
+	if len(edges) != 4 {
+		t.Fatalf("expected 4 refs, got %d", len(edges))
+	}
+	if n := leafCount(edges); n != 2 {
+		t.Errorf("expected the leaf to be listed twice, got %d", n)
+	}
+
+	// unique emits the leaf only once
+	edges = collect(opt.Dag.MaxDepth(-1), opt.Dag.Unique(true))
+	if len(edges) != 3 {
+		t.Fatalf("expected 3 unique refs, got %d", len(edges))
+	}
+	if n := leafCount(edges); n != 1 {
+		t.Errorf("expected the leaf to be listed once, got %d", n)
+	}
+
+	// the depth limit prunes the leaves
+	edges = collect(opt.Dag.MaxDepth(1))
+	if len(edges) != 2 {
+		t.Fatalf("expected 2 refs at depth 1, got %d", len(edges))
+	}
+
+	// the edge limit ends the stream early
+	edges = collect(opt.Dag.MaxDepth(-1), opt.Dag.MaxEdges(3))
+	if len(edges) != 3 {
+		t.Fatalf("expected the edge limit to stop at 3 refs, got %d", len(edges))
+	}
+}
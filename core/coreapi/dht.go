@@ -3,12 +3,18 @@ package coreapi
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
 	caopts "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
 
 	blockservice "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-blockservice"
 	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
+	ds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore"
+	dsq "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore/query"
+	dshelp "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-ds-help"
 	routing "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-routing"
 	blockstore "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-blockstore"
 	peer "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peer"
@@ -55,10 +61,29 @@ func (api *DhtAPI) FindProviders(ctx context.Context, p coreiface.Path, opts ...
 		return nil, fmt.Errorf("number of providers must be greater than 0")
 	}
 
+	if settings.Offline {
+		r := api.routing
+		if cr, ok := r.(*compositeRouting); ok {
+			// local lookups don't fan out, ask the primary router
+			r = cr.IpfsRouting
+		}
+		lr, ok := r.(localProviderRouting)
+		if !ok {
+			return nil, fmt.Errorf("offline provider lookup not supported by this routing implementation")
+		}
+		return lr.LocalFindProvidersAsync(ctx, rp.Cid(), numProviders), nil
+	}
+
 	pchan := api.routing.FindProvidersAsync(ctx, rp.Cid(), numProviders)
 	return pchan, nil
 }
 
+// localProviderRouting is implemented by routing systems which can answer
+// provider queries from local state without hitting the network
+type localProviderRouting interface {
+	LocalFindProvidersAsync(context.Context, cid.Cid, int) <-chan pstore.PeerInfo
+}
+
 func (api *DhtAPI) Provide(ctx context.Context, path coreiface.Path, opts ...caopts.DhtProvideOption) error {
 	settings, err := caopts.DhtProvideOptions(opts...)
 	if err != nil {
@@ -98,6 +123,175 @@ func (api *DhtAPI) Provide(ctx context.Context, path coreiface.Path, opts ...cao
 	return nil
 }
 
+// ProvideMany enumerates all blocks reachable from the given roots into a
+// single streaming set and announces each one, skipping CIDs recorded as
+// provided within the reprovide interval unless the Force option is set.
+func (api *DhtAPI) ProvideMany(ctx context.Context, paths []coreiface.Path, opts ...caopts.DhtProvideManyOption) error {
+	settings, err := caopts.DhtProvideManyOptions(opts...)
+	if err != nil {
+		return err
+	}
+
+	err = api.checkOnline(false)
+	if err != nil {
+		return err
+	}
+
+	cids := make([]cid.Cid, 0, len(paths))
+	for _, p := range paths {
+		rp, err := api.core().ResolvePath(ctx, p)
+		if err != nil {
+			return err
+		}
+
+		c := rp.Cid()
+		has, err := api.blockstore.Has(c)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return fmt.Errorf("block %s not found locally, cannot provide", c)
+		}
+
+		cids = append(cids, c)
+	}
+
+	return provideManyRec(ctx, api.routing, api.blockstore, api.repo.Datastore(), cids, settings.Force)
+}
+
+// provideRecords tracks the last announce time of provided CIDs in the repo
+// datastore so overlapping ProvideMany calls don't re-announce the same keys.
+var provideRecordPrefix = ds.NewKey("/provided/")
+
+// provideRecordTTL matches the default reprovider interval. CIDs announced
+// more recently than this are skipped unless forced.
+var provideRecordTTL = time.Hour * 12
+
+func recentlyProvided(d ds.Datastore, c cid.Cid) bool {
+	v, err := d.Get(provideRecordPrefix.Child(dshelp.CidToDsKey(c)))
+	if err != nil {
+		return false
+	}
+
+	nanos, err := strconv.ParseInt(string(v), 10, 64)
+	if err != nil {
+		return false
+	}
+
+	return time.Since(time.Unix(0, nanos)) < provideRecordTTL
+}
+
+func recordProvided(d ds.Datastore, c cid.Cid) {
+	v := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := d.Put(provideRecordPrefix.Child(dshelp.CidToDsKey(c)), []byte(v)); err != nil {
+		log.Warningf("failed to record provide time for %s: %s", c, err)
+	}
+}
+
+// ProvideRecords lists the contents of the recently-provided cache consulted
+// by ProvideMany. Records older than the reprovide interval are included so
+// operators can inspect stale state too.
+func (api *DhtAPI) ProvideRecords(ctx context.Context) ([]coreiface.ProvideRecord, error) {
+	d := api.repo.Datastore()
+
+	res, err := d.Query(dsq.Query{Prefix: provideRecordPrefix.String()})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	var records []coreiface.ProvideRecord
+	for {
+		e, ok := res.NextSync()
+		if !ok {
+			break
+		}
+		if e.Error != nil {
+			return nil, e.Error
+		}
+
+		c, err := dshelp.DsKeyToCid(ds.RawKey(strings.TrimPrefix(e.Key, provideRecordPrefix.String())))
+		if err != nil {
+			log.Warningf("malformed provide record key %q: %s", e.Key, err)
+			continue
+		}
+
+		nanos, err := strconv.ParseInt(string(e.Value), 10, 64)
+		if err != nil {
+			log.Warningf("malformed provide record for %s: %s", c, err)
+			continue
+		}
+
+		records = append(records, coreiface.ProvideRecord{Cid: c, When: time.Unix(0, nanos)})
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+	}
+
+	return records, nil
+}
+
+// ClearProvideRecords empties the recently-provided cache so the next
+// ProvideMany announces every enumerated key again. Deletes go through the
+// datastore one key at a time, so calling this while provides are in flight
+// is safe; keys announced concurrently are simply re-recorded.
+func (api *DhtAPI) ClearProvideRecords(ctx context.Context) error {
+	records, err := api.ProvideRecords(ctx)
+	if err != nil {
+		return err
+	}
+
+	d := api.repo.Datastore()
+	for _, r := range records {
+		if err := d.Delete(provideRecordPrefix.Child(dshelp.CidToDsKey(r.Cid))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func provideManyRec(ctx context.Context, r routing.IpfsRouting, bs blockstore.Blockstore, d ds.Datastore, cids []cid.Cid, force bool) error {
+	provided := cidutil.NewStreamingSet()
+
+	errCh := make(chan error)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dserv := dag.NewDAGService(blockservice.New(bs, offline.Exchange(bs)))
+		for _, c := range cids {
+			err := dag.EnumerateChildrenAsync(ctx, dag.GetLinksDirect(dserv), c, provided.Visitor(ctx))
+			if err != nil {
+				errCh <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case k := <-provided.New:
+			if !force && recentlyProvided(d, k) {
+				continue
+			}
+			err := r.Provide(ctx, k, true)
+			if err != nil {
+				return err
+			}
+			recordProvided(d, k)
+		case err := <-errCh:
+			return err
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
 func provideKeys(ctx context.Context, r routing.IpfsRouting, cids []cid.Cid) error {
 	for _, c := range cids {
 		err := r.Provide(ctx, c, true)
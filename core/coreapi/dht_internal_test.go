@@ -0,0 +1,62 @@
+package coreapi
+
+import (
+	"context"
+	"testing"
+
+	ds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore"
+	syncds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore/sync"
+	blockstore "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-blockstore"
+	dag "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-merkledag"
+	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
+	routing "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-routing"
+)
+
+// countingRouting counts Provide calls, to observe dedup behavior
+type countingRouting struct {
+	routing.IpfsRouting
+	provides int
+}
+
+func (r *countingRouting) Provide(ctx context.Context, c cid.Cid, brd bool) error {
+	r.provides++
+	return nil
+}
+
+func TestProvideManyDedup(t *testing.T) {
+	ctx := context.Background()
+
+	d := syncds.MutexWrap(ds.NewMapDatastore())
+	bs := blockstore.NewBlockstore(syncds.MutexWrap(ds.NewMapDatastore()))
+	r := &countingRouting{}
+
+	nd := dag.NewRawNode([]byte("provide me"))
+	if err := bs.Put(nd); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []cid.Cid{nd.Cid()}
+
+	if err := provideManyRec(ctx, r, bs, d, keys, false); err != nil {
+		t.Fatal(err)
+	}
+	if r.provides != 1 {
+		t.Fatalf("expected 1 provide, got %d", r.provides)
+	}
+
+	// recently provided keys are skipped
+	if err := provideManyRec(ctx, r, bs, d, keys, false); err != nil {
+		t.Fatal(err)
+	}
+	if r.provides != 1 {
+		t.Fatalf("expected recently provided key to be skipped, got %d provides", r.provides)
+	}
+
+	// force bypasses the cache
+	if err := provideManyRec(ctx, r, bs, d, keys, true); err != nil {
+		t.Fatal(err)
+	}
+	if r.provides != 2 {
+		t.Fatalf("expected forced re-provide, got %d provides", r.provides)
+	}
+}
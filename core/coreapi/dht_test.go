@@ -5,6 +5,7 @@ import (
 	"io"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/ipsn/go-ipfs/core/coreapi/interface"
 	"github.com/ipsn/go-ipfs/core/coreapi/interface/options"
@@ -107,3 +108,82 @@ func TestDhtProvide(t *testing.T) {
 		t.Errorf("got wrong provider: %s != %s", provider.ID.String(), nds[0].Identity.String())
 	}
 }
+
+func TestDhtProvideRecords(t *testing.T) {
+	ctx := context.Background()
+	_, apis, err := makeAPISwarm(ctx, true, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := addTestObject(ctx, apis[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the dht fills its routing table asynchronously after the swarm
+	// connects, so retry until the announcement can be routed
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		err = apis[0].Dht().ProvideMany(ctx, []iface.Path{p})
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	recs, err := apis[0].Dht().ProvideRecords(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) == 0 {
+		t.Fatal("expected provide records after ProvideMany")
+	}
+	first := recs[0].When
+
+	// a second call is deduped, so the recorded time does not move
+	err = apis[0].Dht().ProvideMany(ctx, []iface.Path{p})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recs, err = apis[0].Dht().ProvideRecords(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recs[0].When.Equal(first) {
+		t.Error("expected the second ProvideMany to be deduped")
+	}
+
+	// clearing empties the cache and the next call announces again
+	err = apis[0].Dht().ClearProvideRecords(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recs, err = apis[0].Dht().ProvideRecords(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 0 {
+		t.Fatalf("expected an empty cache after clearing, got %d records", len(recs))
+	}
+
+	err = apis[0].Dht().ProvideMany(ctx, []iface.Path{p})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recs, err = apis[0].Dht().ProvideRecords(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) == 0 {
+		t.Fatal("expected the cleared key to be provided and recorded again")
+	}
+	if !recs[0].When.After(first) {
+		t.Error("expected a fresh provide time after clearing")
+	}
+}
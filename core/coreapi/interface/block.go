@@ -16,6 +16,19 @@ type BlockStat interface {
 	Path() ResolvedPath
 }
 
+// BlockWarmStat reports the result of a cache warm-up walk.
+type BlockWarmStat struct {
+	// Blocks is the number of distinct local blocks touched.
+	Blocks int
+
+	// Bytes is the cumulative serialized size of the touched blocks.
+	Bytes uint64
+
+	// Missing counts blocks skipped because they are not in the local
+	// store; warming never fetches from the network.
+	Missing int
+}
+
 // BlockAPI specifies the interface to the block layer
 type BlockAPI interface {
 	// Put imports raw block data, hashing it using specified settings.
@@ -24,6 +37,12 @@ type BlockAPI interface {
 	// Get attempts to resolve the path and return a reader for data in the block
 	Get(context.Context, Path) (io.Reader, error)
 
+	// Warm walks the DAG under the path through the caching blockstore,
+	// touching each local block so repeated scans of the same content are
+	// served from the cache. A positive depth bounds the walk, counting the
+	// root as the first level; zero or negative walks the whole DAG.
+	Warm(ctx context.Context, p Path, depth int) (*BlockWarmStat, error)
+
 	// Rm removes the block specified by the path from local blockstore.
 	// By default an error will be returned if the block can't be found locally.
 	//
@@ -33,4 +52,13 @@ type BlockAPI interface {
 
 	// Stat returns information on
 	Stat(context.Context, Path) (BlockStat, error)
+
+	// StatAll streams a stat for every block in the local blockstore. Sizes
+	// are read from the blockstore index, not from the block data
+	StatAll(context.Context) (<-chan BlockStat, error)
+
+	// List returns a channel streaming information about every block in the
+	// local blockstore. Block sizes are resolved lazily, when Size is called
+	// on the emitted stat
+	List(context.Context) (<-chan BlockStat, error)
 }
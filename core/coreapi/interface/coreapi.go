@@ -42,6 +42,9 @@ type CoreAPI interface {
 	// PubSub returns an implementation of PubSub API
 	PubSub() PubSubAPI
 
+	// Stats returns an implementation of Stats API
+	Stats() StatsAPI
+
 	// ResolvePath resolves the path using Unixfs resolver
 	ResolvePath(context.Context, Path) (ResolvedPath, error)
 
@@ -49,7 +52,65 @@ type CoreAPI interface {
 	// resolver, gets and returns the resolved Node
 	ResolveNode(context.Context, Path) (ipld.Node, error)
 
+	// ResolveBatch resolves the given paths concurrently with a bounded
+	// worker pool, returning a result per path. /ipns paths go through
+	// namesys, immutable paths through the Unixfs resolver. The context
+	// bounds the whole batch, cancel it to enforce a batch timeout
+	ResolveBatch(ctx context.Context, paths []string) map[string]ResolveResult
+
+	// Subsystems reports the availability of each CoreAPI subsystem in the
+	// current API configuration
+	Subsystems() map[string]SubsystemStatus
+
+	// NodeInfo describes the node backing this API instance, including the
+	// effective value of each experimental feature flag
+	NodeInfo(ctx context.Context) (*NodeInfo, error)
+
+	// WithSession returns a read-only version of this API backed by a
+	// resolution session which caches fetched blocks for reuse between
+	// calls. Sessions are meant to be short-lived; writes through the
+	// returned API will fail
+	WithSession(ctx context.Context) CoreAPI
+
 	// WithOptions creates new instance of CoreAPI based on this instance with
 	// a set of options applied
 	WithOptions(...options.ApiOption) (CoreAPI, error)
 }
+
+// ResolveResult is the outcome of resolving a single path of a batch.
+type ResolveResult struct {
+	// Path is the resolved path, nil when Err is set
+	Path ResolvedPath
+
+	// Err is the resolution error for this path, if any
+	Err error
+}
+
+// NodeInfo describes the identity and configuration of the node backing a
+// CoreAPI instance.
+type NodeInfo struct {
+	// ID is the peer identity of the node
+	ID string
+
+	// AgentVersion is the agent string the node announces over identify
+	AgentVersion string
+
+	// RepoPath is the filesystem path of the backing repo, if known
+	RepoPath string
+
+	// Online is set when the node can reach the network
+	Online bool
+
+	// Experimental maps each experimental feature flag to its effective
+	// value, merging the repo config with compile-time defaults
+	Experimental map[string]bool
+}
+
+// SubsystemStatus describes the state of a single CoreAPI subsystem
+type SubsystemStatus struct {
+	// Available is set when the subsystem is wired up in this API instance
+	Available bool
+
+	// Online is set when the subsystem can reach the network
+	Online bool
+}
@@ -6,9 +6,25 @@ import (
 
 	"github.com/ipsn/go-ipfs/core/coreapi/interface/options"
 
+	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
 	ipld "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipld-format"
 )
 
+// DagEdge is a single parent->child link encountered during a Refs traversal
+type DagEdge struct {
+	// Parent is the cid of the node the link was found in
+	Parent cid.Cid
+
+	// Child is the cid the link points to
+	Child cid.Cid
+
+	// Name is the name of the link, if the format carries one
+	Name string
+
+	// Err terminates the stream when set
+	Err error
+}
+
 // DagOps groups operations that can be batched together
 type DagOps interface {
 	// Put inserts data using specified format and input encoding.
@@ -36,6 +52,11 @@ type DagAPI interface {
 	// Tree returns list of paths within a node specified by the path.
 	Tree(ctx context.Context, path Path, opts ...options.DagTreeOption) ([]Path, error)
 
+	// Refs streams the edges of the DAG below the path. The returned channel
+	// is closed when the traversal is done; a DagEdge with Err set reports a
+	// traversal error and ends the stream
+	Refs(ctx context.Context, path Path, opts ...options.DagRefsOption) (<-chan DagEdge, error)
+
 	// Batch creates new DagBatch
 	Batch(ctx context.Context) DagBatch
 }
@@ -2,13 +2,25 @@ package iface
 
 import (
 	"context"
+	"time"
 
 	"github.com/ipsn/go-ipfs/core/coreapi/interface/options"
 
+	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
 	peer "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peer"
 	pstore "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peerstore"
 )
 
+// ProvideRecord is one entry of the recently-provided cache consulted by
+// ProvideMany to skip keys announced within the reprovide interval
+type ProvideRecord struct {
+	// Cid is the announced key
+	Cid cid.Cid
+
+	// When is the time of the last announce
+	When time.Time
+}
+
 // DhtAPI specifies the interface to the DHT
 // Note: This API will likely get deprecated in near future, see
 // https://github.com/ipfs/interface-ipfs-core/issues/249 for more context.
@@ -23,4 +35,16 @@ type DhtAPI interface {
 
 	// Provide announces to the network that you are providing given values
 	Provide(context.Context, Path, ...options.DhtProvideOption) error
+
+	// ProvideMany announces all blocks reachable from the given roots,
+	// deduplicating overlapping DAGs and CIDs announced within the reprovide
+	// interval. Use options.Dht.Force to re-announce unconditionally
+	ProvideMany(context.Context, []Path, ...options.DhtProvideManyOption) error
+
+	// ProvideRecords lists the contents of the recently-provided cache
+	ProvideRecords(context.Context) ([]ProvideRecord, error)
+
+	// ClearProvideRecords empties the recently-provided cache so the next
+	// ProvideMany announces every enumerated key again
+	ClearProvideRecords(context.Context) error
 }
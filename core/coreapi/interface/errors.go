@@ -3,7 +3,8 @@ package iface
 import "errors"
 
 var (
-	ErrIsDir   = errors.New("this dag node is a directory")
-	ErrNotFile = errors.New("this dag node is not a regular file")
-	ErrOffline = errors.New("this action must be run in online mode, try running 'ipfs daemon' first")
+	ErrIsDir        = errors.New("this dag node is a directory")
+	ErrNotDirectory = errors.New("this dag node is not a directory")
+	ErrNotFile      = errors.New("this dag node is not a regular file")
+	ErrOffline      = errors.New("this action must be run in online mode, try running 'ipfs daemon' first")
 )
@@ -2,6 +2,7 @@ package iface
 
 import (
 	"context"
+	"io"
 
 	options "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
 
@@ -38,4 +39,16 @@ type KeyAPI interface {
 
 	// Remove removes keys from keystore. Returns ipns path of the removed key
 	Remove(ctx context.Context, name string) (Key, error)
+
+	// Import reads a PEM-encoded PKCS8 private key from r, stores it in the
+	// keystore under the specified name and returns the imported key
+	Import(ctx context.Context, name string, r io.Reader, opts ...options.KeyImportOption) (Key, error)
+
+	// Sign signs the given data with the private key named `name`, returning
+	// the raw signature bytes
+	Sign(ctx context.Context, name string, data io.Reader) ([]byte, error)
+
+	// Verify checks the signature over the given data using the public key
+	// of the given peer
+	Verify(ctx context.Context, pid peer.ID, sig []byte, data io.Reader) (bool, error)
 }
@@ -3,6 +3,7 @@ package iface
 import (
 	"context"
 	"errors"
+	"time"
 
 	options "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
 )
@@ -19,6 +20,15 @@ type IpnsEntry interface {
 
 type IpnsResult struct {
 	Path
+
+	// TTL is how long the result may be cached for. Zero means unknown.
+	TTL time.Duration
+
+	// Source reports where the record that produced this result came from:
+	// "cache", "dht", "dnslink" or "proquint". For recursive lookups it
+	// refers to the last step.
+	Source string
+
 	Err error
 }
 
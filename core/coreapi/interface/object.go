@@ -77,11 +77,19 @@ type ObjectAPI interface {
 	// Get returns the node for the path
 	Get(context.Context, Path) (ipld.Node, error)
 
+	// GetWithSchema returns the decoded form of the node at the path after
+	// validating it against the inline schema: a JSON object mapping field
+	// names to the type names "string", "int", "float", "bool", "list",
+	// "map", "link" or "any". Field names ending in '?' are optional,
+	// fields not named in the schema are rejected
+	GetWithSchema(ctx context.Context, path Path, schema string) (map[string]interface{}, error)
+
 	// Data returns reader for data of the node
 	Data(context.Context, Path) (io.Reader, error)
 
-	// Links returns lint or links the node contains
-	Links(context.Context, Path) ([]*ipld.Link, error)
+	// Links streams the links the node contains over a channel. HAMT-sharded
+	// directories are enumerated lazily, one shard at a time
+	Links(context.Context, Path) (<-chan *ipld.Link, error)
 
 	// Stat returns information about the node
 	Stat(context.Context, Path) (*ObjectStat, error)
@@ -91,16 +99,31 @@ type ObjectAPI interface {
 	// with WithCreate option).
 	AddLink(ctx context.Context, base Path, name string, child Path, opts ...options.ObjectAddLinkOption) (ResolvedPath, error)
 
+	// CreateDirectory builds a unixfs directory containing the given
+	// entries in a single pass and returns its path
+	CreateDirectory(ctx context.Context, entries map[string]Path) (ResolvedPath, error)
+
+	// AppendLinks adds multiple new links to the node in a single pass,
+	// keeping the links already present. It errors when a new link name
+	// collides with an existing one
+	AppendLinks(ctx context.Context, base Path, links map[string]Path) (ResolvedPath, error)
+
 	// RmLink removes a link from the node
 	RmLink(ctx context.Context, base Path, link string) (ResolvedPath, error)
 
 	// AppendData appends data to the node
 	AppendData(context.Context, Path, io.Reader) (ResolvedPath, error)
 
+	// SpliceData appends a slice of the source node's data segment to the
+	// base node's data without moving the bytes through the client. A
+	// negative length takes everything from offset to the end of the
+	// source data
+	SpliceData(ctx context.Context, base Path, source Path, offset, length int64) (ResolvedPath, error)
+
 	// SetData sets the data contained in the node
 	SetData(context.Context, Path, io.Reader) (ResolvedPath, error)
 
 	// Diff returns a set of changes needed to transform the first object into the
 	// second.
-	Diff(context.Context, Path, Path) ([]ObjectChange, error)
+	Diff(context.Context, Path, Path, ...options.ObjectDiffOption) ([]ObjectChange, error)
 }
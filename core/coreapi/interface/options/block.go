@@ -6,10 +6,20 @@ import (
 	mh "github.com/ipsn/go-ipfs/gxlibs/github.com/multiformats/go-multihash"
 )
 
+// codecAliases accepts the canonical multicodec names alongside the
+// historical ones go-cid registers, so callers can use the same format
+// strings as modern 'ipfs block put --format'.
+var codecAliases = map[string]string{
+	"dag-pb":   "protobuf",
+	"dag-cbor": "cbor",
+}
+
 type BlockPutSettings struct {
-	Codec    string
-	MhType   uint64
-	MhLength int
+	Codec       string
+	MhType      uint64
+	MhLength    int
+	Compression string
+	Pin         bool
 }
 
 type BlockRmSettings struct {
@@ -44,6 +54,10 @@ func BlockPutOptions(opts ...BlockPutOption) (*BlockPutSettings, cid.Prefix, err
 		}
 	}
 
+	if alias, ok := codecAliases[options.Codec]; ok {
+		options.Codec = alias
+	}
+
 	if options.Codec == "v0" && options.MhType == mh.SHA2_256 {
 		pref.Version = 0
 	}
@@ -86,7 +100,9 @@ type blockOpts struct{}
 var Block blockOpts
 
 // Format is an option for Block.Put which specifies the multicodec to use to
-// serialize the object. Default is "v0"
+// serialize the object, and with that the codec declared by the resulting
+// cid. Both the historical names ("protobuf", "cbor") and the canonical
+// multicodec names ("dag-pb", "dag-cbor") are accepted. Default is "v0"
 func (blockOpts) Format(codec string) BlockPutOption {
 	return func(settings *BlockPutSettings) error {
 		settings.Codec = codec
@@ -105,6 +121,25 @@ func (blockOpts) Hash(mhType uint64, mhLen int) BlockPutOption {
 	}
 }
 
+// Compression is an option for Block.Put which stores the block compressed
+// at rest. The CID still refers to the uncompressed content and reads
+// decompress transparently. Supported codecs: "gzip" and "none" (default).
+func (blockOpts) Compression(codec string) BlockPutOption {
+	return func(settings *BlockPutSettings) error {
+		settings.Compression = codec
+		return nil
+	}
+}
+
+// Pin is an option for Block.Put which recursively pins the added block and
+// flushes the pinset. Default is false
+func (blockOpts) Pin(pin bool) BlockPutOption {
+	return func(settings *BlockPutSettings) error {
+		settings.Pin = pin
+		return nil
+	}
+}
+
 // Force is an option for Block.Rm which, when set to true, will ignore
 // non-existing blocks
 func (blockOpts) Force(force bool) BlockRmOption {
@@ -17,8 +17,15 @@ type DagTreeSettings struct {
 	Depth int
 }
 
+type DagRefsSettings struct {
+	MaxDepth int
+	Unique   bool
+	MaxEdges int
+}
+
 type DagPutOption func(*DagPutSettings) error
 type DagTreeOption func(*DagTreeSettings) error
+type DagRefsOption func(*DagRefsSettings) error
 
 func DagPutOptions(opts ...DagPutOption) (*DagPutSettings, error) {
 	options := &DagPutSettings{
@@ -51,6 +58,22 @@ func DagTreeOptions(opts ...DagTreeOption) (*DagTreeSettings, error) {
 	return options, nil
 }
 
+func DagRefsOptions(opts ...DagRefsOption) (*DagRefsSettings, error) {
+	options := &DagRefsSettings{
+		MaxDepth: 1,
+		Unique:   false,
+		MaxEdges: -1,
+	}
+
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return options, nil
+}
+
 type dagOpts struct{}
 
 var Dag dagOpts
@@ -93,3 +116,32 @@ func (dagOpts) Depth(depth int) DagTreeOption {
 		return nil
 	}
 }
+
+// MaxDepth is an option for Dag.Refs which specifies how deep to traverse.
+// Children of the root are at depth 1. Default is 1 (direct links only), -1
+// means no depth limit
+func (dagOpts) MaxDepth(depth int) DagRefsOption {
+	return func(settings *DagRefsSettings) error {
+		settings.MaxDepth = depth
+		return nil
+	}
+}
+
+// Unique is an option for Dag.Refs which makes the traversal emit each child
+// cid at most once, pruning branches that were already explored. Default is
+// false
+func (dagOpts) Unique(unique bool) DagRefsOption {
+	return func(settings *DagRefsSettings) error {
+		settings.Unique = unique
+		return nil
+	}
+}
+
+// MaxEdges is an option for Dag.Refs which stops the traversal after the
+// given number of edges has been emitted. Default is -1 (no limit)
+func (dagOpts) MaxEdges(n int) DagRefsOption {
+	return func(settings *DagRefsSettings) error {
+		settings.MaxEdges = n
+		return nil
+	}
+}
@@ -6,10 +6,16 @@ type DhtProvideSettings struct {
 
 type DhtFindProvidersSettings struct {
 	NumProviders int
+	Offline      bool
+}
+
+type DhtProvideManySettings struct {
+	Force bool
 }
 
 type DhtProvideOption func(*DhtProvideSettings) error
 type DhtFindProvidersOption func(*DhtFindProvidersSettings) error
+type DhtProvideManyOption func(*DhtProvideManySettings) error
 
 func DhtProvideOptions(opts ...DhtProvideOption) (*DhtProvideSettings, error) {
 	options := &DhtProvideSettings{
@@ -28,6 +34,21 @@ func DhtProvideOptions(opts ...DhtProvideOption) (*DhtProvideSettings, error) {
 func DhtFindProvidersOptions(opts ...DhtFindProvidersOption) (*DhtFindProvidersSettings, error) {
 	options := &DhtFindProvidersSettings{
 		NumProviders: 20,
+		Offline:      false,
+	}
+
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return options, nil
+}
+
+func DhtProvideManyOptions(opts ...DhtProvideManyOption) (*DhtProvideManySettings, error) {
+	options := &DhtProvideManySettings{
+		Force: false,
 	}
 
 	for _, opt := range opts {
@@ -53,10 +74,30 @@ func (dhtOpts) Recursive(recursive bool) DhtProvideOption {
 }
 
 // NumProviders is an option for Dht.FindProviders which specifies the
-// number of peers to look for. Default is 20
+// number of peers to look for. Default is 20. It still applies as an upper
+// bound when combined with Offline
 func (dhtOpts) NumProviders(numProviders int) DhtFindProvidersOption {
 	return func(settings *DhtFindProvidersSettings) error {
 		settings.NumProviders = numProviders
 		return nil
 	}
 }
+
+// Offline is an option for Dht.FindProviders which, when set, only consults
+// the local provider store without issuing any network queries. The returned
+// channel may be empty and closes quickly. Default is false
+func (dhtOpts) Offline(offline bool) DhtFindProvidersOption {
+	return func(settings *DhtFindProvidersSettings) error {
+		settings.Offline = offline
+		return nil
+	}
+}
+
+// Force is an option for Dht.ProvideMany which bypasses the recently-provided
+// cache, re-announcing every CID. Default is false
+func (dhtOpts) Force(force bool) DhtProvideManyOption {
+	return func(settings *DhtProvideManySettings) error {
+		settings.Force = force
+		return nil
+	}
+}
@@ -1,14 +1,16 @@
 package options
 
 type ApiSettings struct {
-	Offline bool
+	Offline   bool
+	LocalOnly bool
 }
 
 type ApiOption func(*ApiSettings) error
 
 func ApiOptions(opts ...ApiOption) (*ApiSettings, error) {
 	options := &ApiSettings{
-		Offline: false,
+		Offline:   false,
+		LocalOnly: false,
 	}
 
 	return ApiOptionsTo(options, opts...)
@@ -34,3 +36,14 @@ func (apiOpts) Offline(offline bool) ApiOption {
 		return nil
 	}
 }
+
+// LocalOnly configures the api to reject any operation that would reach out
+// to the network while still serving DAG reads from the local blockstore.
+// Unlike Offline it does not swap in datastore-backed routing or name
+// resolution.
+func (apiOpts) LocalOnly(local bool) ApiOption {
+	return func(settings *ApiSettings) error {
+		settings.LocalOnly = local
+		return nil
+	}
+}
@@ -16,8 +16,13 @@ type KeyRenameSettings struct {
 	Force bool
 }
 
+type KeyImportSettings struct {
+	Password string
+}
+
 type KeyGenerateOption func(*KeyGenerateSettings) error
 type KeyRenameOption func(*KeyRenameSettings) error
+type KeyImportOption func(*KeyImportSettings) error
 
 func KeyGenerateOptions(opts ...KeyGenerateOption) (*KeyGenerateSettings, error) {
 	options := &KeyGenerateSettings{
@@ -48,6 +53,18 @@ func KeyRenameOptions(opts ...KeyRenameOption) (*KeyRenameSettings, error) {
 	return options, nil
 }
 
+func KeyImportOptions(opts ...KeyImportOption) (*KeyImportSettings, error) {
+	options := &KeyImportSettings{}
+
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return options, nil
+}
+
 type keyOpts struct{}
 
 var Key keyOpts
@@ -85,3 +102,12 @@ func (keyOpts) Force(force bool) KeyRenameOption {
 		return nil
 	}
 }
+
+// Password is an option for Key.Import which provides the password for
+// decrypting an encrypted PEM key.
+func (keyOpts) Password(password string) KeyImportOption {
+	return func(settings *KeyImportSettings) error {
+		settings.Password = password
+		return nil
+	}
+}
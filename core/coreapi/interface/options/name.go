@@ -14,6 +14,9 @@ type NamePublishSettings struct {
 	ValidTime time.Duration
 	Key       string
 
+	// EOL overrides ValidTime with an absolute expiration time when set
+	EOL *time.Time
+
 	TTL *time.Duration
 
 	AllowOffline bool
@@ -74,6 +77,16 @@ func (nameOpts) ValidTime(validTime time.Duration) NamePublishOption {
 	}
 }
 
+// EOL is an option for Name.Publish which sets an absolute expiration time
+// for the record, taking precedence over ValidTime. Useful for re-signing an
+// existing record without extending its lifetime
+func (nameOpts) EOL(eol time.Time) NamePublishOption {
+	return func(settings *NamePublishSettings) error {
+		settings.EOL = &eol
+		return nil
+	}
+}
+
 // Key is an option for Name.Publish which specifies the key to use for
 // publishing. Default value is "self" which is the node's own PeerID.
 // The key parameter must be either PeerID or keystore key alias.
@@ -113,6 +126,34 @@ func (nameOpts) Cache(cache bool) NameResolveOption {
 	}
 }
 
+// Depth is an option for Name.Resolve which limits the number of recursive
+// resolution steps. Use 1 to resolve a single step only, leaving any
+// intermediate ipns path unresolved
+func (nameOpts) Depth(depth uint) NameResolveOption {
+	return func(settings *NameResolveSettings) error {
+		settings.ResolveOpts = append(settings.ResolveOpts, ropts.Depth(depth))
+		return nil
+	}
+}
+
+// DhtRecordCount is an option for Name.Resolve which specifies how many
+// records to request from the DHT; the best record is selected from this set
+func (nameOpts) DhtRecordCount(count uint) NameResolveOption {
+	return func(settings *NameResolveSettings) error {
+		settings.ResolveOpts = append(settings.ResolveOpts, ropts.DhtRecordCount(count))
+		return nil
+	}
+}
+
+// DhtTimeout is an option for Name.Resolve which sets how long to wait for
+// DHT records to be fetched and verified. Zero means no explicit timeout
+func (nameOpts) DhtTimeout(timeout time.Duration) NameResolveOption {
+	return func(settings *NameResolveSettings) error {
+		settings.ResolveOpts = append(settings.ResolveOpts, ropts.DhtTimeout(timeout))
+		return nil
+	}
+}
+
 //
 func (nameOpts) ResolveOption(opt ropts.ResolveOpt) NameResolveOption {
 	return func(settings *NameResolveSettings) error {
@@ -5,18 +5,25 @@ type ObjectNewSettings struct {
 }
 
 type ObjectPutSettings struct {
-	InputEnc string
-	DataType string
-	Pin      bool
+	InputEnc  string
+	DataType  string
+	Pin       bool
+	PinStatus func(status string)
 }
 
 type ObjectAddLinkSettings struct {
-	Create bool
+	Create    bool
+	SortLinks bool
+}
+
+type ObjectDiffSettings struct {
+	Depth int
 }
 
 type ObjectNewOption func(*ObjectNewSettings) error
 type ObjectPutOption func(*ObjectPutSettings) error
 type ObjectAddLinkOption func(*ObjectAddLinkSettings) error
+type ObjectDiffOption func(*ObjectDiffSettings) error
 
 func ObjectNewOptions(opts ...ObjectNewOption) (*ObjectNewSettings, error) {
 	options := &ObjectNewSettings{
@@ -34,9 +41,10 @@ func ObjectNewOptions(opts ...ObjectNewOption) (*ObjectNewSettings, error) {
 
 func ObjectPutOptions(opts ...ObjectPutOption) (*ObjectPutSettings, error) {
 	options := &ObjectPutSettings{
-		InputEnc: "json",
-		DataType: "text",
-		Pin:      false,
+		InputEnc:  "json",
+		DataType:  "text",
+		Pin:       false,
+		PinStatus: nil,
 	}
 
 	for _, opt := range opts {
@@ -50,7 +58,22 @@ func ObjectPutOptions(opts ...ObjectPutOption) (*ObjectPutSettings, error) {
 
 func ObjectAddLinkOptions(opts ...ObjectAddLinkOption) (*ObjectAddLinkSettings, error) {
 	options := &ObjectAddLinkSettings{
-		Create: false,
+		Create:    false,
+		SortLinks: false,
+	}
+
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return options, nil
+}
+
+func ObjectDiffOptions(opts ...ObjectDiffOption) (*ObjectDiffSettings, error) {
+	options := &ObjectDiffSettings{
+		Depth: -1,
 	}
 
 	for _, opt := range opts {
@@ -114,6 +137,18 @@ func (objectOpts) Pin(pin bool) ObjectPutOption {
 	}
 }
 
+// PinStatus is an option for Object.Put which registers a callback reporting
+// coarse progress of the pin flow: it is called with "pinning" before the
+// root is pinned, "flushing" before the pinset is written and "flushed" once
+// the write completes. Only used when Pin is set. Flushing a large pinset can
+// take a while, so CLI frontends use this to show status to the user
+func (objectOpts) PinStatus(f func(status string)) ObjectPutOption {
+	return func(settings *ObjectPutSettings) error {
+		settings.PinStatus = f
+		return nil
+	}
+}
+
 // Create is an option for Object.AddLink which specifies whether create required
 // directories for the child
 func (objectOpts) Create(create bool) ObjectAddLinkOption {
@@ -122,3 +157,24 @@ func (objectOpts) Create(create bool) ObjectAddLinkOption {
 		return nil
 	}
 }
+
+// SortLinks is an option for Object.AddLink which guarantees the links of
+// the resulting node are ordered by name, regardless of how the underlying
+// dag implementation stores them. The default protobuf dag already keeps
+// links name-sorted, so this is a no-op there. Default is false
+func (objectOpts) SortLinks(sort bool) ObjectAddLinkOption {
+	return func(settings *ObjectAddLinkSettings) error {
+		settings.SortLinks = sort
+		return nil
+	}
+}
+
+// Depth is an option for Object.Diff which limits how deep changed subtrees
+// are expanded. At the cutoff a single change is emitted for the whole
+// subtree. Negative values (the default) mean no limit
+func (objectOpts) Depth(depth int) ObjectDiffOption {
+	return func(settings *ObjectDiffSettings) error {
+		settings.Depth = depth
+		return nil
+	}
+}
@@ -2,6 +2,7 @@ package options
 
 type PinAddSettings struct {
 	Recursive bool
+	Name      string
 }
 
 type PinLsSettings struct {
@@ -102,6 +103,15 @@ func (pinOpts) Recursive(recursive bool) PinAddOption {
 	}
 }
 
+// Name is an option for Pin.Add which attaches a human-readable name to the
+// pin. Default is no name
+func (pinOpts) Name(name string) PinAddOption {
+	return func(settings *PinAddSettings) error {
+		settings.Name = name
+		return nil
+	}
+}
+
 // Type is an option for Pin.Ls which allows to specify which pin types should
 // be returned
 //
@@ -5,11 +5,17 @@ type PubSubPeersSettings struct {
 }
 
 type PubSubSubscribeSettings struct {
-	Discover bool
+	Discover    bool
+	ValidateSig bool
+}
+
+type PubSubPublishSettings struct {
+	Sign bool
 }
 
 type PubSubPeersOption func(*PubSubPeersSettings) error
 type PubSubSubscribeOption func(*PubSubSubscribeSettings) error
+type PubSubPublishOption func(*PubSubPublishSettings) error
 
 func PubSubPeersOptions(opts ...PubSubPeersOption) (*PubSubPeersSettings, error) {
 	options := &PubSubPeersSettings{
@@ -27,7 +33,22 @@ func PubSubPeersOptions(opts ...PubSubPeersOption) (*PubSubPeersSettings, error)
 
 func PubSubSubscribeOptions(opts ...PubSubSubscribeOption) (*PubSubSubscribeSettings, error) {
 	options := &PubSubSubscribeSettings{
-		Discover: false,
+		Discover:    false,
+		ValidateSig: false,
+	}
+
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return options, nil
+}
+
+func PubSubPublishOptions(opts ...PubSubPublishOption) (*PubSubPublishSettings, error) {
+	options := &PubSubPublishSettings{
+		Sign: false,
 	}
 
 	for _, opt := range opts {
@@ -56,3 +77,23 @@ func (pubsubOpts) Discover(discover bool) PubSubSubscribeOption {
 		return nil
 	}
 }
+
+// ValidateSig is an option for PubSub.Subscribe which makes the subscription
+// drop messages that don't carry a valid signature envelope from the sending
+// peer
+func (pubsubOpts) ValidateSig(validate bool) PubSubSubscribeOption {
+	return func(settings *PubSubSubscribeSettings) error {
+		settings.ValidateSig = validate
+		return nil
+	}
+}
+
+// Sign is an option for PubSub.Publish which signs the message with the
+// node's private key and wraps it in a signature envelope subscribers can
+// verify
+func (pubsubOpts) Sign(sign bool) PubSubPublishOption {
+	return func(settings *PubSubPublishSettings) error {
+		settings.Sign = sign
+		return nil
+	}
+}
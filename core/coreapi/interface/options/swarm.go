@@ -0,0 +1,55 @@
+package options
+
+import "time"
+
+type SwarmBandwidthSettings struct {
+	Peer  string
+	Proto string
+	Poll  time.Duration
+}
+
+type SwarmBandwidthOption func(*SwarmBandwidthSettings) error
+
+func SwarmBandwidthOptions(opts ...SwarmBandwidthOption) (*SwarmBandwidthSettings, error) {
+	options := &SwarmBandwidthSettings{}
+
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return options, nil
+}
+
+type swarmOpts struct{}
+
+var Swarm swarmOpts
+
+// Peer is an option for Swarm.Bandwidth which limits the output to the
+// given base58 peer id.
+func (swarmOpts) Peer(p string) SwarmBandwidthOption {
+	return func(settings *SwarmBandwidthSettings) error {
+		settings.Peer = p
+		return nil
+	}
+}
+
+// Proto is an option for Swarm.Bandwidth which reports the traffic on the
+// given protocol id instead of per-peer statistics.
+func (swarmOpts) Proto(proto string) SwarmBandwidthOption {
+	return func(settings *SwarmBandwidthSettings) error {
+		settings.Proto = proto
+		return nil
+	}
+}
+
+// Poll is an option for Swarm.Bandwidth which keeps the returned channel
+// open, emitting a fresh snapshot at the given interval until the context
+// is cancelled. The default is a single snapshot.
+func (swarmOpts) Poll(interval time.Duration) SwarmBandwidthOption {
+	return func(settings *SwarmBandwidthSettings) error {
+		settings.Poll = interval
+		return nil
+	}
+}
@@ -40,9 +40,57 @@ type UnixfsAddSettings struct {
 	Events   chan<- interface{}
 	Silent   bool
 	Progress bool
+
+	DedupStats *AddDedupStats
+
+	CheckpointPath string
+}
+
+// AddDedupStats is filled in during an add performed with the Dedup option:
+// Reused counts blocks that were already present in the blockstore and were
+// not written again, Written counts the blocks actually stored.
+type AddDedupStats struct {
+	Reused  uint64
+	Written uint64
+}
+
+type UnixfsGetSettings struct {
+	Progress func(read, total int64)
+}
+
+type UnixfsLsSettings struct {
+	ResolveChildren bool
 }
 
 type UnixfsAddOption func(*UnixfsAddSettings) error
+type UnixfsGetOption func(*UnixfsGetSettings) error
+type UnixfsLsOption func(*UnixfsLsSettings) error
+
+func UnixfsGetOptions(opts ...UnixfsGetOption) (*UnixfsGetSettings, error) {
+	options := &UnixfsGetSettings{}
+
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return options, nil
+}
+
+func UnixfsLsOptions(opts ...UnixfsLsOption) (*UnixfsLsSettings, error) {
+	options := &UnixfsLsSettings{
+		ResolveChildren: true,
+	}
+
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return options, nil
+}
 
 func UnixfsAddOptions(opts ...UnixfsAddOption) (*UnixfsAddSettings, cid.Prefix, error) {
 	options := &UnixfsAddSettings{
@@ -69,6 +117,10 @@ func UnixfsAddOptions(opts ...UnixfsAddOption) (*UnixfsAddSettings, cid.Prefix,
 		Events:   nil,
 		Silent:   false,
 		Progress: false,
+
+		DedupStats: nil,
+
+		CheckpointPath: "",
 	}
 
 	for _, opt := range opts {
@@ -247,6 +299,10 @@ func (unixfsOpts) StdinName(name string) UnixfsAddOption {
 // Events specifies channel which will be used to report events about ongoing
 // Add operation.
 //
+// The API closes the channel when the add finishes or fails, so consumers
+// can simply range over it. Sends honor the add's context, a consumer that
+// stops reading only stalls the add until that context is cancelled.
+//
 // Note that if this channel blocks it may slowdown the adder
 func (unixfsOpts) Events(sink chan<- interface{}) UnixfsAddOption {
 	return func(settings *UnixfsAddSettings) error {
@@ -255,6 +311,29 @@ func (unixfsOpts) Events(sink chan<- interface{}) UnixfsAddOption {
 	}
 }
 
+// Dedup makes the adder check the blockstore for every computed block and
+// skip the write (and the provide it would trigger) when the block is
+// already present, recording reuse counts in the given stats. Re-adding
+// mostly unchanged content still hashes every byte, but touches the
+// datastore only for the blocks that actually changed.
+func (unixfsOpts) Dedup(stats *AddDedupStats) UnixfsAddOption {
+	return func(settings *UnixfsAddSettings) error {
+		settings.DedupStats = stats
+		return nil
+	}
+}
+
+// CheckpointPath is an option for Unixfs.ResumableAdd which sets the file
+// the import checkpoint is written to on every chunk boundary. When the
+// file already exists the add resumes from the state recorded in it instead
+// of starting over. Required for ResumableAdd, ignored by Add.
+func (unixfsOpts) CheckpointPath(path string) UnixfsAddOption {
+	return func(settings *UnixfsAddSettings) error {
+		settings.CheckpointPath = path
+		return nil
+	}
+}
+
 // Silent reduces event output
 func (unixfsOpts) Silent(silent bool) UnixfsAddOption {
 	return func(settings *UnixfsAddSettings) error {
@@ -263,6 +342,27 @@ func (unixfsOpts) Silent(silent bool) UnixfsAddOption {
 	}
 }
 
+// ReadProgress is an option for Unixfs.Get which invokes the callback as
+// the returned file is read, with the number of bytes read so far and the
+// total file size (-1 when unknown). It only applies when the path names a
+// file; directories are returned unchanged.
+func (unixfsOpts) ReadProgress(cb func(read, total int64)) UnixfsGetOption {
+	return func(settings *UnixfsGetSettings) error {
+		settings.Progress = cb
+		return nil
+	}
+}
+
+// ResolveChildren tells LsEntries whether to fetch each child node to
+// resolve its unixfs type and file size. When disabled entries carry only
+// the name, cid and raw dag size of the link. Default is true.
+func (unixfsOpts) ResolveChildren(resolve bool) UnixfsLsOption {
+	return func(settings *UnixfsLsSettings) error {
+		settings.ResolveChildren = resolve
+		return nil
+	}
+}
+
 // Progress tells the adder whether to enable progress events
 func (unixfsOpts) Progress(enable bool) UnixfsAddOption {
 	return func(settings *UnixfsAddSettings) error {
@@ -2,8 +2,11 @@ package iface
 
 import (
 	"context"
+	"io"
 
 	options "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
+
+	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
 )
 
 // Pin holds information about pinned resource
@@ -13,10 +16,21 @@ type Pin interface {
 
 	// Type of the pin
 	Type() string
+
+	// Name is the optional human-readable name attached to the pin, or the
+	// empty string if none was set
+	Name() string
+
+	// Err is non-nil when enumerating the pin set failed; the other methods
+	// return zero values in that case
+	Err() error
 }
 
 // PinStatus holds information about pin health
 type PinStatus interface {
+	// Cid is the CID of the (recursively) pinned root the status refers to
+	Cid() cid.Cid
+
 	// Ok indicates whether the pin has been verified to be correct
 	Ok() bool
 
@@ -24,6 +38,24 @@ type PinStatus interface {
 	BadNodes() []BadPinNode
 }
 
+// PinStats holds aggregate statistics about the local pin set
+type PinStats struct {
+	// DirectCount is the number of directly pinned objects
+	DirectCount int
+
+	// RecursiveCount is the number of recursively pinned roots
+	RecursiveCount int
+
+	// IndirectCount is the number of objects pinned through recursive pins
+	IndirectCount int
+
+	// TotalCount is the sum of the above counts
+	TotalCount int
+
+	// TotalSize is the cumulative size of all recursively pinned trees
+	TotalSize uint64
+}
+
 // BadPinNode is a node that has been marked as bad by Pin.Verify
 type BadPinNode interface {
 	// Path is the path of the node
@@ -42,6 +74,11 @@ type PinAPI interface {
 	// Ls returns list of pinned objects on this node
 	Ls(context.Context, ...options.PinLsOption) ([]Pin, error)
 
+	// LsStream streams the pinned objects on this node over a channel,
+	// never holding the whole pin set in memory. A pin with a non-nil Err
+	// aborts the stream
+	LsStream(context.Context, ...options.PinLsOption) (<-chan Pin, error)
+
 	// Rm removes pin for object specified by the path
 	Rm(context.Context, Path) error
 
@@ -51,4 +88,15 @@ type PinAPI interface {
 
 	// Verify verifies the integrity of pinned objects
 	Verify(context.Context) (<-chan PinStatus, error)
+
+	// Stats returns aggregate statistics about the local pin set
+	Stats(context.Context) (PinStats, error)
+
+	// Export writes the direct and recursive pin set to w as a
+	// newline-delimited JSON stream of {Cid, Type} entries
+	Export(ctx context.Context, w io.Writer) error
+
+	// Import reads a stream in the format written by Export, adds each
+	// entry to the pin set and returns the number of imported pins
+	Import(ctx context.Context, r io.Reader) (int, error)
 }
@@ -41,7 +41,7 @@ type PubSubAPI interface {
 	Peers(context.Context, ...options.PubSubPeersOption) ([]peer.ID, error)
 
 	// Publish a message to a given pubsub topic
-	Publish(context.Context, string, []byte) error
+	Publish(context.Context, string, []byte, ...options.PubSubPublishOption) error
 
 	// Subscribe to messages on a given topic
 	Subscribe(context.Context, string, ...options.PubSubSubscribeOption) (PubSubSubscription, error)
@@ -0,0 +1,66 @@
+package iface
+
+import (
+	"context"
+	"time"
+)
+
+// BitswapStat is a snapshot of the data-transfer counters kept by the
+// bitswap exchange.
+type BitswapStat struct {
+	BlocksSent      uint64
+	DataSent        uint64
+	BlocksReceived  uint64
+	DataReceived    uint64
+	DupBlksReceived uint64
+	DupDataReceived uint64
+}
+
+// BitswapStatSnapshot is one entry of the periodic bitswap counter history.
+type BitswapStatSnapshot struct {
+	Timestamp      time.Time
+	BlocksSent     uint64
+	DataSent       uint64
+	BlocksReceived uint64
+	DataReceived   uint64
+	WantlistSize   int
+	Peers          int
+}
+
+// NodeStats is a dashboard-friendly snapshot of node-wide statistics:
+// identity, connectivity, repo usage, exchange counters and bandwidth
+// totals in a single structure.
+type NodeStats struct {
+	PeerID       string
+	AgentVersion string
+	Addresses    []string
+	NumPeers     int
+
+	RepoSize       uint64
+	RepoNumObjects int
+
+	BitswapStats BitswapStat
+
+	BandwidthIn  uint64
+	BandwidthOut uint64
+}
+
+// StatsAPI exposes transfer statistics of the node
+type StatsAPI interface {
+	// Bitswap returns a snapshot of the bitswap exchange counters
+	Bitswap(context.Context) (BitswapStat, error)
+
+	// Node returns a node-wide snapshot in a single call. Fields backed by
+	// subsystems that are unavailable, e.g. bitswap or the bandwidth
+	// reporter on an offline node, are left at their zero values
+	Node(context.Context) (NodeStats, error)
+
+	// BitswapHistory returns the periodic bitswap counter snapshots
+	// covering the last span, oldest first. A non-positive span returns
+	// the whole history buffer
+	BitswapHistory(ctx context.Context, span time.Duration) ([]BitswapStatSnapshot, error)
+
+	// ResetBitswap zeroes the bitswap counters so a bounded phase of work,
+	// e.g. a benchmark run, can be measured in isolation
+	ResetBitswap(context.Context) error
+}
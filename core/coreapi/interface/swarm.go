@@ -5,6 +5,8 @@ import (
 	"errors"
 	"time"
 
+	options "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
+
 	net "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-net"
 	ma "github.com/ipsn/go-ipfs/gxlibs/github.com/multiformats/go-multiaddr"
 	"github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peer"
@@ -35,6 +37,19 @@ type ConnectionInfo interface {
 	Streams() ([]protocol.ID, error)
 }
 
+// SwarmBandwidthStat holds the traffic exchanged with a single peer. When
+// filtering by protocol the Peer field is empty and the numbers cover all
+// peers speaking that protocol.
+type SwarmBandwidthStat struct {
+	Peer peer.ID
+
+	In  uint64
+	Out uint64
+
+	RateIn  float64
+	RateOut float64
+}
+
 // SwarmAPI specifies the interface to libp2p swarm
 type SwarmAPI interface {
 	// Connect to a given peer
@@ -54,4 +69,18 @@ type SwarmAPI interface {
 
 	// ListenAddrs returns the list of all listening addresses
 	ListenAddrs(context.Context) ([]ma.Multiaddr, error)
+
+	// Bandwidth returns per-peer traffic statistics. With the Poll option a
+	// fresh snapshot is emitted at the given interval until the context is
+	// cancelled
+	Bandwidth(ctx context.Context, opts ...options.SwarmBandwidthOption) (<-chan SwarmBandwidthStat, error)
+
+	// Protect marks the peer as important under the given tag, keeping its
+	// connections from being closed when the connection manager is over its
+	// limit
+	Protect(ctx context.Context, p peer.ID, tag string) error
+
+	// Unprotect removes the protection tag from the peer, returning whether
+	// the peer is still protected under other tags
+	Unprotect(ctx context.Context, p peer.ID, tag string) (bool, error)
 }
@@ -2,9 +2,12 @@ package iface
 
 import (
 	"context"
+	"io"
+	"os"
 
 	"github.com/ipsn/go-ipfs/core/coreapi/interface/options"
 
+	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
 	files "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-files"
 	ipld "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipld-format"
 )
@@ -15,6 +18,41 @@ type AddEvent struct {
 	Hash  string `json:",omitempty"`
 	Bytes int64  `json:",omitempty"`
 	Size  string `json:",omitempty"`
+
+	// Dedup carries the block reuse counters of a finished add run with
+	// the Dedup option, set only on the final event
+	Dedup *options.AddDedupStats `json:",omitempty"`
+
+	// Err terminates the event stream of a failed ResumableAdd. Add
+	// reports its errors out of band and never sets it
+	Err error `json:"-"`
+}
+
+// FileType is the unixfs type of a node or directory entry.
+type FileType int
+
+const (
+	// TUnknown means the node's type was not resolved
+	TUnknown FileType = iota
+	TFile
+	TDirectory
+	TSymlink
+)
+
+// LsEntry is a single directory entry streamed by UnixfsAPI.LsEntries.
+type LsEntry struct {
+	Err error
+
+	Name string
+	Cid  cid.Cid
+
+	// Type is the entry's unixfs type, TUnknown unless child resolving
+	// was enabled and the child is a unixfs node
+	Type FileType
+
+	// Size is the unixfs file size for files and symlinks. When child
+	// resolving is disabled it falls back to the link's raw dag size
+	Size uint64
 }
 
 // UnixfsAPI is the basic interface to immutable files in IPFS
@@ -25,12 +63,55 @@ type UnixfsAPI interface {
 	// TODO: a long useful comment on how to use this for many different scenarios
 	Add(context.Context, files.Node, ...options.UnixfsAddOption) (ResolvedPath, error)
 
+	// AddToDirectory imports data from the reader and links it into the given
+	// directory under name, returning the new directory root. The imported
+	// file inherits the directory's cid prefix unless overridden with options
+	AddToDirectory(ctx context.Context, dir Path, name string, data io.Reader, opts ...options.UnixfsAddOption) (ResolvedPath, error)
+
+	// ResumableAdd imports a single file from the reader like Add, writing a
+	// checkpoint file (options.Unixfs.CheckpointPath, required) on every
+	// chunk boundary. When the checkpoint file already exists the import
+	// resumes instead of starting over: the chunks it records are replayed
+	// from the local blockstore and the reader is seeked past the bytes they
+	// cover. The final event on the returned channel carries the root hash,
+	// or a non-nil Err when the import failed; the channel is closed either
+	// way
+	ResumableAdd(ctx context.Context, r io.ReadSeeker, opts ...options.UnixfsAddOption) (<-chan AddEvent, error)
+
 	// Get returns a read-only handle to a file tree referenced by a path
 	//
 	// Note that some implementations of this API may apply the specified context
 	// to operations performed on the returned file
-	Get(context.Context, Path) (files.Node, error)
+	Get(context.Context, Path, ...options.UnixfsGetOption) (files.Node, error)
+
+	// GetFile is like Get but fails with ErrNotFile when the path does not
+	// reference a regular file
+	GetFile(context.Context, Path, ...options.UnixfsGetOption) (files.File, error)
+
+	// GetRange returns a reader over length bytes of the referenced file
+	// starting at offset, fetching only the blocks covering that span. A
+	// negative length reads to the end of the file; an offset past the end
+	// of the file is an error
+	GetRange(ctx context.Context, p Path, offset, length int64, opts ...options.UnixfsGetOption) (files.File, error)
+
+	// GetDirectory is like Get but fails with ErrNotDirectory when the
+	// path does not reference a directory
+	GetDirectory(context.Context, Path, ...options.UnixfsGetOption) (files.Directory, error)
+
+	// GetType returns the unixfs type of the node the path references
+	// without fetching its content
+	GetType(context.Context, Path) (FileType, error)
 
 	// Ls returns the list of links in a directory
 	Ls(context.Context, Path) ([]*ipld.Link, error)
+
+	// LsEntries streams the entries of a directory with unixfs type and
+	// file size resolved from each child node, so large (HAMT)
+	// directories don't have to be buffered. Child resolving can be
+	// turned off with options.Unixfs.ResolveChildren(false)
+	LsEntries(context.Context, Path, ...options.UnixfsLsOption) (<-chan LsEntry, error)
+
+	// Chmod stores the given unix permission bits in the unixfs metadata
+	// of the referenced node, returning the path of the rebuilt node
+	Chmod(ctx context.Context, p Path, mode os.FileMode) (ResolvedPath, error)
 }
@@ -3,8 +3,11 @@ package coreapi
 import (
 	"context"
 	"crypto/rand"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"sort"
 
 	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
@@ -12,6 +15,7 @@ import (
 
 	crypto "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-crypto"
 	peer "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peer"
+	routing "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-routing"
 	ipfspath "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-path"
 )
 
@@ -190,6 +194,144 @@ func (api *KeyAPI) Rename(ctx context.Context, oldName string, newName string, o
 	return &key{newName, pid}, overwrite, ks.Delete(oldName)
 }
 
+// Import reads a PEM-encoded PKCS8 private key from r, stores it in the
+// keystore under `name` and returns the derived peer identity. Encrypted
+// keys are decrypted with the password given through the options.
+func (api *KeyAPI) Import(ctx context.Context, name string, r io.Reader, opts ...caopts.KeyImportOption) (coreiface.Key, error) {
+	options, err := caopts.KeyImportOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "self" {
+		return nil, fmt.Errorf("cannot import key with name 'self'")
+	}
+
+	_, err = api.repo.Keystore().Get(name)
+	if err == nil {
+		return nil, fmt.Errorf("key with name '%s' already exists", name)
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in the key data")
+	}
+
+	der := block.Bytes
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		if options.Password == "" {
+			return nil, fmt.Errorf("key is encrypted, a password is required")
+		}
+		der, err = decryptPKCS8(der, []byte(options.Password))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sk, err := parsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	err = api.repo.Keystore().Put(name, sk)
+	if err != nil {
+		return nil, err
+	}
+
+	pid, err := peer.IDFromPrivateKey(sk)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key{name, pid}, nil
+}
+
+// Sign signs the data read from r with the private key named `name` and
+// returns the raw signature bytes. The name 'self' signs with the node key.
+func (api *KeyAPI) Sign(ctx context.Context, name string, data io.Reader) ([]byte, error) {
+	var sk crypto.PrivKey
+	if name == "self" {
+		if api.privateKey == nil {
+			return nil, errors.New("no private key available for 'self'")
+		}
+		sk = api.privateKey
+	} else {
+		var err error
+		sk, err = api.repo.Keystore().Get(name)
+		if err != nil {
+			return nil, fmt.Errorf("no key named %s was found", name)
+		}
+	}
+
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return sk.Sign(b)
+}
+
+// Verify checks sig over the data read from r using the public key of the
+// given peer. The key is taken from the peerstore when known, extracted from
+// the peer id when possible, found in the local keystore for keys we own,
+// and looked up through routing otherwise.
+func (api *KeyAPI) Verify(ctx context.Context, pid peer.ID, sig []byte, data io.Reader) (bool, error) {
+	var pk crypto.PubKey
+	if api.peerstore != nil {
+		pk = api.peerstore.PubKey(pid)
+	}
+	if pk == nil {
+		// identity-hashed ids carry their public key inline
+		if extracted, err := pid.ExtractPublicKey(); err == nil {
+			pk = extracted
+		}
+	}
+	if pk == nil {
+		// a key we own verifies locally, look for it in the keystore
+		if names, err := api.repo.Keystore().List(); err == nil {
+			for _, name := range names {
+				sk, err := api.repo.Keystore().Get(name)
+				if err != nil {
+					continue
+				}
+				if id, err := peer.IDFromPrivateKey(sk); err == nil && id == pid {
+					pk = sk.GetPublic()
+					break
+				}
+			}
+		}
+	}
+	if pk == nil {
+		if api.routing == nil {
+			return false, coreiface.ErrOffline
+		}
+
+		var err error
+		pk, err = routing.GetPublicKey(api.routing, ctx, pid)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return false, err
+	}
+
+	// key implementations disagree on whether a mismatched signature is
+	// an error (rsa) or just false (ed25519); report both as a mismatch
+	ok, err := pk.Verify(b, sig)
+	if err != nil {
+		return false, nil
+	}
+	return ok, nil
+}
+
 // Remove removes keys from keystore. Returns ipns path of the removed key.
 func (api *KeyAPI) Remove(ctx context.Context, name string) (coreiface.Key, error) {
 	ks := api.repo.Keystore()
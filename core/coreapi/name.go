@@ -65,6 +65,9 @@ func (api *NameAPI) Publish(ctx context.Context, p coreiface.Path, opts ...caopt
 	}
 
 	eol := time.Now().Add(options.ValidTime)
+	if options.EOL != nil {
+		eol = *options.EOL
+	}
 	err = api.namesys.PublishWithEOL(ctx, k, pth, eol)
 	if err != nil {
 		return nil, err
@@ -109,7 +112,7 @@ func (api *NameAPI) Search(ctx context.Context, name string, opts ...caopts.Name
 			p, _ := coreiface.ParsePath(res.Path.String())
 
 			select {
-			case out <- coreiface.IpnsResult{Path: p, Err: res.Err}:
+			case out <- coreiface.IpnsResult{Path: p, TTL: res.TTL, Source: string(res.Source), Err: res.Err}:
 			case <-ctx.Done():
 				return
 			}
@@ -254,4 +254,60 @@ func TestBasicPublishResolveTimeout(t *testing.T) {
 	}
 }
 
+func TestPublishOffline(t *testing.T) {
+	ctx := context.Background()
+	nds, apis, err := makeAPISwarm(ctx, true, 2)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	n := nds[0]
+	api := apis[0]
+
+	p, err := addTestObject(ctx, api)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	off, err := api.WithOptions(opt.Api.Offline(true))
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	e, err := off.Name().Publish(ctx, p,
+		opt.Name.AllowOffline(true),
+		opt.Name.EOL(time.Now().Add(time.Hour)),
+	)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if e.Name() != n.Identity.Pretty() {
+		t.Errorf("expected e.Name to equal '%s', got '%s'", n.Identity.Pretty(), e.Name())
+	}
+
+	// the record only exists in the local datastore so far; once the
+	// node's online namesys consults it the name resolves. The dht fills
+	// its routing table asynchronously after the swarm connects, so retry
+	// until the lookup can be routed.
+	var res coreiface.Path
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		res, err = api.Name().Resolve(ctx, e.Name())
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if res.String() != p.String() {
+		t.Errorf("expected paths to match, '%s'!='%s'", res.String(), p.String())
+	}
+}
+
 //TODO: When swarm api is created, add multinode tests
@@ -10,6 +10,8 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"sort"
+	"strings"
 
 	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
 	caopts "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
@@ -18,6 +20,7 @@ import (
 
 	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
 	ft "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs"
+	hamt "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs/hamt"
 	ipld "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipld-format"
 	dag "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-merkledag"
 )
@@ -127,11 +130,20 @@ func (api *ObjectAPI) Put(ctx context.Context, src io.Reader, opts ...caopts.Obj
 	}
 
 	if options.Pin {
+		if options.PinStatus != nil {
+			options.PinStatus("pinning")
+		}
 		api.pinning.PinWithMode(dagnode.Cid(), pin.Recursive)
+		if options.PinStatus != nil {
+			options.PinStatus("flushing")
+		}
 		err = api.pinning.Flush()
 		if err != nil {
 			return nil, err
 		}
+		if options.PinStatus != nil {
+			options.PinStatus("flushed")
+		}
 	}
 
 	return coreiface.IpfsPath(dagnode.Cid()), nil
@@ -141,32 +153,168 @@ func (api *ObjectAPI) Get(ctx context.Context, path coreiface.Path) (ipld.Node,
 	return api.core().ResolveNode(ctx, path)
 }
 
-func (api *ObjectAPI) Data(ctx context.Context, path coreiface.Path) (io.Reader, error) {
+// GetWithSchema resolves the node at path and validates its fields against
+// the given inline schema, returning the decoded representation. The schema
+// is a JSON object mapping field names to one of the type names "string",
+// "int", "float", "bool", "list", "map", "link" or "any"; a field name
+// ending in '?' is optional. Fields not named in the schema are rejected.
+// The node is decoded through its codec's JSON form, so this composes with
+// dag-cbor and dag-json objects put through the Dag API.
+func (api *ObjectAPI) GetWithSchema(ctx context.Context, path coreiface.Path, schema string) (map[string]interface{}, error) {
+	var fields map[string]string
+	if err := json.Unmarshal([]byte(schema), &fields); err != nil {
+		return nil, fmt.Errorf("invalid schema: %s", err)
+	}
+
 	nd, err := api.core().ResolveNode(ctx, path)
 	if err != nil {
 		return nil, err
 	}
 
-	pbnd, ok := nd.(*dag.ProtoNode)
+	m, ok := nd.(json.Marshaler)
 	if !ok {
-		return nil, dag.ErrNotProtobuf
+		return nil, fmt.Errorf("%s node has no JSON form", cid.CodecToStr[nd.Cid().Type()])
+	}
+	data, err := m.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("schema: node is not a map: %s", err)
+	}
+
+	seen := make(map[string]struct{}, len(fields))
+	for name, typ := range fields {
+		optional := strings.HasSuffix(name, "?")
+		name = strings.TrimSuffix(name, "?")
+		seen[name] = struct{}{}
+
+		val, ok := obj[name]
+		if !ok {
+			if optional {
+				continue
+			}
+			return nil, fmt.Errorf("schema: missing required field %q", name)
+		}
+
+		if err := checkSchemaType(name, typ, val); err != nil {
+			return nil, err
+		}
+	}
+
+	for name := range obj {
+		if _, ok := seen[name]; !ok {
+			return nil, fmt.Errorf("schema: unexpected field %q", name)
+		}
+	}
+
+	return obj, nil
+}
+
+// checkSchemaType validates a single decoded value against a schema type
+// name. JSON decoding turns all numbers into float64, so "int" additionally
+// requires an integral value.
+func checkSchemaType(name, typ string, val interface{}) error {
+	ok := false
+	switch typ {
+	case "any":
+		ok = true
+	case "string":
+		_, ok = val.(string)
+	case "bool":
+		_, ok = val.(bool)
+	case "float":
+		_, ok = val.(float64)
+	case "int":
+		var f float64
+		if f, ok = val.(float64); ok {
+			ok = f == float64(int64(f))
+		}
+	case "list":
+		_, ok = val.([]interface{})
+	case "map":
+		_, ok = val.(map[string]interface{})
+	case "link":
+		var m map[string]interface{}
+		if m, ok = val.(map[string]interface{}); ok {
+			_, ok = m["/"]
+			ok = ok && len(m) == 1
+		}
+	default:
+		return fmt.Errorf("invalid schema: unknown type %q for field %q", typ, name)
 	}
 
-	return bytes.NewReader(pbnd.Data()), nil
+	if !ok {
+		return fmt.Errorf("schema: field %q is not of type %s", name, typ)
+	}
+	return nil
 }
 
-func (api *ObjectAPI) Links(ctx context.Context, path coreiface.Path) ([]*ipld.Link, error) {
+func (api *ObjectAPI) Data(ctx context.Context, path coreiface.Path) (io.Reader, error) {
 	nd, err := api.core().ResolveNode(ctx, path)
 	if err != nil {
 		return nil, err
 	}
 
-	links := nd.Links()
-	out := make([]*ipld.Link, len(links))
-	for n, l := range links {
-		out[n] = (*ipld.Link)(l)
+	switch nd := nd.(type) {
+	case *dag.ProtoNode:
+		return bytes.NewReader(nd.Data()), nil
+	case *dag.RawNode:
+		return bytes.NewReader(nd.RawData()), nil
+	default:
+		codec, ok := cid.CodecToStr[nd.Cid().Type()]
+		if !ok {
+			codec = fmt.Sprintf("%d", nd.Cid().Type())
+		}
+		return nil, fmt.Errorf("cannot read data from %s node", codec)
+	}
+}
+
+// Links streams the links of the node at the path. HAMT-sharded directories
+// are walked through the shard one child node at a time, so their full link
+// list is never held in memory.
+func (api *ObjectAPI) Links(ctx context.Context, path coreiface.Path) (<-chan *ipld.Link, error) {
+	nd, err := api.core().ResolveNode(ctx, path)
+	if err != nil {
+		return nil, err
 	}
 
+	out := make(chan *ipld.Link)
+	go func() {
+		defer close(out)
+
+		emit := func(l *ipld.Link) error {
+			select {
+			case out <- l:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if pbnd, ok := nd.(*dag.ProtoNode); ok {
+			if fsn, err := ft.FSNodeFromBytes(pbnd.Data()); err == nil && fsn.Type() == ft.THAMTShard {
+				shard, err := hamt.NewHamtFromDag(api.dag, nd)
+				if err != nil {
+					log.Errorf("cannot load hamt shard %s: %s", nd.Cid(), err)
+					return
+				}
+				if err := shard.ForEachLink(ctx, emit); err != nil && err != ctx.Err() {
+					log.Errorf("cannot enumerate hamt shard %s: %s", nd.Cid(), err)
+				}
+				return
+			}
+		}
+
+		for _, l := range nd.Links() {
+			if emit(l) != nil {
+				return
+			}
+		}
+	}()
+
 	return out, nil
 }
 
@@ -231,6 +379,100 @@ func (api *ObjectAPI) AddLink(ctx context.Context, base coreiface.Path, name str
 		return nil, err
 	}
 
+	if options.SortLinks {
+		// rebuild the root with its links ordered by name so the cid no
+		// longer depends on insertion order
+		sorted := nnode.Copy().(*dag.ProtoNode)
+		links := sorted.Links()
+		sort.Slice(links, func(i, j int) bool { return links[i].Name < links[j].Name })
+		sorted.SetLinks(links)
+
+		if err := api.dag.Add(ctx, sorted); err != nil {
+			return nil, err
+		}
+		nnode = sorted
+	}
+
+	return coreiface.IpfsPath(nnode.Cid()), nil
+}
+
+// CreateDirectory builds a unixfs directory containing the given entries in
+// a single editor pass, which is much cheaper than creating an empty
+// directory and adding links one AddLink call at a time.
+func (api *ObjectAPI) CreateDirectory(ctx context.Context, entries map[string]coreiface.Path) (coreiface.ResolvedPath, error) {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		if name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid entry name %q", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	e := dagutils.NewDagEditor(ft.EmptyDirNode(), api.dag)
+
+	for _, name := range names {
+		childNd, err := api.core().ResolveNode(ctx, entries[name])
+		if err != nil {
+			return nil, err
+		}
+
+		err = e.InsertNodeAtPath(ctx, name, childNd, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	nnode, err := e.Finalize(ctx, api.dag)
+	if err != nil {
+		return nil, err
+	}
+
+	return coreiface.IpfsPath(nnode.Cid()), nil
+}
+
+func (api *ObjectAPI) AppendLinks(ctx context.Context, base coreiface.Path, links map[string]coreiface.Path) (coreiface.ResolvedPath, error) {
+	baseNd, err := api.core().ResolveNode(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+
+	basePb, ok := baseNd.(*dag.ProtoNode)
+	if !ok {
+		return nil, dag.ErrNotProtobuf
+	}
+
+	names := make([]string, 0, len(links))
+	for name := range links {
+		if name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid link name %q", name)
+		}
+		if _, err := basePb.GetNodeLink(name); err == nil {
+			return nil, fmt.Errorf("link %q already exists", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	e := dagutils.NewDagEditor(basePb, api.dag)
+
+	for _, name := range names {
+		childNd, err := api.core().ResolveNode(ctx, links[name])
+		if err != nil {
+			return nil, err
+		}
+
+		err = e.InsertNodeAtPath(ctx, name, childNd, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	nnode, err := e.Finalize(ctx, api.dag)
+	if err != nil {
+		return nil, err
+	}
+
 	return coreiface.IpfsPath(nnode.Cid()), nil
 }
 
@@ -268,6 +510,57 @@ func (api *ObjectAPI) SetData(ctx context.Context, path coreiface.Path, r io.Rea
 	return api.patchData(ctx, path, r, false)
 }
 
+// SpliceData appends a slice of the source node's data segment to the base
+// node's data. The bytes are read server-side, so assembling an object from
+// pieces already in the dag doesn't round-trip them through the client.
+func (api *ObjectAPI) SpliceData(ctx context.Context, base coreiface.Path, source coreiface.Path, offset, length int64) (coreiface.ResolvedPath, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("cannot have negative source offset")
+	}
+
+	srcNd, err := api.core().ResolveNode(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	srcPb, ok := srcNd.(*dag.ProtoNode)
+	if !ok {
+		return nil, dag.ErrNotProtobuf
+	}
+
+	srcData := srcPb.Data()
+	if offset > int64(len(srcData)) {
+		return nil, fmt.Errorf("source offset %d is past the end of the %d byte data segment", offset, len(srcData))
+	}
+
+	slice := srcData[offset:]
+	if length >= 0 && length < int64(len(slice)) {
+		slice = slice[:length]
+	}
+
+	baseNd, err := api.core().ResolveNode(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+
+	basePb, ok := baseNd.(*dag.ProtoNode)
+	if !ok {
+		return nil, dag.ErrNotProtobuf
+	}
+
+	if total := len(basePb.Data()) + len(slice); total > inputLimit {
+		return nil, fmt.Errorf("spliced data segment would be %d bytes, exceeding the %d byte limit", total, inputLimit)
+	}
+
+	basePb.SetData(append(basePb.Data(), slice...))
+
+	if err := api.dag.Add(ctx, basePb); err != nil {
+		return nil, err
+	}
+
+	return coreiface.IpfsPath(basePb.Cid()), nil
+}
+
 func (api *ObjectAPI) patchData(ctx context.Context, path coreiface.Path, r io.Reader, appendData bool) (coreiface.ResolvedPath, error) {
 	nd, err := api.core().ResolveNode(ctx, path)
 	if err != nil {
@@ -297,7 +590,12 @@ func (api *ObjectAPI) patchData(ctx context.Context, path coreiface.Path, r io.R
 	return coreiface.IpfsPath(pbnd.Cid()), nil
 }
 
-func (api *ObjectAPI) Diff(ctx context.Context, before coreiface.Path, after coreiface.Path) ([]coreiface.ObjectChange, error) {
+func (api *ObjectAPI) Diff(ctx context.Context, before coreiface.Path, after coreiface.Path, opts ...caopts.ObjectDiffOption) ([]coreiface.ObjectChange, error) {
+	settings, err := caopts.ObjectDiffOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
 	beforeNd, err := api.core().ResolveNode(ctx, before)
 	if err != nil {
 		return nil, err
@@ -308,7 +606,7 @@ func (api *ObjectAPI) Diff(ctx context.Context, before coreiface.Path, after cor
 		return nil, err
 	}
 
-	changes, err := dagutils.Diff(ctx, api.dag, beforeNd, afterNd)
+	changes, err := dagutils.DiffDepth(ctx, api.dag, beforeNd, afterNd, settings.Depth)
 	if err != nil {
 		return nil, err
 	}
@@ -10,8 +10,24 @@ import (
 
 	"github.com/ipsn/go-ipfs/core/coreapi/interface"
 	opt "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
+
+	ipld "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipld-format"
 )
 
+// allLinks drains the Links stream into a slice for easy assertions.
+func allLinks(ctx context.Context, api iface.CoreAPI, p iface.Path) ([]*ipld.Link, error) {
+	ch, err := api.Object().Links(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*ipld.Link
+	for l := range ch {
+		out = append(out, l)
+	}
+	return out, nil
+}
+
 func TestNew(t *testing.T) {
 	ctx := context.Background()
 	_, api, err := makeAPI(ctx)
@@ -100,6 +116,61 @@ func TestObjectGet(t *testing.T) {
 	}
 }
 
+func TestObjectGetWithSchema(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	linked, err := api.Dag().Put(ctx, strings.NewReader(`{"a":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := api.Dag().Put(ctx, strings.NewReader(
+		`{"name":"foo","size":3,"tags":["a","b"],"owner":{"/":"`+linked.Cid().String()+`"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a conforming object decodes, optional fields may be absent
+	obj, err := api.Object().GetWithSchema(ctx, p,
+		`{"name":"string","size":"int","tags":"list","owner":"link","note?":"string"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj["name"] != "foo" {
+		t.Errorf("unexpected name: %v", obj["name"])
+	}
+
+	// a field of the wrong type is a validation error
+	_, err = api.Object().GetWithSchema(ctx, p,
+		`{"name":"int","size":"int","tags":"list","owner":"link"}`)
+	if err == nil || !strings.Contains(err.Error(), "is not of type int") {
+		t.Errorf("expected a type validation error, got: %s", err)
+	}
+
+	// a missing required field is a validation error
+	_, err = api.Object().GetWithSchema(ctx, p,
+		`{"name":"string","size":"int","tags":"list","owner":"link","checksum":"string"}`)
+	if err == nil || !strings.Contains(err.Error(), "missing required field") {
+		t.Errorf("expected a missing field error, got: %s", err)
+	}
+
+	// fields not named in the schema are rejected
+	_, err = api.Object().GetWithSchema(ctx, p, `{"name":"string"}`)
+	if err == nil || !strings.Contains(err.Error(), "unexpected field") {
+		t.Errorf("expected an unexpected field error, got: %s", err)
+	}
+
+	// a malformed schema is rejected up front
+	_, err = api.Object().GetWithSchema(ctx, p, `not a schema`)
+	if err == nil || !strings.Contains(err.Error(), "invalid schema") {
+		t.Errorf("expected an invalid schema error, got: %s", err)
+	}
+}
+
 func TestObjectData(t *testing.T) {
 	ctx := context.Background()
 	_, api, err := makeAPI(ctx)
@@ -144,7 +215,7 @@ func TestObjectLinks(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	links, err := api.Object().Links(ctx, p2)
+	links, err := allLinks(ctx, api, p2)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -231,7 +302,7 @@ func TestObjectAddLink(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	links, err := api.Object().Links(ctx, p3)
+	links, err := allLinks(ctx, api, p3)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -249,6 +320,65 @@ func TestObjectAddLink(t *testing.T) {
 	}
 }
 
+func TestObjectAddLinkSorted(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"dir"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c1, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"foo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c2, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"bar"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// link "a" always points at c1 and "b" at c2, only the insertion
+	// order differs between the two builds
+	type entry struct {
+		name  string
+		child iface.ResolvedPath
+	}
+	build := func(entries []entry, opts ...opt.ObjectAddLinkOption) iface.ResolvedPath {
+		p := base
+		for _, e := range entries {
+			var err error
+			p, err = api.Object().AddLink(ctx, p, e.name, e.child, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		return p
+	}
+
+	ab := []entry{{"a", c1}, {"b", c2}}
+	ba := []entry{{"b", c2}, {"a", c1}}
+
+	// the protobuf dag keeps links name-sorted, so insertion order must
+	// not leak into the cid even without the option
+	if !build(ab).Cid().Equals(build(ba).Cid()) {
+		t.Error("expected insertion order not to affect the cid")
+	}
+
+	sortedAB := build(ab, opt.Object.SortLinks(true))
+	sortedBA := build(ba, opt.Object.SortLinks(true))
+	if !sortedAB.Cid().Equals(sortedBA.Cid()) {
+		t.Errorf("expected sorted cids to match, got %s and %s", sortedAB.Cid(), sortedBA.Cid())
+	}
+	if !sortedAB.Cid().Equals(build(ab).Cid()) {
+		t.Error("expected sorting an already-sorted node to not change the cid")
+	}
+}
+
 func TestObjectAddLinkCreate(t *testing.T) {
 	ctx := context.Background()
 	_, api, err := makeAPI(ctx)
@@ -279,7 +409,7 @@ func TestObjectAddLinkCreate(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	links, err := api.Object().Links(ctx, p3)
+	links, err := allLinks(ctx, api, p3)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -319,7 +449,7 @@ func TestObjectRmLink(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	links, err := api.Object().Links(ctx, p3)
+	links, err := allLinks(ctx, api, p3)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -358,6 +488,79 @@ func TestObjectAddData(t *testing.T) {
 	}
 }
 
+func TestObjectSpliceData(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"foo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"0123456789"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readData := func(p iface.Path) string {
+		r, err := api.Object().Data(ctx, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	p1, err := api.Object().SpliceData(ctx, base, source, 2, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readData(p1); got != "foo23456" {
+		t.Errorf("unexpected spliced data: %q", got)
+	}
+
+	// a negative length takes everything from the offset to the end
+	p2, err := api.Object().SpliceData(ctx, base, source, 7, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readData(p2); got != "foo789" {
+		t.Errorf("unexpected spliced data: %q", got)
+	}
+
+	// a length past the end of the source is clamped
+	p3, err := api.Object().SpliceData(ctx, base, source, 7, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p3.Cid() != p2.Cid() {
+		t.Errorf("expected clamped splice to match: %s != %s", p3.Cid(), p2.Cid())
+	}
+
+	// splicing is equivalent to appending the same bytes, so the cids match
+	p4, err := api.Object().AppendData(ctx, base, strings.NewReader("23456"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p4.Cid() != p1.Cid() {
+		t.Errorf("expected splice and append cids to match: %s != %s", p4.Cid(), p1.Cid())
+	}
+
+	if _, err := api.Object().SpliceData(ctx, base, source, 11, -1); err == nil {
+		t.Error("expected an error for an offset past the end of the source data")
+	}
+
+	if _, err := api.Object().SpliceData(ctx, base, source, -1, -1); err == nil {
+		t.Error("expected an error for a negative offset")
+	}
+}
+
 func TestObjectSetData(t *testing.T) {
 	ctx := context.Background()
 	_, api, err := makeAPI(ctx)
@@ -425,3 +628,228 @@ func TestDiffTest(t *testing.T) {
 		t.Fatal("unexpected before path")
 	}
 }
+
+func TestDiffDepth(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf1, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"leaf1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf2, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"leaf2"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subA, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"sub", "Links":[{"Name":"leaf", "Hash":"`+leaf1.Cid().String()+`", "Size":7}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subB, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"sub", "Links":[{"Name":"leaf", "Hash":"`+leaf2.Cid().String()+`", "Size":7}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rootA, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"root", "Links":[{"Name":"sub", "Hash":"`+subA.Cid().String()+`", "Size":7}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rootB, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"root", "Links":[{"Name":"sub", "Hash":"`+subB.Cid().String()+`", "Size":7}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without a depth limit the change is reported on the leaf
+	changes, err := api.Object().Diff(ctx, rootA, rootB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 1 {
+		t.Fatal("unexpected changes len")
+	}
+
+	if changes[0].Path != "sub/leaf" {
+		t.Errorf("unexpected change path: %s", changes[0].Path)
+	}
+
+	// with depth=1 the whole changed subtree is a single Mod
+	changes, err = api.Object().Diff(ctx, rootA, rootB, opt.Object.Depth(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 1 {
+		t.Fatal("unexpected changes len")
+	}
+
+	if changes[0].Type != iface.DiffMod {
+		t.Fatal("unexpected change type")
+	}
+
+	if changes[0].Path != "sub" {
+		t.Errorf("unexpected change path: %s", changes[0].Path)
+	}
+
+	if changes[0].Before.Cid().String() != subA.Cid().String() {
+		t.Error("unexpected before cid")
+	}
+
+	if changes[0].After.Cid().String() != subB.Cid().String() {
+		t.Error("unexpected after cid")
+	}
+}
+
+func TestObjectAppendLinks(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p1, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"foo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"bazz", "Links":[{"Name":"bar", "Hash":"`+p1.Cid().String()+`", "Size":3}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p2, err := api.Object().AppendLinks(ctx, base, map[string]iface.Path{
+		"abc": p1,
+		"def": p1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	links, err := allLinks(ctx, api, p2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(links) != 3 {
+		t.Fatalf("unexpected number of links: %d", len(links))
+	}
+
+	names := make([]string, len(links))
+	for i, l := range links {
+		names[i] = l.Name
+	}
+	for i, expected := range []string{"abc", "bar", "def"} {
+		if names[i] != expected {
+			t.Errorf("unexpected link %d name: %s", i, names[i])
+		}
+	}
+
+	// colliding names are rejected
+	_, err = api.Object().AppendLinks(ctx, p2, map[string]iface.Path{"bar": p1})
+	if err == nil {
+		t.Fatal("expected an error appending a colliding link")
+	}
+
+	// names with path separators are rejected
+	_, err = api.Object().AppendLinks(ctx, p2, map[string]iface.Path{"a/b": p1})
+	if err == nil {
+		t.Fatal("expected an error appending an invalid link name")
+	}
+}
+
+func TestObjectCreateDirectory(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p1, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"foo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p2, err := api.Object().Put(ctx, strings.NewReader(`{"Data":"bar"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := api.Object().CreateDirectory(ctx, map[string]iface.Path{
+		"foo": p1,
+		"bar": p2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	links, err := allLinks(ctx, api, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(links) != 2 {
+		t.Fatalf("unexpected number of links: %d", len(links))
+	}
+
+	if links[0].Name != "bar" || !links[0].Cid.Equals(p2.Cid()) {
+		t.Errorf("unexpected link 0: %s %s", links[0].Name, links[0].Cid)
+	}
+
+	if links[1].Name != "foo" || !links[1].Cid.Equals(p1.Cid()) {
+		t.Errorf("unexpected link 1: %s %s", links[1].Name, links[1].Cid)
+	}
+
+	// entry names with path separators are rejected
+	_, err = api.Object().CreateDirectory(ctx, map[string]iface.Path{"a/b": p1})
+	if err == nil {
+		t.Fatal("expected an error creating a directory with an invalid entry name")
+	}
+}
+
+func TestObjectDataRaw(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := api.Block().Put(ctx, strings.NewReader(`raw bytes`), opt.Block.Format("raw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := api.Object().Data(ctx, res.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "raw bytes" {
+		t.Errorf("unexpected raw data: %q", string(data))
+	}
+
+	// other codecs are refused with an error naming them
+	cres, err := api.Dag().Put(ctx, strings.NewReader(`"Hello"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = api.Object().Data(ctx, cres)
+	if err == nil {
+		t.Fatal("expected an error reading data from a cbor node")
+	}
+	if !strings.Contains(err.Error(), "cbor") {
+		t.Errorf("expected the error to name the codec, got: %s", err)
+	}
+}
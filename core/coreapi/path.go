@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	gopath "path"
+	"sync"
 
 	"github.com/ipsn/go-ipfs/core"
 	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
@@ -15,6 +16,69 @@ import (
 	ipld "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipld-format"
 )
 
+// resolveBatchWorkers bounds the concurrent resolutions per ResolveBatch
+// call.
+const resolveBatchWorkers = 8
+
+// ResolveBatch resolves the given paths concurrently and returns a result
+// per path. Each path goes through ResolvePath, so /ipns names are resolved
+// through namesys and immutable paths through the Unixfs resolver. Repeated
+// paths are resolved once. The context bounds the whole batch; on
+// cancellation the remaining paths report the context error.
+func (api *CoreAPI) ResolveBatch(ctx context.Context, paths []string) map[string]coreiface.ResolveResult {
+	unique := make([]string, 0, len(paths))
+	seen := make(map[string]struct{}, len(paths))
+	for _, pstr := range paths {
+		if _, ok := seen[pstr]; ok {
+			continue
+		}
+		seen[pstr] = struct{}{}
+		unique = append(unique, pstr)
+	}
+
+	out := make(map[string]coreiface.ResolveResult, len(unique))
+	jobs := make(chan string)
+
+	var (
+		lk sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	workers := resolveBatchWorkers
+	if len(unique) < workers {
+		workers = len(unique)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pstr := range jobs {
+				var res coreiface.ResolveResult
+				if err := ctx.Err(); err != nil {
+					res = coreiface.ResolveResult{Err: err}
+				} else if p, err := coreiface.ParsePath(pstr); err != nil {
+					res = coreiface.ResolveResult{Err: err}
+				} else {
+					rp, err := api.ResolvePath(ctx, p)
+					res = coreiface.ResolveResult{Path: rp, Err: err}
+				}
+
+				lk.Lock()
+				out[pstr] = res
+				lk.Unlock()
+			}
+		}()
+	}
+
+	for _, pstr := range unique {
+		jobs <- pstr
+	}
+	close(jobs)
+	wg.Wait()
+
+	return out
+}
+
 // ResolveNode resolves the path `p` using Unixfs resolver, gets and returns the
 // resolved Node.
 func (api *CoreAPI) ResolveNode(ctx context.Context, p coreiface.Path) (ipld.Node, error) {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
 	"github.com/ipsn/go-ipfs/core/coreapi/interface/options"
@@ -117,6 +118,60 @@ func TestInvalidPathRemainder(t *testing.T) {
 	}
 }
 
+func TestResolveBatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	nds, apis, err := makeAPISwarm(ctx, true, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := apis[0]
+
+	p, err := addTestObject(ctx, api)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Name().Publish(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+
+	goodIpfs := p.String()
+	badIpfs := p.String() + "/nope"
+	goodIpns := "/ipns/" + nds[0].Identity.Pretty()
+	badIpns := "/ipns/" + testPeerID
+	malformed := "not-a-path"
+
+	res := api.ResolveBatch(ctx, []string{goodIpfs, badIpfs, goodIpns, badIpns, malformed})
+
+	if len(res) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(res))
+	}
+
+	if r := res[goodIpfs]; r.Err != nil {
+		t.Errorf("expected %s to resolve, got: %s", goodIpfs, r.Err)
+	} else if r.Path.String() != p.String() {
+		t.Errorf("expected %s, got %s", p, r.Path)
+	}
+
+	if r := res[goodIpns]; r.Err != nil {
+		t.Errorf("expected %s to resolve, got: %s", goodIpns, r.Err)
+	} else if r.Path.String() != p.String() {
+		t.Errorf("expected %s to resolve to %s, got %s", goodIpns, p, r.Path)
+	}
+
+	if res[badIpfs].Err == nil {
+		t.Errorf("expected %s to fail", badIpfs)
+	}
+	if res[badIpns].Err == nil {
+		t.Errorf("expected %s to fail", badIpns)
+	}
+	if res[malformed].Err == nil {
+		t.Errorf("expected %q to fail", malformed)
+	}
+}
+
 func TestPathRoot(t *testing.T) {
 	ctx := context.Background()
 	_, api, err := makeAPI(ctx)
@@ -2,16 +2,22 @@ package coreapi
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"sync"
+	"time"
 
 	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
 	caopts "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
 	corerepo "github.com/ipsn/go-ipfs/core/corerepo"
+	pin "github.com/ipsn/go-ipfs/pin"
 	bserv "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-blockservice"
 	merkledag "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-merkledag"
 
 	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
 	offline "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-exchange-offline"
+	"github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-verifcid"
 )
 
 type PinAPI CoreAPI
@@ -34,10 +40,37 @@ func (api *PinAPI) Add(ctx context.Context, p coreiface.Path, opts ...caopts.Pin
 		return err
 	}
 
+	if settings.Name != "" {
+		if err := api.pinning.SetPinName(rp.Cid(), settings.Name); err != nil {
+			return err
+		}
+	}
+
 	return api.pinning.Flush()
 }
 
+// Ls collects the streamed pin set into a slice. Prefer LsStream when the
+// pin set may be large.
 func (api *PinAPI) Ls(ctx context.Context, opts ...caopts.PinLsOption) ([]coreiface.Pin, error) {
+	pins, err := api.LsStream(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []coreiface.Pin
+	for p := range pins {
+		if p.Err() != nil {
+			return nil, p.Err()
+		}
+		out = append(out, p)
+	}
+
+	return out, nil
+}
+
+// LsStream streams the pin set over a channel, filtering by type at the
+// source so only the matching pins are ever enumerated.
+func (api *PinAPI) LsStream(ctx context.Context, opts ...caopts.PinLsOption) (<-chan coreiface.Pin, error) {
 	settings, err := caopts.PinLsOptions(opts...)
 	if err != nil {
 		return nil, err
@@ -49,7 +82,7 @@ func (api *PinAPI) Ls(ctx context.Context, opts ...caopts.PinLsOption) ([]coreif
 		return nil, fmt.Errorf("invalid type '%s', must be one of {direct, indirect, recursive, all}", settings.Type)
 	}
 
-	return api.pinLsAll(settings.Type, ctx)
+	return api.pinLsStream(settings.Type, ctx), nil
 }
 
 func (api *PinAPI) Rm(ctx context.Context, p coreiface.Path) error {
@@ -99,6 +132,10 @@ type badNode struct {
 	err  error
 }
 
+func (s *pinStatus) Cid() cid.Cid {
+	return s.cid
+}
+
 func (s *pinStatus) Ok() bool {
 	return s.ok
 }
@@ -115,6 +152,10 @@ func (n *badNode) Err() error {
 	return n.err
 }
 
+// Verify walks every recursive pin and streams a status per pin root,
+// listing the nodes that are missing or fail to decode. Verification is
+// backed by the offline exchange so it never fetches from the network, and
+// subtrees reachable from multiple pins are only traversed once.
 func (api *PinAPI) Verify(ctx context.Context) (<-chan coreiface.PinStatus, error) {
 	visited := make(map[cid.Cid]*pinStatus)
 	bs := api.blockstore
@@ -128,6 +169,13 @@ func (api *PinAPI) Verify(ctx context.Context) (<-chan coreiface.PinStatus, erro
 			return status
 		}
 
+		if err := verifcid.ValidateCid(root); err != nil {
+			status := &pinStatus{ok: false, cid: root}
+			status.badNodes = []coreiface.BadPinNode{&badNode{path: coreiface.IpldPath(root), err: err}}
+			visited[root] = status
+			return status
+		}
+
 		links, err := getLinks(ctx, root)
 		if err != nil {
 			status := &pinStatus{ok: false, cid: root}
@@ -153,16 +201,147 @@ func (api *PinAPI) Verify(ctx context.Context) (<-chan coreiface.PinStatus, erro
 	go func() {
 		defer close(out)
 		for _, c := range recPins {
-			out <- checkPin(c)
+			select {
+			case out <- checkPin(c):
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 
 	return out, nil
 }
 
+// pinExportEntry is one line of the newline-delimited JSON stream produced
+// by Export and consumed by Import.
+type pinExportEntry struct {
+	Cid  string
+	Type string
+}
+
+// Export writes the pin set as a newline-delimited JSON stream of
+// {Cid, Type} entries. Only direct and recursive pins are exported, as
+// indirect pins are implied by their recursive roots.
+func (api *PinAPI) Export(ctx context.Context, w io.Writer) error {
+	enc := json.NewEncoder(w)
+
+	write := func(keys []cid.Cid, typeStr string) error {
+		for _, c := range keys {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
+			if err := enc.Encode(pinExportEntry{Cid: c.String(), Type: typeStr}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := write(api.pinning.DirectKeys(), "direct"); err != nil {
+		return err
+	}
+	return write(api.pinning.RecursiveKeys(), "recursive")
+}
+
+// Import reads a stream in the format written by Export and adds each entry
+// to the pin set, returning the number of imported pins.
+func (api *PinAPI) Import(ctx context.Context, r io.Reader) (int, error) {
+	dec := json.NewDecoder(r)
+
+	count := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return count, ctx.Err()
+		default:
+		}
+
+		var entry pinExportEntry
+		switch err := dec.Decode(&entry); err {
+		case nil:
+		case io.EOF:
+			return count, api.pinning.Flush()
+		default:
+			return count, err
+		}
+
+		c, err := cid.Decode(entry.Cid)
+		if err != nil {
+			return count, err
+		}
+
+		mode, ok := pin.StringToMode(entry.Type)
+		if !ok {
+			return count, fmt.Errorf("unknown pin type %q", entry.Type)
+		}
+
+		api.pinning.PinWithMode(c, mode)
+		count++
+	}
+}
+
+// pinStatsCacheFor is how long a computed PinStats result stays valid.
+const pinStatsCacheFor = time.Second * 30
+
+// pinStatsCache memoizes the result of Stats, which walks every recursive
+// pin and can be expensive on large pin sets.
+type pinStatsCache struct {
+	lk    sync.Mutex
+	stats coreiface.PinStats
+	at    time.Time
+}
+
+// Stats computes aggregate statistics over the local pin set. Results are
+// cached for pinStatsCacheFor.
+func (api *PinAPI) Stats(ctx context.Context) (coreiface.PinStats, error) {
+	cache := api.pinStatsCache
+	cache.lk.Lock()
+	defer cache.lk.Unlock()
+
+	if !cache.at.IsZero() && time.Since(cache.at) < pinStatsCacheFor {
+		return cache.stats, nil
+	}
+
+	var stats coreiface.PinStats
+	stats.DirectCount = len(api.pinning.DirectKeys())
+
+	recursive := api.pinning.RecursiveKeys()
+	stats.RecursiveCount = len(recursive)
+
+	indirect := cid.NewSet()
+	for _, k := range recursive {
+		err := merkledag.EnumerateChildren(ctx, merkledag.GetLinksWithDAG(api.dag), k, indirect.Visit)
+		if err != nil {
+			return coreiface.PinStats{}, err
+		}
+
+		nd, err := api.dag.Get(ctx, k)
+		if err != nil {
+			return coreiface.PinStats{}, err
+		}
+		size, err := nd.Size()
+		if err != nil {
+			return coreiface.PinStats{}, err
+		}
+		stats.TotalSize += size
+	}
+	stats.IndirectCount = indirect.Len()
+
+	stats.TotalCount = stats.DirectCount + stats.RecursiveCount + stats.IndirectCount
+
+	cache.stats = stats
+	cache.at = time.Now()
+	return stats, nil
+}
+
 type pinInfo struct {
 	pinType string
 	path    coreiface.ResolvedPath
+	name    string
+	err     error
 }
 
 func (p *pinInfo) Path() coreiface.ResolvedPath {
@@ -173,42 +352,86 @@ func (p *pinInfo) Type() string {
 	return p.pinType
 }
 
-func (api *PinAPI) pinLsAll(typeStr string, ctx context.Context) ([]coreiface.Pin, error) {
+func (p *pinInfo) Name() string {
+	return p.name
+}
+
+func (p *pinInfo) Err() error {
+	return p.err
+}
 
-	keys := make(map[cid.Cid]*pinInfo)
+func (api *PinAPI) pinLsStream(typeStr string, ctx context.Context) <-chan coreiface.Pin {
+	out := make(chan coreiface.Pin)
 
-	AddToResultKeys := func(keyList []cid.Cid, typeStr string) {
-		for _, c := range keyList {
-			keys[c] = &pinInfo{
-				pinType: typeStr,
-				path:    coreiface.IpldPath(c),
+	go func() {
+		defer close(out)
+
+		emit := func(info *pinInfo) bool {
+			select {
+			case out <- info:
+				return true
+			case <-ctx.Done():
+				return false
 			}
 		}
-	}
 
-	if typeStr == "direct" || typeStr == "all" {
-		AddToResultKeys(api.pinning.DirectKeys(), "direct")
-	}
-	if typeStr == "indirect" || typeStr == "all" {
-		set := cid.NewSet()
-		for _, k := range api.pinning.RecursiveKeys() {
-			err := merkledag.EnumerateChildren(ctx, merkledag.GetLinksWithDAG(api.dag), k, set.Visit)
-			if err != nil {
-				return nil, err
+		emitted := cid.NewSet()
+		emitKeys := func(keyList []cid.Cid, typeStr string) bool {
+			for _, c := range keyList {
+				if !emitted.Visit(c) {
+					continue
+				}
+				ok := emit(&pinInfo{
+					pinType: typeStr,
+					path:    coreiface.IpldPath(c),
+					name:    api.pinning.PinName(c),
+				})
+				if !ok {
+					return false
+				}
 			}
+			return true
 		}
-		AddToResultKeys(set.Keys(), "indirect")
-	}
-	if typeStr == "recursive" || typeStr == "all" {
-		AddToResultKeys(api.pinning.RecursiveKeys(), "recursive")
-	}
 
-	out := make([]coreiface.Pin, 0, len(keys))
-	for _, v := range keys {
-		out = append(out, v)
-	}
+		if typeStr == "direct" || typeStr == "all" {
+			if !emitKeys(api.pinning.DirectKeys(), "direct") {
+				return
+			}
+		}
+		if typeStr == "recursive" || typeStr == "all" {
+			if !emitKeys(api.pinning.RecursiveKeys(), "recursive") {
+				return
+			}
+		}
+		if typeStr == "indirect" || typeStr == "all" {
+			// Indirect pins are enumerated lazily, one recursive root at
+			// a time, so the full set is never materialized in memory.
+			// The walked set only guards the traversal; cids already
+			// emitted as direct or recursive pins are still descended
+			// into, just not emitted again.
+			walked := cid.NewSet()
+			for _, k := range api.pinning.RecursiveKeys() {
+				err := merkledag.EnumerateChildren(ctx, merkledag.GetLinksWithDAG(api.dag), k, func(c cid.Cid) bool {
+					if !walked.Visit(c) {
+						return false
+					}
+					if !emitted.Visit(c) {
+						return true
+					}
+					return emit(&pinInfo{
+						pinType: "indirect",
+						path:    coreiface.IpldPath(c),
+					})
+				})
+				if err != nil {
+					emit(&pinInfo{err: err})
+					return
+				}
+			}
+		}
+	}()
 
-	return out, nil
+	return out
 }
 
 func (api *PinAPI) core() coreiface.CoreAPI {
@@ -1,10 +1,13 @@
 package coreapi_test
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 
+	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
 	opt "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
 )
 
@@ -209,3 +212,385 @@ func TestPinRecursive(t *testing.T) {
 		t.Errorf("unexpected verify result count: %d", n)
 	}
 }
+
+func TestPinLsStream(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	p0, err := api.Unixfs().Add(ctx, strFile("foo")())
+	if err != nil {
+		t.Error(err)
+	}
+
+	p1, err := api.Dag().Put(ctx, strings.NewReader(`{"lnk": {"/": "`+p0.Cid().String()+`"}}`))
+	if err != nil {
+		t.Error(err)
+	}
+
+	p2, err := api.Unixfs().Add(ctx, strFile("bar")())
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = api.Pin().Add(ctx, p1)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = api.Pin().Add(ctx, p2, opt.Pin.Recursive(false))
+	if err != nil {
+		t.Error(err)
+	}
+
+	collect := func(opts ...opt.PinLsOption) map[string]string {
+		pins, err := api.Pin().LsStream(ctx, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := make(map[string]string)
+		for p := range pins {
+			if p.Err() != nil {
+				t.Fatal(p.Err())
+			}
+			if _, ok := out[p.Path().Cid().String()]; ok {
+				t.Errorf("pin %s was emitted twice", p.Path())
+			}
+			out[p.Path().Cid().String()] = p.Type()
+		}
+		return out
+	}
+
+	all := collect()
+	if len(all) != 3 {
+		t.Errorf("unexpected pin count: %d", len(all))
+	}
+	if all[p1.Cid().String()] != "recursive" {
+		t.Errorf("unexpected type for %s: %s", p1, all[p1.Cid().String()])
+	}
+	if all[p2.Cid().String()] != "direct" {
+		t.Errorf("unexpected type for %s: %s", p2, all[p2.Cid().String()])
+	}
+	if all[p0.Cid().String()] != "indirect" {
+		t.Errorf("unexpected type for %s: %s", p0, all[p0.Cid().String()])
+	}
+
+	// filtering happens at the source
+	indirect := collect(opt.Pin.Type.Indirect())
+	if len(indirect) != 1 || indirect[p0.Cid().String()] != "indirect" {
+		t.Errorf("unexpected indirect pins: %v", indirect)
+	}
+}
+
+func TestPinName(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	p, err := api.Unixfs().Add(ctx, strFile("foo")())
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = api.Pin().Add(ctx, p, opt.Pin.Name("my data"))
+	if err != nil {
+		t.Error(err)
+	}
+
+	list, err := api.Pin().Ls(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 {
+		t.Fatalf("unexpected pin list len: %d", len(list))
+	}
+	if list[0].Name() != "my data" {
+		t.Errorf("unexpected pin name: %q", list[0].Name())
+	}
+
+	// unpinning drops the name
+	err = api.Pin().Rm(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Pin().Add(ctx, p)
+	if err != nil {
+		t.Error(err)
+	}
+
+	list, err = api.Pin().Ls(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 {
+		t.Fatalf("unexpected pin list len: %d", len(list))
+	}
+	if list[0].Name() != "" {
+		t.Errorf("expected the name to be dropped on unpin, got %q", list[0].Name())
+	}
+}
+
+func TestPinVerify(t *testing.T) {
+	ctx := context.Background()
+	nd, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	p0, err := api.Unixfs().Add(ctx, strFile("foo")())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p1, err := api.Dag().Put(ctx, strings.NewReader(`{"lnk": {"/": "`+p0.Cid().String()+`"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Pin().Add(ctx, p1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := api.Pin().Verify(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for s := range res {
+		if s.Cid() == p1.Cid() && !s.Ok() {
+			t.Error("expected pin to verify ok")
+		}
+	}
+
+	err = nd.Blockstore.DeleteBlock(p0.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = api.Pin().Verify(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var checked bool
+	for s := range res {
+		if s.Cid() != p1.Cid() {
+			continue
+		}
+		checked = true
+
+		if s.Ok() {
+			t.Error("expected pin to be broken")
+		}
+
+		var found bool
+		for _, bad := range s.BadNodes() {
+			if bad.Path().Cid() == p0.Cid() {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("missing block %s not reported in BadNodes", p0.Cid())
+		}
+	}
+
+	if !checked {
+		t.Error("pin root missing from verify output")
+	}
+}
+
+func TestPinUpdate(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	p0, err := api.Unixfs().Add(ctx, strFile("old root")())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p1, err := api.Unixfs().Add(ctx, strFile("new root")())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Pin().Add(ctx, p0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Pin().Update(ctx, p0, p1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pinned := func(p coreiface.ResolvedPath) bool {
+		list, err := api.Pin().Ls(ctx, opt.Pin.Type.Recursive())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pin := range list {
+			if pin.Path().Cid() == p.Cid() {
+				return true
+			}
+		}
+		return false
+	}
+
+	if pinned(p0) {
+		t.Error("old root is still recursively pinned after update")
+	}
+	if !pinned(p1) {
+		t.Error("new root is not recursively pinned after update")
+	}
+
+	// with Unpin(false) the old pin is kept
+	err = api.Pin().Update(ctx, p1, p0, opt.Pin.Unpin(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !pinned(p0) || !pinned(p1) {
+		t.Error("expected both roots to be recursively pinned")
+	}
+}
+
+func TestPinStats(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p0, err := api.Unixfs().Add(ctx, strFile("foo")())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Pin().Add(ctx, p0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p1, err := api.Unixfs().Add(ctx, strFile("bar")())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Pin().Add(ctx, p1, opt.Pin.Recursive(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := api.Pin().Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.DirectCount != 1 {
+		t.Errorf("unexpected direct count: %d", stats.DirectCount)
+	}
+	if stats.RecursiveCount != 1 {
+		t.Errorf("unexpected recursive count: %d", stats.RecursiveCount)
+	}
+	if stats.TotalCount != stats.DirectCount+stats.RecursiveCount+stats.IndirectCount {
+		t.Error("total count doesn't add up")
+	}
+	if stats.TotalSize == 0 {
+		t.Error("expected non-zero total size")
+	}
+
+	// the result is cached, so a pin added now must not show up yet
+	p2, err := api.Unixfs().Add(ctx, strFile("baz")())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.Pin().Add(ctx, p2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cached, err := api.Pin().Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cached.RecursiveCount != stats.RecursiveCount {
+		t.Error("expected cached stats to be returned")
+	}
+}
+
+func TestPinExportImport(t *testing.T) {
+	ctx := context.Background()
+	_, apiA, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, apiB, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := make(map[string]string)
+	for i := 0; i < 50; i++ {
+		p, err := apiA.Unixfs().Add(ctx, strFile(fmt.Sprintf("pin%d", i))())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if i%2 == 0 {
+			err = apiA.Pin().Add(ctx, p)
+			expected[p.Cid().String()] = "recursive"
+		} else {
+			err = apiA.Pin().Add(ctx, p, opt.Pin.Recursive(false))
+			expected[p.Cid().String()] = "direct"
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := apiA.Pin().Export(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := apiB.Pin().Import(ctx, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != len(expected) {
+		t.Fatalf("expected %d imported pins, got %d", len(expected), count)
+	}
+
+	for _, typeOpt := range []opt.PinLsOption{opt.Pin.Type.Direct(), opt.Pin.Type.Recursive()} {
+		list, err := apiB.Pin().Ls(ctx, typeOpt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, p := range list {
+			if expected[p.Path().Cid().String()] != p.Type() {
+				t.Errorf("pin %s has unexpected type %s", p.Path(), p.Type())
+			}
+			delete(expected, p.Path().Cid().String())
+		}
+	}
+
+	if len(expected) != 0 {
+		t.Errorf("%d pins were not imported", len(expected))
+	}
+}
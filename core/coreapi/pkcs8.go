@@ -0,0 +1,210 @@
+package coreapi
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+
+	crypto "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-crypto"
+
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Object identifiers for the key types and encryption algorithms recognized
+// when importing PKCS8 keys.
+var (
+	oidPublicKeyRSA        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidPublicKeyECDSA      = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidPublicKeyEd25519    = asn1.ObjectIdentifier{1, 3, 101, 112}
+	oidNamedCurveSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// pkcs8 mirrors the ASN.1 structure of an unencrypted PKCS8 key, so key
+// types the standard library does not handle can still be recognized.
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// ecPrivateKey is the SEC1 structure wrapped inside a PKCS8 EC key.
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// parsePKCS8PrivateKey converts a DER-encoded PKCS8 private key into a
+// libp2p private key. RSA, Ed25519 and secp256k1 keys are supported.
+func parsePKCS8PrivateKey(der []byte) (crypto.PrivKey, error) {
+	var p pkcs8
+	if _, err := asn1.Unmarshal(der, &p); err != nil {
+		return nil, fmt.Errorf("invalid PKCS8 key: %s", err)
+	}
+
+	switch {
+	case p.Algo.Algorithm.Equal(oidPublicKeyRSA):
+		sk, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			return nil, err
+		}
+		rk, ok := sk.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("unexpected RSA key type %T", sk)
+		}
+		return crypto.UnmarshalRsaPrivateKey(x509.MarshalPKCS1PrivateKey(rk))
+	case p.Algo.Algorithm.Equal(oidPublicKeyEd25519):
+		var seed []byte
+		if _, err := asn1.Unmarshal(p.PrivateKey, &seed); err != nil {
+			return nil, fmt.Errorf("invalid ed25519 key: %s", err)
+		}
+		if len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid ed25519 seed length: %d", len(seed))
+		}
+		return crypto.UnmarshalEd25519PrivateKey(ed25519.NewKeyFromSeed(seed))
+	case p.Algo.Algorithm.Equal(oidPublicKeyECDSA):
+		var curve asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(p.Algo.Parameters.FullBytes, &curve); err != nil {
+			return nil, fmt.Errorf("invalid EC key parameters: %s", err)
+		}
+		if !curve.Equal(oidNamedCurveSecp256k1) {
+			return nil, fmt.Errorf("unsupported EC curve %v, only secp256k1 can be imported", curve)
+		}
+
+		var ec ecPrivateKey
+		if _, err := asn1.Unmarshal(p.PrivateKey, &ec); err != nil {
+			return nil, fmt.Errorf("invalid EC key: %s", err)
+		}
+		if len(ec.PrivateKey) > 32 {
+			return nil, fmt.Errorf("invalid secp256k1 key length: %d", len(ec.PrivateKey))
+		}
+		k := make([]byte, 32)
+		copy(k[32-len(ec.PrivateKey):], ec.PrivateKey)
+		return crypto.UnmarshalSecp256k1PrivateKey(k)
+	default:
+		return nil, fmt.Errorf("unsupported key type %v (RSA, Ed25519 and secp256k1 are supported)", p.Algo.Algorithm)
+	}
+}
+
+// encryptedPrivateKeyInfo is the ASN.1 structure of an encrypted PKCS8 key.
+type encryptedPrivateKeyInfo struct {
+	Algo          pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int                      `asn1:"optional"`
+	PRF        pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// decryptPKCS8 decrypts a DER-encoded encrypted PKCS8 key (PBES2 with
+// PBKDF2 and AES-CBC, as produced by 'openssl pkcs8 -topk8 -v2 aes-256-cbc')
+// and returns the plain PKCS8 bytes.
+func decryptPKCS8(der []byte, password []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("invalid encrypted PKCS8 key: %s", err)
+	}
+
+	if !info.Algo.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported PKCS8 encryption scheme %v, only PBES2 is supported", info.Algo.Algorithm)
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algo.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("invalid PBES2 parameters: %s", err)
+	}
+
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("unsupported key derivation function %v, only PBKDF2 is supported", params.KeyDerivationFunc.Algorithm)
+	}
+
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, fmt.Errorf("invalid PBKDF2 parameters: %s", err)
+	}
+
+	prf := sha1.New
+	if kdf.PRF.Algorithm != nil {
+		switch {
+		case kdf.PRF.Algorithm.Equal(oidHMACWithSHA1):
+			prf = sha1.New
+		case kdf.PRF.Algorithm.Equal(oidHMACWithSHA256):
+			prf = sha256.New
+		case kdf.PRF.Algorithm.Equal(oidHMACWithSHA512):
+			prf = sha512.New
+		default:
+			return nil, fmt.Errorf("unsupported PBKDF2 pseudo-random function %v", kdf.PRF.Algorithm)
+		}
+	}
+
+	var keyLen int
+	switch {
+	case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+		keyLen = 16
+	case params.EncryptionScheme.Algorithm.Equal(oidAES192CBC):
+		keyLen = 24
+	case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+		keyLen = 32
+	default:
+		return nil, fmt.Errorf("unsupported encryption scheme %v, only AES-CBC is supported", params.EncryptionScheme.Algorithm)
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("invalid AES-CBC parameters: %s", err)
+	}
+
+	key := pbkdf2.Key(password, kdf.Salt, kdf.Iterations, keyLen, prf)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, fmt.Errorf("invalid AES-CBC IV length: %d", len(iv))
+	}
+	if len(info.EncryptedData) == 0 || len(info.EncryptedData)%block.BlockSize() != 0 {
+		return nil, fmt.Errorf("invalid encrypted key length: %d", len(info.EncryptedData))
+	}
+
+	out := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, info.EncryptedData)
+
+	// check the PKCS7 padding, a mismatch almost always means a bad password
+	pad := int(out[len(out)-1])
+	if pad == 0 || pad > block.BlockSize() {
+		return nil, fmt.Errorf("could not decrypt key, is the password correct?")
+	}
+	for _, b := range out[len(out)-pad:] {
+		if int(b) != pad {
+			return nil, fmt.Errorf("could not decrypt key, is the password correct?")
+		}
+	}
+
+	return out[:len(out)-pad], nil
+}
@@ -2,6 +2,7 @@ package coreapi
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"strings"
 	"sync"
@@ -11,6 +12,7 @@ import (
 	caopts "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
 
 	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
+	ci "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-crypto"
 	routing "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-routing"
 	peer "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peer"
 	pstore "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peerstore"
@@ -23,10 +25,25 @@ type PubSubAPI CoreAPI
 type pubSubSubscription struct {
 	cancel       context.CancelFunc
 	subscription *pubsub.Subscription
+
+	// validateSig makes Next drop messages without a valid signature envelope
+	validateSig bool
 }
 
 type pubSubMessage struct {
 	msg *pubsub.Message
+
+	// data overrides the raw message payload when the signature envelope has
+	// been unwrapped
+	data []byte
+}
+
+// pubSubEnvelope wraps signed message payloads so subscribers can verify the
+// sender
+type pubSubEnvelope struct {
+	PubKey []byte `json:"pubkey"`
+	Data   []byte `json:"data"`
+	Sig    []byte `json:"sig"`
 }
 
 func (api *PubSubAPI) Ls(ctx context.Context) ([]string, error) {
@@ -59,12 +76,38 @@ func (api *PubSubAPI) Peers(ctx context.Context, opts ...caopts.PubSubPeersOptio
 	return out, nil
 }
 
-func (api *PubSubAPI) Publish(ctx context.Context, topic string, data []byte) error {
+func (api *PubSubAPI) Publish(ctx context.Context, topic string, data []byte, opts ...caopts.PubSubPublishOption) error {
 	_, err := api.checkNode()
 	if err != nil {
 		return err
 	}
 
+	settings, err := caopts.PubSubPublishOptions(opts...)
+	if err != nil {
+		return err
+	}
+
+	if settings.Sign {
+		pk, err := ci.MarshalPublicKey(api.privateKey.GetPublic())
+		if err != nil {
+			return err
+		}
+
+		sig, err := api.privateKey.Sign(data)
+		if err != nil {
+			return err
+		}
+
+		data, err = json.Marshal(&pubSubEnvelope{
+			PubKey: pk,
+			Data:   data,
+			Sig:    sig,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	return api.pubSub.Publish(topic, data)
 }
 
@@ -95,7 +138,36 @@ func (api *PubSubAPI) Subscribe(ctx context.Context, topic string, opts ...caopt
 		}()
 	}
 
-	return &pubSubSubscription{cancel, sub}, nil
+	return &pubSubSubscription{cancel: cancel, subscription: sub, validateSig: options.ValidateSig}, nil
+}
+
+// verifyEnvelope unwraps a signature envelope, checking that the signature
+// covers the payload and that the signing key belongs to the sending peer.
+// The second return value is false for unsigned or tampered messages.
+func verifyEnvelope(msg *pubsub.Message) ([]byte, bool) {
+	var env pubSubEnvelope
+	if err := json.Unmarshal(msg.Data, &env); err != nil {
+		return nil, false
+	}
+	if env.PubKey == nil || env.Sig == nil {
+		return nil, false
+	}
+
+	pk, err := ci.UnmarshalPublicKey(env.PubKey)
+	if err != nil {
+		return nil, false
+	}
+
+	pid, err := peer.IDFromPublicKey(pk)
+	if err != nil || pid != peer.ID(msg.From) {
+		return nil, false
+	}
+
+	if ok, err := pk.Verify(env.Data, env.Sig); err != nil || !ok {
+		return nil, false
+	}
+
+	return env.Data, true
 }
 
 func connectToPubSubPeers(ctx context.Context, r routing.IpfsRouting, ph p2phost.Host, cid cid.Cid) {
@@ -142,12 +214,20 @@ func (sub *pubSubSubscription) Close() error {
 }
 
 func (sub *pubSubSubscription) Next(ctx context.Context) (coreiface.PubSubMessage, error) {
-	msg, err := sub.subscription.Next(ctx)
-	if err != nil {
-		return nil, err
+	for {
+		msg, err := sub.subscription.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if !sub.validateSig {
+			return &pubSubMessage{msg: msg}, nil
+		}
+
+		if data, ok := verifyEnvelope(msg); ok {
+			return &pubSubMessage{msg: msg, data: data}, nil
+		}
 	}
-
-	return &pubSubMessage{msg}, nil
 }
 
 func (msg *pubSubMessage) From() peer.ID {
@@ -155,6 +235,9 @@ func (msg *pubSubMessage) From() peer.ID {
 }
 
 func (msg *pubSubMessage) Data() []byte {
+	if msg.data != nil {
+		return msg.data
+	}
 	return msg.msg.Data
 }
 
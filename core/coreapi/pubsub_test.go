@@ -2,9 +2,12 @@ package coreapi_test
 
 import (
 	"context"
-	"github.com/ipsn/go-ipfs/core/coreapi/interface/options"
 	"testing"
 	"time"
+
+	"github.com/ipsn/go-ipfs/core/coreapi/interface/options"
+
+	peer "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peer"
 )
 
 func TestBasicPubSub(t *testing.T) {
@@ -94,3 +97,91 @@ func TestBasicPubSub(t *testing.T) {
 		t.Fatalf("got incorrect number of topics: %d", len(peers))
 	}
 }
+
+func TestPubSubMutualPeers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nds, apis, err := makeAPISwarm(ctx, true, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, api := range apis {
+		sub, err := api.PubSub().Subscribe(ctx, "testch")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sub.Close()
+	}
+
+	// it takes a moment for the subscriptions to propagate
+	for i, api := range apis {
+		other := nds[1-i].Identity
+
+		var peers []peer.ID
+		for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+			peers, err = api.PubSub().Peers(ctx, options.PubSub.Topic("testch"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(peers) == 1 && peers[0] == other {
+				break
+			}
+		}
+
+		if len(peers) != 1 || peers[0] != other {
+			t.Fatalf("node %d expected to see %s in topic peers, got %v", i, other, peers)
+		}
+	}
+}
+
+func TestSignedPubSub(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nds, apis, err := makeAPISwarm(ctx, true, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := apis[0].PubSub().Subscribe(ctx, "testch", options.PubSub.ValidateSig(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	go func() {
+		tick := time.Tick(100 * time.Millisecond)
+
+		for {
+			// unsigned messages must never reach the validating subscriber
+			err := apis[1].PubSub().Publish(ctx, "testch", []byte("unsigned"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = apis[1].PubSub().Publish(ctx, "testch", []byte("signed"), options.PubSub.Sign(true))
+			if err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-tick:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	m, err := sub.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(m.Data()) != "signed" {
+		t.Errorf("expected only the signed payload, got: %s", string(m.Data()))
+	}
+
+	if m.From() != nds[1].Identity {
+		t.Errorf("m.From didn't match")
+	}
+}
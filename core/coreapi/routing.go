@@ -0,0 +1,163 @@
+package coreapi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
+	peer "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peer"
+	pstore "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peerstore"
+	routing "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-routing"
+	rhelpers "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-routing-helpers"
+)
+
+// defaultRouterTimeout bounds each constituent router during composite
+// provider operations so one slow router cannot block results from the
+// others. Overridable through Routing.DelegateTimeout in the config.
+var defaultRouterTimeout = time.Minute
+
+// compositeRouting fans provider operations out over several content routers
+// (e.g. the DHT plus a delegated router) while leaving all other routing
+// operations to the primary router. Provide succeeds when at least one
+// router accepts the announcement and FindProvidersAsync merges and dedupes
+// the providers found by all of them.
+type compositeRouting struct {
+	routing.IpfsRouting
+
+	routers []routing.ContentRouting
+	timeout time.Duration
+}
+
+// newCompositeRouting wraps primary so that provider operations fan out to
+// the given content routers. With fewer than two routers the primary router
+// is returned unchanged.
+func newCompositeRouting(primary routing.IpfsRouting, routers []routing.ContentRouting, timeout time.Duration) routing.IpfsRouting {
+	if len(routers) < 2 {
+		return primary
+	}
+	if timeout <= 0 {
+		timeout = defaultRouterTimeout
+	}
+
+	return &compositeRouting{
+		IpfsRouting: primary,
+		routers:     routers,
+		timeout:     timeout,
+	}
+}
+
+// extraContentRouters returns the constituent routers of a composed routing
+// system so provider operations can address each of them individually, or
+// nil when the router is not composed.
+func extraContentRouters(r routing.IpfsRouting) []routing.ContentRouting {
+	var subs []routing.IpfsRouting
+	switch r := r.(type) {
+	case rhelpers.Tiered:
+		subs = r.Routers
+	case rhelpers.Parallel:
+		subs = r.Routers
+	default:
+		return nil
+	}
+
+	routers := make([]routing.ContentRouting, 0, len(subs))
+	for _, s := range subs {
+		routers = append(routers, s)
+	}
+	return routers
+}
+
+// Provide announces the key through every router in parallel. The routers
+// that fail are logged individually; an error is only returned when none of
+// them accepted the announcement.
+func (cr *compositeRouting) Provide(ctx context.Context, c cid.Cid, brdcst bool) error {
+	var (
+		wg     sync.WaitGroup
+		lk     sync.Mutex
+		failed int
+		first  error
+	)
+
+	for i, r := range cr.routers {
+		wg.Add(1)
+		go func(i int, r routing.ContentRouting) {
+			defer wg.Done()
+
+			rctx, cancel := context.WithTimeout(ctx, cr.timeout)
+			defer cancel()
+
+			if err := r.Provide(rctx, c, brdcst); err != nil {
+				log.Warningf("composite provide %s: router %d failed: %s", c, i, err)
+
+				lk.Lock()
+				failed++
+				if first == nil {
+					first = err
+				}
+				lk.Unlock()
+			}
+		}(i, r)
+	}
+	wg.Wait()
+
+	if failed == len(cr.routers) {
+		return fmt.Errorf("all routers failed to provide %s: %s", c, first)
+	}
+	return nil
+}
+
+// FindProvidersAsync queries every router in parallel, merging their results
+// into a single deduplicated stream of at most count providers.
+func (cr *compositeRouting) FindProvidersAsync(ctx context.Context, c cid.Cid, count int) <-chan pstore.PeerInfo {
+	out := make(chan pstore.PeerInfo)
+
+	fctx, cancel := context.WithCancel(ctx)
+
+	var (
+		wg   sync.WaitGroup
+		lk   sync.Mutex
+		seen = make(map[peer.ID]struct{})
+	)
+
+	for _, r := range cr.routers {
+		wg.Add(1)
+		go func(r routing.ContentRouting) {
+			defer wg.Done()
+
+			rctx, rcancel := context.WithTimeout(fctx, cr.timeout)
+			defer rcancel()
+
+			for p := range r.FindProvidersAsync(rctx, c, count) {
+				lk.Lock()
+				if _, dup := seen[p.ID]; dup {
+					lk.Unlock()
+					continue
+				}
+				seen[p.ID] = struct{}{}
+				done := len(seen) >= count
+				lk.Unlock()
+
+				select {
+				case out <- p:
+				case <-fctx.Done():
+					return
+				}
+
+				if done {
+					cancel()
+					return
+				}
+			}
+		}(r)
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		close(out)
+	}()
+
+	return out
+}
@@ -0,0 +1,195 @@
+package coreapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
+	mockrouting "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-routing/mock"
+	dag "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-merkledag"
+	peer "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peer"
+	pstore "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peerstore"
+	routing "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-routing"
+	testutil "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-testutil"
+)
+
+// fakeDelegate is a stand-in for a delegated content router with a fixed
+// provider set and an optional response delay.
+type fakeDelegate struct {
+	providers []pstore.PeerInfo
+	delay     time.Duration
+
+	lk         sync.Mutex
+	provided   []cid.Cid
+	provideErr error
+}
+
+func (f *fakeDelegate) Provide(ctx context.Context, c cid.Cid, brd bool) error {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+
+	if f.provideErr != nil {
+		return f.provideErr
+	}
+	f.provided = append(f.provided, c)
+	return nil
+}
+
+func (f *fakeDelegate) FindProvidersAsync(ctx context.Context, c cid.Cid, count int) <-chan pstore.PeerInfo {
+	out := make(chan pstore.PeerInfo)
+	go func() {
+		defer close(out)
+
+		if f.delay > 0 {
+			select {
+			case <-time.After(f.delay):
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		for _, p := range f.providers {
+			select {
+			case out <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func newMockDht(t *testing.T) routing.IpfsRouting {
+	id, err := testutil.RandIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return mockrouting.NewServer().Client(id)
+}
+
+func collectProviders(t *testing.T, ch <-chan pstore.PeerInfo) map[peer.ID]int {
+	out := make(map[peer.ID]int)
+	for {
+		select {
+		case p, ok := <-ch:
+			if !ok {
+				return out
+			}
+			out[p.ID]++
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for providers")
+		}
+	}
+}
+
+func TestCompositeFindProvidersMerges(t *testing.T) {
+	ctx := context.Background()
+	c := dag.NewRawNode([]byte("composite")).Cid()
+
+	dht := newMockDht(t)
+	if err := dht.Provide(ctx, c, true); err != nil {
+		t.Fatal(err)
+	}
+
+	var dhtProvider peer.ID
+	for p := range dht.FindProvidersAsync(ctx, c, 1) {
+		dhtProvider = p.ID
+	}
+	if dhtProvider == "" {
+		t.Fatal("mock dht returned no provider")
+	}
+
+	other, err := testutil.RandIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the delegate returns the dht provider again plus one of its own
+	delegate := &fakeDelegate{providers: []pstore.PeerInfo{
+		{ID: dhtProvider},
+		{ID: other.ID()},
+	}}
+
+	cr := newCompositeRouting(dht, []routing.ContentRouting{dht, delegate}, time.Second)
+
+	got := collectProviders(t, cr.FindProvidersAsync(ctx, c, 10))
+	if len(got) != 2 {
+		t.Fatalf("expected 2 unique providers, got %d: %v", len(got), got)
+	}
+	for p, n := range got {
+		if n != 1 {
+			t.Fatalf("provider %s was emitted %d times", p, n)
+		}
+	}
+	if _, ok := got[dhtProvider]; !ok {
+		t.Error("missing dht provider")
+	}
+	if _, ok := got[other.ID()]; !ok {
+		t.Error("missing delegated provider")
+	}
+}
+
+func TestCompositeSlowRouterDoesNotBlock(t *testing.T) {
+	ctx := context.Background()
+	c := dag.NewRawNode([]byte("slow")).Cid()
+
+	dht := newMockDht(t)
+	if err := dht.Provide(ctx, c, true); err != nil {
+		t.Fatal(err)
+	}
+
+	slow := &fakeDelegate{delay: time.Minute}
+
+	cr := newCompositeRouting(dht, []routing.ContentRouting{dht, slow}, time.Millisecond*100)
+
+	start := time.Now()
+	got := collectProviders(t, cr.FindProvidersAsync(ctx, c, 10))
+	if len(got) != 1 {
+		t.Fatalf("expected the dht provider, got %d providers", len(got))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second*2 {
+		t.Fatalf("slow router blocked the results for %s", elapsed)
+	}
+}
+
+func TestCompositeProvide(t *testing.T) {
+	ctx := context.Background()
+	c := dag.NewRawNode([]byte("provide")).Cid()
+
+	dht := newMockDht(t)
+	delegate := &fakeDelegate{}
+
+	cr := newCompositeRouting(dht, []routing.ContentRouting{dht, delegate}, time.Second)
+	if err := cr.Provide(ctx, c, true); err != nil {
+		t.Fatal(err)
+	}
+
+	delegate.lk.Lock()
+	nprovided := len(delegate.provided)
+	delegate.lk.Unlock()
+	if nprovided != 1 {
+		t.Fatalf("expected the delegate to see 1 provide, got %d", nprovided)
+	}
+
+	found := collectProviders(t, dht.FindProvidersAsync(ctx, c, 1))
+	if len(found) != 1 {
+		t.Fatal("expected the dht to see the provide")
+	}
+
+	// a single failing router doesn't fail the operation
+	failing := &fakeDelegate{provideErr: errors.New("delegate down")}
+	cr = newCompositeRouting(dht, []routing.ContentRouting{dht, failing}, time.Second)
+	if err := cr.Provide(ctx, c, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// all routers failing does
+	cr = newCompositeRouting(dht, []routing.ContentRouting{failing, failing}, time.Second)
+	if err := cr.Provide(ctx, c, true); err == nil {
+		t.Fatal("expected provide to fail when all routers fail")
+	}
+}
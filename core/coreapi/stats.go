@@ -0,0 +1,118 @@
+package coreapi
+
+import (
+	"context"
+	"time"
+
+	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
+
+	bitswap "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap"
+	identify "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p/p2p/protocol/identify"
+)
+
+type StatsAPI CoreAPI
+
+// Bitswap returns a snapshot of the bitswap data-transfer counters. It
+// errors when the node runs an exchange other than bitswap, e.g. offline.
+func (api *StatsAPI) Bitswap(ctx context.Context) (coreiface.BitswapStat, error) {
+	bs, ok := api.exchange.(*bitswap.Bitswap)
+	if !ok {
+		return coreiface.BitswapStat{}, coreiface.ErrOffline
+	}
+
+	st, err := bs.Stat()
+	if err != nil {
+		return coreiface.BitswapStat{}, err
+	}
+
+	return coreiface.BitswapStat{
+		BlocksSent:      st.BlocksSent,
+		DataSent:        st.DataSent,
+		BlocksReceived:  st.BlocksReceived,
+		DataReceived:    st.DataReceived,
+		DupBlksReceived: st.DupBlksReceived,
+		DupDataReceived: st.DupDataReceived,
+	}, nil
+}
+
+// BitswapHistory returns the periodic bitswap counter snapshots covering the
+// last span, oldest first.
+func (api *StatsAPI) BitswapHistory(ctx context.Context, span time.Duration) ([]coreiface.BitswapStatSnapshot, error) {
+	bs, ok := api.exchange.(*bitswap.Bitswap)
+	if !ok {
+		return nil, coreiface.ErrOffline
+	}
+
+	hist := bs.StatHistory(span)
+	out := make([]coreiface.BitswapStatSnapshot, len(hist))
+	for i, s := range hist {
+		out[i] = coreiface.BitswapStatSnapshot{
+			Timestamp:      s.Timestamp,
+			BlocksSent:     s.BlocksSent,
+			DataSent:       s.DataSent,
+			BlocksReceived: s.BlocksReceived,
+			DataReceived:   s.DataReceived,
+			WantlistSize:   s.WantlistSize,
+			Peers:          s.Peers,
+		}
+	}
+	return out, nil
+}
+
+// Node returns a node-wide statistics snapshot. Counting repo objects walks
+// all blockstore keys, which can take a moment on large repos.
+func (api *StatsAPI) Node(ctx context.Context) (coreiface.NodeStats, error) {
+	st := coreiface.NodeStats{
+		PeerID:       api.identity.Pretty(),
+		AgentVersion: identify.ClientVersion,
+	}
+
+	if api.peerHost != nil {
+		for _, a := range api.peerHost.Addrs() {
+			st.Addresses = append(st.Addresses, a.String())
+		}
+	}
+	if api.peerstore != nil {
+		st.NumPeers = len(api.peerstore.Peers())
+	}
+
+	usage, err := api.repo.GetStorageUsage()
+	if err != nil {
+		return coreiface.NodeStats{}, err
+	}
+	st.RepoSize = usage
+
+	keys, err := api.blockstore.AllKeysChan(ctx)
+	if err != nil {
+		return coreiface.NodeStats{}, err
+	}
+	for range keys {
+		st.RepoNumObjects++
+	}
+	if err := ctx.Err(); err != nil {
+		return coreiface.NodeStats{}, err
+	}
+
+	if bst, err := api.Bitswap(ctx); err == nil {
+		st.BitswapStats = bst
+	}
+
+	if api.reporter != nil {
+		totals := api.reporter.GetBandwidthTotals()
+		st.BandwidthIn = uint64(totals.TotalIn)
+		st.BandwidthOut = uint64(totals.TotalOut)
+	}
+
+	return st, nil
+}
+
+// ResetBitswap zeroes the bitswap counters.
+func (api *StatsAPI) ResetBitswap(ctx context.Context) error {
+	bs, ok := api.exchange.(*bitswap.Bitswap)
+	if !ok {
+		return coreiface.ErrOffline
+	}
+
+	bs.ResetStats()
+	return nil
+}
@@ -0,0 +1,119 @@
+package coreapi_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ipsn/go-ipfs/core/coreapi/interface/options"
+
+	files "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-files"
+)
+
+func TestBitswapStats(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, apis, err := makeAPISwarm(ctx, true, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([]byte, 4096)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	p, err := apis[0].Unixfs().Add(ctx, files.NewBytesFile(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := apis[1].Unixfs().Get(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(ioutil.Discard, f.(files.File)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the receiver saw the payload, within block framing overhead
+	recv, err := apis[1].Stats().Bitswap(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recv.BlocksReceived == 0 {
+		t.Fatal("expected at least one received block to be counted")
+	}
+	if recv.DataReceived < uint64(len(data)) || recv.DataReceived > uint64(len(data))+16*1024 {
+		t.Fatalf("unexpected DataReceived: %d", recv.DataReceived)
+	}
+
+	// the sender's counters mirror it
+	sent, err := apis[0].Stats().Bitswap(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent.BlocksSent != recv.BlocksReceived || sent.DataSent != recv.DataReceived {
+		t.Fatalf("sender counters don't mirror the receiver: %+v vs %+v", sent, recv)
+	}
+
+	// resetting zeroes the snapshot
+	if err := apis[1].Stats().ResetBitswap(ctx); err != nil {
+		t.Fatal(err)
+	}
+	recv, err = apis[1].Stats().Bitswap(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recv.BlocksReceived != 0 || recv.DataReceived != 0 {
+		t.Fatalf("expected counters to be zeroed, got %+v", recv)
+	}
+
+	// an offline api has no bitswap to report on
+	off, err := apis[0].WithOptions(options.Api.Offline(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := off.Stats().Bitswap(ctx); err == nil {
+		t.Fatal("expected an error from an offline api")
+	}
+}
+
+func TestNodeStats(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nds, apis, err := makeAPISwarm(ctx, true, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([]byte, 4096)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	if _, err := apis[0].Unixfs().Add(ctx, files.NewBytesFile(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := apis[0].Stats().Node(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if st.PeerID != nds[0].Identity.Pretty() {
+		t.Errorf("wrong PeerID: %s", st.PeerID)
+	}
+	if st.AgentVersion == "" {
+		t.Error("expected AgentVersion to be set")
+	}
+	if st.NumPeers == 0 {
+		t.Error("expected a connected swarm peer to be counted")
+	}
+	if st.RepoNumObjects == 0 {
+		t.Error("expected the added blocks to be counted")
+	}
+}
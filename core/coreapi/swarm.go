@@ -6,7 +6,9 @@ import (
 	"time"
 
 	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
+	caopts "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
 
+	metrics "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-metrics"
 	inet "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-net"
 	net "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-net"
 	swarm "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-swarm"
@@ -76,6 +78,27 @@ func (api *SwarmAPI) Disconnect(ctx context.Context, addr ma.Multiaddr) error {
 	return nil
 }
 
+// Protect tags a peer as important in the connection manager, so its
+// connections are not closed under resource pressure.
+func (api *SwarmAPI) Protect(ctx context.Context, p peer.ID, tag string) error {
+	if api.peerHost == nil {
+		return coreiface.ErrOffline
+	}
+
+	api.peerHost.ConnManager().Protect(p, tag)
+	return nil
+}
+
+// Unprotect drops the protection tag again, returning whether the peer
+// remains protected under other tags.
+func (api *SwarmAPI) Unprotect(ctx context.Context, p peer.ID, tag string) (bool, error) {
+	if api.peerHost == nil {
+		return false, coreiface.ErrOffline
+	}
+
+	return api.peerHost.ConnManager().Unprotect(p, tag), nil
+}
+
 func (api *SwarmAPI) KnownAddrs(context.Context) (map[peer.ID][]ma.Multiaddr, error) {
 	if api.peerHost == nil {
 		return nil, coreiface.ErrOffline
@@ -146,6 +169,80 @@ func (api *SwarmAPI) Peers(context.Context) ([]coreiface.ConnectionInfo, error)
 	return out, nil
 }
 
+// Bandwidth reports the traffic exchanged with each connected peer. With the
+// Peer option only that peer is reported, with the Proto option a single
+// entry covering the given protocol is emitted instead, and with the Poll
+// option snapshots keep coming at the given interval until ctx is cancelled.
+func (api *SwarmAPI) Bandwidth(ctx context.Context, opts ...caopts.SwarmBandwidthOption) (<-chan coreiface.SwarmBandwidthStat, error) {
+	settings, err := caopts.SwarmBandwidthOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if api.reporter == nil || api.peerHost == nil {
+		return nil, coreiface.ErrOffline
+	}
+
+	var pid peer.ID
+	if settings.Peer != "" {
+		pid, err = peer.IDB58Decode(settings.Peer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	toStat := func(p peer.ID, s metrics.Stats) coreiface.SwarmBandwidthStat {
+		return coreiface.SwarmBandwidthStat{
+			Peer:    p,
+			In:      uint64(s.TotalIn),
+			Out:     uint64(s.TotalOut),
+			RateIn:  s.RateIn,
+			RateOut: s.RateOut,
+		}
+	}
+
+	out := make(chan coreiface.SwarmBandwidthStat)
+	go func() {
+		defer close(out)
+
+		for {
+			var snapshot []coreiface.SwarmBandwidthStat
+			switch {
+			case settings.Proto != "":
+				snapshot = append(snapshot, toStat("", api.reporter.GetBandwidthForProtocol(protocol.ID(settings.Proto))))
+			case pid != "":
+				snapshot = append(snapshot, toStat(pid, api.reporter.GetBandwidthForPeer(pid)))
+			default:
+				peers := api.peerHost.Network().Peers()
+				sort.Slice(peers, func(i, j int) bool { return peers[i] < peers[j] })
+				for _, p := range peers {
+					snapshot = append(snapshot, toStat(p, api.reporter.GetBandwidthForPeer(p)))
+				}
+			}
+
+			for _, s := range snapshot {
+				select {
+				case out <- s:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if settings.Poll <= 0 {
+				return
+			}
+
+			select {
+			case <-time.After(settings.Poll):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
 func (ci *connInfo) ID() peer.ID {
 	return ci.peer
 }
@@ -0,0 +1,115 @@
+package coreapi
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	coreiface "github.com/ipsn/go-ipfs/core/coreapi/interface"
+	caopts "github.com/ipsn/go-ipfs/core/coreapi/interface/options"
+
+	blocks "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-block-format"
+	metrics "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-metrics"
+	inet "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-net"
+	protocol "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-protocol"
+	mocknet "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p/p2p/net/mock"
+)
+
+func TestSwarmBandwidth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mn, err := mocknet.FullMeshConnected(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h1, h2 := mn.Hosts()[0], mn.Hosts()[1]
+
+	rep := metrics.NewBandwidthCounter()
+
+	// ship a block from h2 to h1 over a stream, metering it on h1's
+	// reporter the way the network stack would
+	block := blocks.NewBlock([]byte("some bytes to account for"))
+	proto := protocol.ID("/test/block")
+
+	done := make(chan error, 1)
+	h1.SetStreamHandler(proto, func(s inet.Stream) {
+		defer s.Close()
+		buf, err := ioutil.ReadAll(s)
+		if err != nil {
+			done <- err
+			return
+		}
+		rep.LogRecvMessageStream(int64(len(buf)), proto, s.Conn().RemotePeer())
+		done <- nil
+	})
+
+	s, err := h2.NewStream(ctx, h1.ID(), proto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Write(block.RawData()); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	api := &SwarmAPI{peerHost: h1, reporter: rep}
+
+	// the peer we got the block from shows up with nonzero traffic; the
+	// flow meters behind the reporter tally totals on a periodic sweep,
+	// so poll until the transfer is accounted
+	var stats []coreiface.SwarmBandwidthStat
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		ch, err := api.Bandwidth(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats = stats[:0]
+		for st := range ch {
+			stats = append(stats, st)
+		}
+		if len(stats) == 1 && stats[0].In > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("transfer never accounted, got %+v", stats)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if stats[0].Peer != h2.ID() {
+		t.Fatalf("unexpected peer %s", stats[0].Peer)
+	}
+	if stats[0].In != uint64(len(block.RawData())) {
+		t.Fatalf("expected %d bytes in, got %d", len(block.RawData()), stats[0].In)
+	}
+
+	// filtering on the peer yields the same numbers
+	ch, err := api.Bandwidth(ctx, caopts.Swarm.Peer(h2.ID().Pretty()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, ok := <-ch
+	if !ok || st.In != uint64(len(block.RawData())) {
+		t.Fatalf("unexpected filtered stat: %+v", st)
+	}
+
+	// filtering on the protocol reports an aggregate entry
+	ch, err = api.Bandwidth(ctx, caopts.Swarm.Proto(string(proto)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, ok = <-ch
+	if !ok || st.Peer != "" || st.In != uint64(len(block.RawData())) {
+		t.Fatalf("unexpected protocol stat: %+v", st)
+	}
+
+	// without a reporter the api reports offline
+	offline := &SwarmAPI{peerHost: h1}
+	if _, err := offline.Bandwidth(ctx); err != coreiface.ErrOffline {
+		t.Fatalf("expected ErrOffline, got %s", err)
+	}
+}
@@ -1,8 +1,14 @@
 package coreapi
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
 
 	"github.com/ipsn/go-ipfs/core"
 	"github.com/ipsn/go-ipfs/filestore"
@@ -12,7 +18,9 @@ import (
 	"github.com/ipsn/go-ipfs/core/coreunix"
 
 	blockservice "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-blockservice"
+	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
 	bstore "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-blockstore"
+	offlinexch "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-exchange-offline"
 	mfs "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-mfs"
 	files "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-files"
 	cidutil "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cidutil"
@@ -33,6 +41,12 @@ func (api *UnixfsAPI) Add(ctx context.Context, files files.Node, opts ...options
 		return nil, err
 	}
 
+	// the API owns the events channel: consumers range over it until it is
+	// closed here, whether the add finished or failed
+	if settings.Events != nil {
+		defer close(settings.Events)
+	}
+
 	cfg, err := api.repo.Config()
 	if err != nil {
 		return nil, err
@@ -72,7 +86,15 @@ func (api *UnixfsAPI) Add(ctx context.Context, files files.Node, opts ...options
 	}
 
 	bserv := blockservice.New(addblockstore, exch) // hash security 001
-	dserv := dag.NewDAGService(bserv)
+	var dserv ipld.DAGService = dag.NewDAGService(bserv)
+
+	if settings.DedupStats != nil {
+		dserv = &dedupDAGService{
+			DAGService: dserv,
+			bs:         addblockstore,
+			stats:      settings.DedupStats,
+		}
+	}
 
 	fileAdder, err := coreunix.NewAdder(ctx, pinning, addblockstore, dserv)
 	if err != nil {
@@ -129,7 +151,357 @@ func (api *UnixfsAPI) Add(ctx context.Context, files files.Node, opts ...options
 	return coreiface.IpfsPath(nd.Cid()), nil
 }
 
-func (api *UnixfsAPI) Get(ctx context.Context, p coreiface.Path) (files.Node, error) {
+// dedupDAGService drops writes of nodes whose block is already in the
+// blockstore, tallying reused against written blocks. Hashing still happens
+// for every byte — nodes are fully built before they reach Add — but
+// re-adding mostly unchanged content skips the duplicate datastore writes
+// and the provides they would trigger.
+type dedupDAGService struct {
+	ipld.DAGService
+
+	bs    bstore.Blockstore
+	stats *options.AddDedupStats
+}
+
+func (ds *dedupDAGService) Add(ctx context.Context, nd ipld.Node) error {
+	has, err := ds.bs.Has(nd.Cid())
+	if err != nil {
+		return err
+	}
+	if has {
+		ds.stats.Reused++
+		return nil
+	}
+	ds.stats.Written++
+	return ds.DAGService.Add(ctx, nd)
+}
+
+func (ds *dedupDAGService) AddMany(ctx context.Context, nds []ipld.Node) error {
+	for _, nd := range nds {
+		if err := ds.Add(ctx, nd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addCheckpoint is the state a ResumableAdd leaves behind so an interrupted
+// import can be picked up where it stopped.
+type addCheckpoint struct {
+	// Chunker and RawLeaves guard against resuming with different
+	// settings, which would produce different leaves.
+	Chunker   string
+	RawLeaves bool
+
+	// Offset is the number of file bytes covered by Leaves.
+	Offset int64
+
+	// Leaves holds the cids of the file chunks committed so far, in file
+	// order; the last entry is the most recently added leaf.
+	Leaves []cid.Cid
+}
+
+// loadAddCheckpoint reads the checkpoint left behind by an earlier run, or
+// returns a fresh one when the file does not exist yet.
+func loadAddCheckpoint(path string) (*addCheckpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &addCheckpoint{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cp := new(addCheckpoint)
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("malformed checkpoint file %s: %s", path, err)
+	}
+	return cp, nil
+}
+
+// writeAddCheckpoint persists the checkpoint atomically so a crash while
+// writing never destroys the previous one.
+func writeAddCheckpoint(path string, cp *addCheckpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// leafData returns the file bytes carried by nd if it is a file leaf.
+// Internal nodes, directories and metadata nodes report false.
+func leafData(nd ipld.Node) ([]byte, bool) {
+	switch nd := nd.(type) {
+	case *dag.RawNode:
+		return nd.RawData(), true
+	case *dag.ProtoNode:
+		if len(nd.Links()) > 0 {
+			return nil, false
+		}
+		fsn, err := ft.FSNodeFromBytes(nd.Data())
+		if err != nil || (fsn.Type() != ft.TFile && fsn.Type() != ft.TRaw) {
+			return nil, false
+		}
+		return fsn.Data(), true
+	}
+	return nil, false
+}
+
+// checkpointDAGService persists the checkpoint after every file leaf it
+// commits, so an interrupted ResumableAdd can pick up from the last complete
+// chunk. Internal nodes pass through unrecorded.
+type checkpointDAGService struct {
+	ipld.DAGService
+
+	path string
+	cp   *addCheckpoint
+}
+
+func (ds *checkpointDAGService) Add(ctx context.Context, nd ipld.Node) error {
+	if err := ds.DAGService.Add(ctx, nd); err != nil {
+		return err
+	}
+	data, ok := leafData(nd)
+	if !ok {
+		return nil
+	}
+	ds.cp.Offset += int64(len(data))
+	ds.cp.Leaves = append(ds.cp.Leaves, nd.Cid())
+	return writeAddCheckpoint(ds.path, ds.cp)
+}
+
+func (ds *checkpointDAGService) AddMany(ctx context.Context, nds []ipld.Node) error {
+	for _, nd := range nds {
+		if err := ds.Add(ctx, nd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// leafReplayReader streams back the file bytes of already-stored leaves,
+// fetching each node from the dag service only when the read reaches it.
+type leafReplayReader struct {
+	ctx    context.Context
+	ds     ipld.NodeGetter
+	leaves []cid.Cid
+	cur    io.Reader
+}
+
+func (r *leafReplayReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur != nil {
+			n, err := r.cur.Read(p)
+			if err == io.EOF {
+				r.cur = nil
+				if n == 0 {
+					continue
+				}
+				err = nil
+			}
+			return n, err
+		}
+		if len(r.leaves) == 0 {
+			return 0, io.EOF
+		}
+		nd, err := r.ds.Get(r.ctx, r.leaves[0])
+		if err != nil {
+			return 0, err
+		}
+		data, ok := leafData(nd)
+		if !ok {
+			return 0, fmt.Errorf("checkpoint leaf %s is not a file chunk", r.leaves[0])
+		}
+		r.leaves = r.leaves[1:]
+		r.cur = bytes.NewReader(data)
+	}
+}
+
+// ResumableAdd imports a single file from the reader like Add, persisting an
+// import checkpoint on every chunk boundary. When the checkpoint file already
+// exists the import resumes instead of starting over: the chunks recorded in
+// it are replayed from the local blockstore and the reader is seeked past the
+// bytes they cover, so source data already committed is never read again. The
+// chunks run through the regular import pipeline either way, which keeps the
+// final root cid identical to what a fresh Add of the same content produces.
+//
+// The final event on the returned channel carries the root hash, or a non-nil
+// Err when the import failed and the checkpoint was kept for the next
+// attempt. The channel is closed when the import finishes either way.
+func (api *UnixfsAPI) ResumableAdd(ctx context.Context, r io.ReadSeeker, opts ...options.UnixfsAddOption) (<-chan coreiface.AddEvent, error) {
+	settings, prefix, err := options.UnixfsAddOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if settings.CheckpointPath == "" {
+		return nil, fmt.Errorf("resumable add requires the CheckpointPath option")
+	}
+	if settings.OnlyHash || settings.NoCopy {
+		return nil, fmt.Errorf("resumable add is not supported with only-hash or nocopy")
+	}
+
+	cp, err := loadAddCheckpoint(settings.CheckpointPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(cp.Leaves) > 0 && (cp.Chunker != settings.Chunker || cp.RawLeaves != settings.RawLeaves) {
+		return nil, fmt.Errorf("checkpoint %s was written with different chunking settings", settings.CheckpointPath)
+	}
+
+	addblockstore := api.blockstore
+	if !settings.FsCache {
+		addblockstore = bstore.NewGCBlockstore(api.baseBlocks, api.blockstore)
+	}
+	bserv := blockservice.New(addblockstore, api.exchange) // hash security 001
+
+	// replayed chunks must come from blocks we already hold; a missing one
+	// means the checkpoint is stale and the add should fail rather than
+	// stall on the network
+	localDserv := dag.NewDAGService(blockservice.New(addblockstore, offlinexch.Exchange(addblockstore)))
+
+	reader := io.Reader(r)
+	if len(cp.Leaves) > 0 {
+		// fail fast when the data behind the checkpoint is gone; the
+		// remaining leaves are loaded lazily as the replay reaches them
+		if _, err := localDserv.Get(ctx, cp.Leaves[len(cp.Leaves)-1]); err != nil {
+			return nil, fmt.Errorf("checkpoint refers to data missing from the local store: %s", err)
+		}
+		reader = io.MultiReader(&leafReplayReader{ctx: ctx, ds: localDserv, leaves: cp.Leaves}, r)
+	}
+	if _, err := r.Seek(cp.Offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	// the checkpoint is rebuilt from scratch as the pipeline commits
+	// leaves: replayed chunks produce the same cids and offsets again, so
+	// a second interruption leaves it just as consistent
+	cds := &checkpointDAGService{
+		DAGService: dag.NewDAGService(bserv),
+		path:       settings.CheckpointPath,
+		cp:         &addCheckpoint{Chunker: settings.Chunker, RawLeaves: settings.RawLeaves},
+	}
+
+	fileAdder, err := coreunix.NewAdder(ctx, api.pinning, addblockstore, cds)
+	if err != nil {
+		return nil, err
+	}
+
+	fileAdder.Chunker = settings.Chunker
+	fileAdder.Pin = settings.Pin
+	fileAdder.Silent = true
+	fileAdder.RawLeaves = settings.RawLeaves
+	fileAdder.Name = settings.StdinName
+	fileAdder.CidBuilder = prefix
+
+	switch settings.Layout {
+	case options.BalancedLayout:
+		// Default
+	case options.TrickleLayout:
+		fileAdder.Trickle = true
+	default:
+		return nil, fmt.Errorf("unknown layout: %d", settings.Layout)
+	}
+
+	out := make(chan coreiface.AddEvent, 1)
+	go func() {
+		defer close(out)
+
+		nd, err := fileAdder.AddAllAndPin(files.NewReaderFile(reader))
+		if err != nil {
+			// keep the checkpoint around, it records how far this
+			// attempt got
+			select {
+			case out <- coreiface.AddEvent{Err: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
+
+		// complete: the next call with this path starts from scratch
+		os.Remove(settings.CheckpointPath)
+
+		c := nd.Cid()
+		select {
+		case out <- coreiface.AddEvent{Name: c.String(), Hash: c.String(), Bytes: cds.cp.Offset}:
+		case <-ctx.Done():
+		}
+	}()
+
+	return out, nil
+}
+
+// AddToDirectory imports the data from the reader, links it into the
+// directory referenced by dir under the given name, and returns the new
+// directory root. The imported file inherits the directory's cid prefix
+// unless overridden by the passed options. Sharded (HAMT) directories are
+// handled transparently.
+func (api *UnixfsAPI) AddToDirectory(ctx context.Context, dir coreiface.Path, name string, data io.Reader, opts ...options.UnixfsAddOption) (coreiface.ResolvedPath, error) {
+	if strings.Contains(name, "/") {
+		return nil, fmt.Errorf("entry name can't contain '/': %q", name)
+	}
+
+	dirNd, err := api.core().ResolveNode(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	dirPb, ok := dirNd.(*dag.ProtoNode)
+	if !ok {
+		return nil, dag.ErrNotProtobuf
+	}
+
+	prefix := dirPb.Cid().Prefix()
+	dopts := []options.UnixfsAddOption{
+		options.Unixfs.CidVersion(int(prefix.Version)),
+		options.Unixfs.Hash(prefix.MhType),
+	}
+	if prefix.Version > 0 {
+		dopts = append(dopts, options.Unixfs.RawLeaves(true))
+	}
+	dopts = append(dopts, opts...)
+
+	fp, err := api.Add(ctx, files.NewReaderFile(data), dopts...)
+	if err != nil {
+		return nil, err
+	}
+
+	fnd, err := api.core().ResolveNode(ctx, fp)
+	if err != nil {
+		return nil, err
+	}
+
+	ud, err := uio.NewDirectoryFromNode(api.dag, dirPb)
+	if err != nil {
+		return nil, err
+	}
+	ud.SetCidBuilder(dirPb.CidBuilder())
+
+	if err := ud.AddChild(ctx, name, fnd); err != nil {
+		return nil, err
+	}
+
+	nnd, err := ud.GetNode()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := api.dag.Add(ctx, nnd); err != nil {
+		return nil, err
+	}
+
+	return coreiface.IpfsPath(nnd.Cid()), nil
+}
+
+func (api *UnixfsAPI) Get(ctx context.Context, p coreiface.Path, opts ...options.UnixfsGetOption) (files.Node, error) {
+	settings, err := options.UnixfsGetOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
 	ses := api.core().getSession(ctx)
 
 	nd, err := ses.ResolveNode(ctx, p)
@@ -137,7 +509,244 @@ func (api *UnixfsAPI) Get(ctx context.Context, p coreiface.Path) (files.Node, er
 		return nil, err
 	}
 
-	return newUnixfsFile(ctx, ses.dag, nd)
+	fn, err := newUnixfsFile(ctx, ses.dag, nd)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.Progress != nil {
+		if f, ok := fn.(files.File); ok {
+			total, err := f.Size()
+			if err != nil {
+				total = -1
+			}
+			return &progressFile{File: f, total: total, cb: settings.Progress}, nil
+		}
+	}
+
+	return fn, nil
+}
+
+// GetFile is like Get but only succeeds when the path references a regular
+// file, so callers don't have to type-assert the returned node.
+func (api *UnixfsAPI) GetFile(ctx context.Context, p coreiface.Path, opts ...options.UnixfsGetOption) (files.File, error) {
+	nd, err := api.Get(ctx, p, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := nd.(*files.Symlink); ok {
+		return nil, coreiface.ErrNotFile
+	}
+
+	f, ok := nd.(files.File)
+	if !ok {
+		return nil, coreiface.ErrNotFile
+	}
+	return f, nil
+}
+
+// GetRange returns a reader over the span of the file starting at offset,
+// limited to length bytes. A negative length, or one reaching past the end of
+// the file, is clamped to the remainder. Only the blocks covering the span
+// are fetched: the seek walks down the DAG using the chunk index, so a small
+// range of a large file doesn't pull the whole thing.
+func (api *UnixfsAPI) GetRange(ctx context.Context, p coreiface.Path, offset, length int64, opts ...options.UnixfsGetOption) (files.File, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("cannot have negative read offset")
+	}
+
+	f, err := api.GetFile(ctx, p, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := f.Size()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if offset > size {
+		f.Close()
+		return nil, fmt.Errorf("offset %d is past the end of the %d byte file", offset, size)
+	}
+
+	if length < 0 || offset+length > size {
+		length = size - offset
+	}
+
+	if offset > 0 {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	return &rangeFile{file: f, start: offset, length: length}, nil
+}
+
+// rangeFile restricts a unixfs file to a byte span, presenting it as a
+// self-contained file: offsets are relative to the span start and Size
+// reports the span length.
+type rangeFile struct {
+	file files.File
+
+	start  int64
+	length int64
+	pos    int64
+}
+
+func (rf *rangeFile) Read(p []byte) (int, error) {
+	if rf.pos >= rf.length {
+		return 0, io.EOF
+	}
+	if max := rf.length - rf.pos; int64(len(p)) > max {
+		p = p[:max]
+	}
+	n, err := rf.file.Read(p)
+	rf.pos += int64(n)
+	return n, err
+}
+
+func (rf *rangeFile) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = rf.pos + offset
+	case io.SeekEnd:
+		pos = rf.length + offset
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	if pos < 0 {
+		return 0, fmt.Errorf("cannot seek before start of range")
+	}
+
+	if _, err := rf.file.Seek(rf.start+pos, io.SeekStart); err != nil {
+		return 0, err
+	}
+	rf.pos = pos
+	return pos, nil
+}
+
+func (rf *rangeFile) Size() (int64, error) {
+	return rf.length, nil
+}
+
+func (rf *rangeFile) Close() error {
+	return rf.file.Close()
+}
+
+// GetDirectory is like Get but only succeeds when the path references a
+// directory.
+func (api *UnixfsAPI) GetDirectory(ctx context.Context, p coreiface.Path, opts ...options.UnixfsGetOption) (files.Directory, error) {
+	nd, err := api.Get(ctx, p, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	d, ok := nd.(files.Directory)
+	if !ok {
+		return nil, coreiface.ErrNotDirectory
+	}
+	return d, nil
+}
+
+// GetType reports the unixfs type of the node the path references, reading
+// only its root node, not the content.
+func (api *UnixfsAPI) GetType(ctx context.Context, p coreiface.Path) (coreiface.FileType, error) {
+	nd, err := api.core().ResolveNode(ctx, p)
+	if err != nil {
+		return coreiface.TUnknown, err
+	}
+
+	switch nd := nd.(type) {
+	case *dag.RawNode:
+		return coreiface.TFile, nil
+	case *dag.ProtoNode:
+		d, err := ft.FSNodeFromBytes(nd.Data())
+		if err != nil {
+			return coreiface.TUnknown, err
+		}
+
+		switch d.Type() {
+		case ft.TFile, ft.TRaw:
+			return coreiface.TFile, nil
+		case ft.TDirectory, ft.THAMTShard:
+			return coreiface.TDirectory, nil
+		case ft.TSymlink:
+			return coreiface.TSymlink, nil
+		}
+	}
+	return coreiface.TUnknown, nil
+}
+
+// Chmod stores the given unix permission bits in the unixfs metadata of the
+// referenced node and returns the path of the rebuilt node. Only the node
+// itself is rewritten; content blocks keep their cids.
+func (api *UnixfsAPI) Chmod(ctx context.Context, p coreiface.Path, mode os.FileMode) (coreiface.ResolvedPath, error) {
+	nd, err := api.core().ResolveNode(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	pbnd, ok := nd.(*dag.ProtoNode)
+	if !ok {
+		return nil, dag.ErrNotProtobuf
+	}
+
+	fsn, err := ft.FSNodeFromBytes(pbnd.Data())
+	if err != nil {
+		return nil, err
+	}
+
+	fsn.SetMode(mode)
+
+	data, err := fsn.GetBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	newNd := pbnd.Copy().(*dag.ProtoNode)
+	newNd.SetData(data)
+
+	if err := api.dag.Add(ctx, newNd); err != nil {
+		return nil, err
+	}
+
+	return coreiface.IpfsPath(newNd.Cid()), nil
+}
+
+// progressFile wraps a unixfs file so consuming the reader reports progress
+// through a callback, for UIs tracking large downloads.
+type progressFile struct {
+	files.File
+
+	read  int64
+	total int64
+	cb    func(read, total int64)
+}
+
+func (pf *progressFile) Read(p []byte) (int, error) {
+	n, err := pf.File.Read(p)
+	if n > 0 {
+		pf.read += int64(n)
+		pf.cb(pf.read, pf.total)
+	}
+	return n, err
+}
+
+// Seek keeps the reported byte count in sync when the caller skips around.
+func (pf *progressFile) Seek(offset int64, whence int) (int64, error) {
+	off, err := pf.File.Seek(offset, whence)
+	if err == nil {
+		pf.read = off
+	}
+	return off, err
 }
 
 // Ls returns the contents of an IPFS or IPNS object(s) at path p, with the format:
@@ -170,6 +779,97 @@ func (api *UnixfsAPI) Ls(ctx context.Context, p coreiface.Path) ([]*ipld.Link, e
 	return links, nil
 }
 
+// LsEntries streams the entries of the directory at p. Unless disabled with
+// options.Unixfs.ResolveChildren(false), each child node is fetched (through
+// the same session as the directory itself) to resolve its unixfs type and
+// file size.
+func (api *UnixfsAPI) LsEntries(ctx context.Context, p coreiface.Path, opts ...options.UnixfsLsOption) (<-chan coreiface.LsEntry, error) {
+	settings, err := options.UnixfsLsOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ses := api.core().getSession(ctx)
+
+	dagnode, err := ses.ResolveNode(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := uio.NewDirectoryFromNode(ses.dag, dagnode)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan coreiface.LsEntry)
+	go func() {
+		defer close(out)
+		for lr := range dir.EnumLinksAsync(ctx) {
+			var entry coreiface.LsEntry
+			if lr.Err != nil {
+				entry = coreiface.LsEntry{Err: lr.Err}
+			} else {
+				entry = api.lsEntry(ctx, ses.dag, lr.Link, settings.ResolveChildren)
+			}
+			select {
+			case out <- entry:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// lsEntry builds an LsEntry for a single directory link, fetching the child
+// node to fill in the unixfs type and size when resolve is set.
+func (api *UnixfsAPI) lsEntry(ctx context.Context, ds ipld.DAGService, lnk *ipld.Link, resolve bool) coreiface.LsEntry {
+	entry := coreiface.LsEntry{
+		Name: lnk.Name,
+		Cid:  lnk.Cid,
+		Size: lnk.Size,
+	}
+	if !resolve {
+		return entry
+	}
+
+	switch lnk.Cid.Type() {
+	case cid.Raw:
+		// raw leaves are files, the link size is the data size
+		entry.Type = coreiface.TFile
+	case cid.DagProtobuf:
+		nd, err := lnk.GetNode(ctx, ds)
+		if err != nil {
+			entry.Err = err
+			return entry
+		}
+
+		pbnd, ok := nd.(*dag.ProtoNode)
+		if !ok {
+			return entry
+		}
+
+		d, err := ft.FSNodeFromBytes(pbnd.Data())
+		if err != nil {
+			entry.Err = err
+			return entry
+		}
+
+		switch d.Type() {
+		case ft.TFile, ft.TRaw:
+			entry.Type = coreiface.TFile
+			entry.Size = d.FileSize()
+		case ft.TDirectory, ft.THAMTShard:
+			entry.Type = coreiface.TDirectory
+		case ft.TSymlink:
+			entry.Type = coreiface.TSymlink
+			entry.Size = uint64(len(d.Data()))
+		}
+	}
+	return entry
+}
+
 func (api *UnixfsAPI) core() *CoreAPI {
 	return (*CoreAPI)(api)
 }
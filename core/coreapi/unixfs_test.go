@@ -4,15 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"math"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/ipsn/go-ipfs/core"
 	"github.com/ipsn/go-ipfs/core/coreapi"
@@ -30,6 +33,7 @@ import (
 	peer "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peer"
 	pstore "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peerstore"
 	unixfs "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs"
+	uio "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs/io"
 	config "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-config"
 	mdag "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-merkledag"
 	mh "github.com/ipsn/go-ipfs/gxlibs/github.com/multiformats/go-multihash"
@@ -521,7 +525,6 @@ func TestAdd(t *testing.T) {
 			// Add!
 
 			p, err := tapi.Unixfs().Add(ctx, data, opts...)
-			close(eventOut)
 			evtWg.Wait()
 			if testCase.err != "" {
 				if err == nil {
@@ -616,6 +619,107 @@ func TestAdd(t *testing.T) {
 	}
 }
 
+func TestAddDirProgressEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sizes := map[string]int64{"a": 1000, "b": 2000, "c": 3000}
+	ents := make(map[string]files.Node, len(sizes))
+	for name, size := range sizes {
+		ents[name] = files.NewReaderFile(strings.NewReader(strings.Repeat(name, int(size))))
+	}
+	data := files.NewMapDirectory(map[string]files.Node{
+		"t": files.NewMapDirectory(ents),
+	})
+
+	eventOut := make(chan interface{})
+	var evtWg sync.WaitGroup
+	evtWg.Add(1)
+
+	// progress events carry no hash, just the path and a running byte
+	// count; keep the highest count seen per path
+	bytesSeen := make(map[string]int64)
+	var final *coreiface.AddEvent
+	go func() {
+		defer evtWg.Done()
+		for evt := range eventOut {
+			event, ok := evt.(*coreiface.AddEvent)
+			if !ok {
+				t.Error("unexpected event type")
+				continue
+			}
+			if event.Hash == "" {
+				if event.Bytes > bytesSeen[event.Name] {
+					bytesSeen[event.Name] = event.Bytes
+				}
+				continue
+			}
+			final = event
+		}
+	}()
+
+	p, err := api.Unixfs().Add(ctx, data,
+		options.Unixfs.Events(eventOut),
+		options.Unixfs.Progress(true),
+	)
+	evtWg.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bytesSeen) != len(sizes) {
+		t.Fatalf("expected progress events for %d files, got %d", len(sizes), len(bytesSeen))
+	}
+	for name, size := range sizes {
+		if bytesSeen["t/"+name] != size {
+			t.Errorf("expected %d bytes reported for %s, got %d", size, name, bytesSeen["t/"+name])
+		}
+	}
+
+	if final == nil {
+		t.Fatal("no summary event emitted")
+	}
+	if final.Hash != p.Cid().String() {
+		t.Errorf("summary event hash %s doesn't match added path %s", final.Hash, p)
+	}
+}
+
+func TestAddEventsStalledConsumer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nobody ever reads from the channel; cancelling the context must
+	// unwedge the add
+	eventOut := make(chan interface{})
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err = api.Unixfs().Add(ctx, files.NewBytesFile([]byte("stalled")),
+		options.Unixfs.Events(eventOut),
+		options.Unixfs.Progress(true),
+	)
+	if err == nil {
+		t.Fatal("expected the add to fail once the context was cancelled")
+	}
+
+	// the API still closed the channel on the error path
+	if _, ok := <-eventOut; ok {
+		t.Fatal("expected the events channel to be closed")
+	}
+}
+
 func TestAddPinned(t *testing.T) {
 	ctx := context.Background()
 	_, api, err := makeAPI(ctx)
@@ -663,6 +767,66 @@ func TestAddHashOnly(t *testing.T) {
 	}
 }
 
+func TestAddDedup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, api, cds, err := makeCountingAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 251 is prime, so every 4096-byte chunk gets distinct content
+	data := make([]byte, 64*1024)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	stats := &options.AddDedupStats{}
+	p1, err := api.Unixfs().Add(ctx, files.NewBytesFile(data),
+		options.Unixfs.Chunker("size-4096"),
+		options.Unixfs.Dedup(stats))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Written < 17 {
+		t.Errorf("expected the first add to write all 16 leaves plus the roots, wrote %d", stats.Written)
+	}
+	// mfs flushing re-adds a handful of structural nodes within the run,
+	// those count as reused even on a fresh add
+	if stats.Reused >= stats.Written {
+		t.Errorf("expected a fresh add to mostly write, wrote %d, reused %d", stats.Written, stats.Reused)
+	}
+
+	writes := cds.writes()
+	if writes == 0 {
+		t.Fatal("expected the first add to hit the datastore")
+	}
+
+	stats2 := &options.AddDedupStats{}
+	p2, err := api.Unixfs().Add(ctx, files.NewBytesFile(data),
+		options.Unixfs.Chunker("size-4096"),
+		options.Unixfs.Dedup(stats2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p2.Cid() != p1.Cid() {
+		t.Fatalf("re-add produced a different cid: %s != %s", p2.Cid(), p1.Cid())
+	}
+
+	if got := cds.writes(); got != writes {
+		t.Errorf("expected zero new datastore writes on re-add, got %d", got-writes)
+	}
+	if stats2.Written != 0 {
+		t.Errorf("expected no written blocks on re-add, got %d", stats2.Written)
+	}
+	if stats2.Reused < stats.Written {
+		t.Errorf("expected all %d written blocks to be counted as reused, got %d", stats.Written, stats2.Reused)
+	}
+}
+
 func TestGetEmptyFile(t *testing.T) {
 	ctx := context.Background()
 	node, api, err := makeAPI(ctx)
@@ -695,6 +859,149 @@ func TestGetEmptyFile(t *testing.T) {
 	}
 }
 
+func TestGetRange(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := api.Unixfs().Add(ctx, files.NewBytesFile([]byte(helloStr)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readRange := func(offset, length int64) (string, int64) {
+		f, err := api.Unixfs().GetRange(ctx, p, offset, length)
+		if err != nil {
+			t.Fatalf("GetRange(%d, %d): %s", offset, length, err)
+		}
+		defer f.Close()
+
+		size, err := f.Size()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data), size
+	}
+
+	if got, size := readRange(7, 5); got != "world" || size != 5 {
+		t.Errorf("expected 'world'/5, got %q/%d", got, size)
+	}
+
+	// negative length reads to the end of the file
+	if got, size := readRange(7, -1); got != "world!" || size != 6 {
+		t.Errorf("expected 'world!'/6, got %q/%d", got, size)
+	}
+
+	// length past the end is clamped
+	if got, size := readRange(7, 100); got != "world!" || size != 6 {
+		t.Errorf("expected clamped 'world!'/6, got %q/%d", got, size)
+	}
+
+	// offset at the end of the file yields an empty range
+	if got, size := readRange(int64(len(helloStr)), -1); got != "" || size != 0 {
+		t.Errorf("expected empty range, got %q/%d", got, size)
+	}
+
+	if _, err := api.Unixfs().GetRange(ctx, p, int64(len(helloStr))+1, -1); err == nil {
+		t.Error("expected an error for an offset past the end of the file")
+	}
+
+	if _, err := api.Unixfs().GetRange(ctx, p, -1, 1); err == nil {
+		t.Error("expected an error for a negative offset")
+	}
+
+	// seeking is relative to the start of the range
+	f, err := api.Unixfs().GetRange(ctx, p, 7, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(1, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "orld" {
+		t.Errorf("expected 'orld' after in-range seek, got %q", string(data))
+	}
+}
+
+func TestGetRangeOnlyFetchesRange(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nds, apis, err := makeAPISwarm(ctx, true, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 40 chunks of 4 bytes each, small enough that every chunk is a
+	// distinct leaf block
+	data := make([]byte, 160)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	p, err := apis[0].Unixfs().Add(ctx, files.NewBytesFile(data), options.Unixfs.Chunker("size-4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := nds[0].DAG.Get(ctx, p.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := root.Links()
+	if len(leaves) != 40 {
+		t.Fatalf("expected 40 leaves, got %d", len(leaves))
+	}
+
+	// bytes 80..87 live in chunks 20 and 21
+	f, err := apis[1].Unixfs().GetRange(ctx, p, 80, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data[80:88]) {
+		t.Errorf("got wrong range content: %v", got)
+	}
+
+	for i, lnk := range leaves {
+		has, err := nds[1].Blockstore.Has(lnk.Cid)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		switch {
+		case i == 20 || i == 21:
+			if !has {
+				t.Errorf("expected in-range chunk %d to be fetched", i)
+			}
+		case i < 20 || i >= 35:
+			// chunks before the offset are skipped by the seek; chunks
+			// well past the range stay out of the read-ahead window
+			if has {
+				t.Errorf("chunk %d outside the range was fetched", i)
+			}
+		}
+	}
+}
+
 func TestGetDir(t *testing.T) {
 	ctx := context.Background()
 	node, api, err := makeAPI(ctx)
@@ -746,6 +1053,74 @@ func TestGetNonUnixfs(t *testing.T) {
 	}
 }
 
+func TestGetTyped(t *testing.T) {
+	ctx := context.Background()
+	node, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fp, err := api.Unixfs().Add(ctx, files.NewBytesFile([]byte("typed file")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edir := unixfs.EmptyDirNode()
+	if err := node.DAG.Add(ctx, edir); err != nil {
+		t.Fatal(err)
+	}
+	dp := coreiface.IpfsPath(edir.Cid())
+
+	symdata, err := unixfs.SymlinkData("target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	symnd := mdag.NodeWithData(symdata)
+	if err := node.DAG.Add(ctx, symnd); err != nil {
+		t.Fatal(err)
+	}
+	sp := coreiface.IpfsPath(symnd.Cid())
+
+	f, err := api.Unixfs().GetFile(ctx, fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(ioutil.Discard, f); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Unixfs().GetFile(ctx, dp); err != coreiface.ErrNotFile {
+		t.Errorf("expected ErrNotFile for a directory, got %s", err)
+	}
+	if _, err := api.Unixfs().GetFile(ctx, sp); err != coreiface.ErrNotFile {
+		t.Errorf("expected ErrNotFile for a symlink, got %s", err)
+	}
+
+	if _, err := api.Unixfs().GetDirectory(ctx, dp); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.Unixfs().GetDirectory(ctx, fp); err != coreiface.ErrNotDirectory {
+		t.Errorf("expected ErrNotDirectory for a file, got %s", err)
+	}
+
+	for _, tc := range []struct {
+		p        coreiface.Path
+		expected coreiface.FileType
+	}{
+		{fp, coreiface.TFile},
+		{dp, coreiface.TDirectory},
+		{sp, coreiface.TSymlink},
+	} {
+		typ, err := api.Unixfs().GetType(ctx, tc.p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if typ != tc.expected {
+			t.Errorf("expected type %d for %s, got %d", tc.expected, tc.p, typ)
+		}
+	}
+}
+
 func TestLs(t *testing.T) {
 	ctx := context.Background()
 	node, api, err := makeAPI(ctx)
@@ -786,6 +1161,148 @@ func TestLs(t *testing.T) {
 	}
 }
 
+func TestLsEntries(t *testing.T) {
+	ctx := context.Background()
+	node, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := strings.NewReader("content-of-file")
+	k, _, err := coreunix.AddWrapped(node, r, "name-of-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(k, "/")
+	if len(parts) != 2 {
+		t.Fatalf("unexpected path: %s", k)
+	}
+	p, err := coreiface.ParsePath("/ipfs/" + parts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := api.Unixfs().LsEntries(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, ok := <-entries
+	if !ok {
+		t.Fatal("expected an entry")
+	}
+	if entry.Err != nil {
+		t.Fatal(entry.Err)
+	}
+	if entry.Name != "name-of-file" {
+		t.Errorf("expected name = name-of-file, got %s", entry.Name)
+	}
+	if entry.Type != coreiface.TFile {
+		t.Errorf("expected a file entry, got type %d", entry.Type)
+	}
+	if entry.Size != 15 {
+		t.Errorf("expected the unixfs file size 15, got %d", entry.Size)
+	}
+	if _, ok := <-entries; ok {
+		t.Fatal("expected a single entry")
+	}
+
+	// without child resolving the type stays unknown and the size falls
+	// back to the link's dag size
+	entries, err = api.Unixfs().LsEntries(ctx, p, options.Unixfs.ResolveChildren(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, ok = <-entries
+	if !ok {
+		t.Fatal("expected an entry")
+	}
+	if entry.Err != nil {
+		t.Fatal(entry.Err)
+	}
+	if entry.Type != coreiface.TUnknown {
+		t.Errorf("expected an unresolved entry, got type %d", entry.Type)
+	}
+	if entry.Size != 23 {
+		t.Errorf("expected the link dag size 23, got %d", entry.Size)
+	}
+}
+
+func TestLsEntriesSharded(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uio.UseHAMTSharding = true
+	defer func() { uio.UseHAMTSharding = false }()
+
+	const nfiles = 50
+	dirents := make(map[string]files.Node, nfiles)
+	for i := 0; i < nfiles; i++ {
+		name := fmt.Sprintf("file-%d", i)
+		dirents[name] = files.NewBytesFile([]byte(name))
+	}
+
+	// wrap so the returned path is the directory itself, not its first entry
+	p, err := api.Unixfs().Add(ctx, files.NewMapDirectory(dirents), options.Unixfs.Wrap(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// make sure the fixture really is a HAMT shard
+	nd, err := api.ResolveNode(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pbnd, ok := nd.(*mdag.ProtoNode)
+	if !ok {
+		t.Fatal("expected a dag-pb root")
+	}
+	fsn, err := unixfs.FSNodeFromBytes(pbnd.Data())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fsn.Type() != unixfs.THAMTShard {
+		t.Fatalf("expected a sharded directory, got type %d", fsn.Type())
+	}
+
+	entries, err := api.Unixfs().LsEntries(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]uint64, nfiles)
+	for entry := range entries {
+		if entry.Err != nil {
+			t.Fatal(entry.Err)
+		}
+		if entry.Type != coreiface.TFile {
+			t.Errorf("expected a file entry for %s, got type %d", entry.Name, entry.Type)
+		}
+		if _, found := seen[entry.Name]; found {
+			t.Errorf("duplicate entry %s", entry.Name)
+		}
+		seen[entry.Name] = entry.Size
+	}
+
+	if len(seen) != nfiles {
+		t.Fatalf("expected %d entries, got %d", nfiles, len(seen))
+	}
+	for name := range dirents {
+		size, found := seen[name]
+		if !found {
+			t.Errorf("missing entry %s", name)
+			continue
+		}
+		if size != uint64(len(name)) {
+			t.Errorf("expected size %d for %s, got %d", len(name), name, size)
+		}
+	}
+}
+
 func TestEntriesExpired(t *testing.T) {
 	ctx := context.Background()
 	node, api, err := makeAPI(ctx)
@@ -957,3 +1474,351 @@ func TestAddCloses(t *testing.T) {
 	}
 
 }
+
+func TestAddToDirectory(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// wrap so the returned path is the directory itself, not its first entry
+	dir, err := api.Unixfs().Add(ctx, flatDir(), options.Unixfs.Wrap(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newDir, err := api.Unixfs().AddToDirectory(ctx, dir, "added", strings.NewReader("added data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	links, err := api.Unixfs().Ls(ctx, newDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := map[string]bool{}
+	for _, l := range links {
+		names[l.Name] = true
+	}
+	for _, name := range []string{"bar", "foo", "added"} {
+		if !names[name] {
+			t.Errorf("entry %q missing from new directory root", name)
+		}
+	}
+
+	addedPath, err := coreiface.ParsePath(newDir.String() + "/added")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := api.Unixfs().Get(ctx, addedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(f.(files.File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "added data" {
+		t.Errorf("got wrong data: %q", string(data))
+	}
+
+	if _, err := api.Unixfs().AddToDirectory(ctx, dir, "a/b", strings.NewReader("x")); err == nil {
+		t.Error("expected error for entry name containing '/'")
+	}
+}
+
+func TestAddToDirectoryPrefix(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := api.Unixfs().Add(ctx, flatDir(), options.Unixfs.Wrap(true), options.Unixfs.CidVersion(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newDir, err := api.Unixfs().AddToDirectory(ctx, dir, "added", strings.NewReader("added data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if newDir.Cid().Prefix().Version != 1 {
+		t.Errorf("new root didn't inherit cid version, got %d", newDir.Cid().Prefix().Version)
+	}
+
+	links, err := api.Unixfs().Ls(ctx, newDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range links {
+		if l.Name == "added" && l.Cid.Prefix().Version != 1 {
+			t.Errorf("added entry didn't inherit cid version, got %d", l.Cid.Prefix().Version)
+		}
+	}
+}
+
+func TestAddToDirectorySharded(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uio.UseHAMTSharding = true
+	defer func() { uio.UseHAMTSharding = false }()
+
+	dir, err := api.Unixfs().Add(ctx, flatDir(), options.Unixfs.Wrap(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newDir, err := api.Unixfs().AddToDirectory(ctx, dir, "added", strings.NewReader("added data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addedPath, err := coreiface.ParsePath(newDir.String() + "/added")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := api.Unixfs().Get(ctx, addedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(f.(files.File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "added data" {
+		t.Errorf("got wrong data: %q", string(data))
+	}
+}
+
+func TestGetReadProgress(t *testing.T) {
+	ctx := context.Background()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := strings.Repeat("progress data ", 1000)
+	p, err := api.Unixfs().Add(ctx, files.NewBytesFile([]byte(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		calls int
+		last  int64
+		total int64
+	)
+	f, err := api.Unixfs().Get(ctx, p, options.Unixfs.ReadProgress(func(read, size int64) {
+		if read <= last {
+			t.Errorf("expected strictly increasing byte counts, got %d after %d", read, last)
+		}
+		calls++
+		last = read
+		total = size
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := io.Copy(ioutil.Discard, f.(files.File))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls == 0 {
+		t.Fatal("expected the progress callback to fire")
+	}
+	if n != int64(len(data)) {
+		t.Fatalf("expected %d bytes, read %d", len(data), n)
+	}
+	if last != int64(len(data)) {
+		t.Fatalf("expected progress to end at %d, got %d", len(data), last)
+	}
+	if total != int64(len(data)) {
+		t.Fatalf("expected the total size %d to be reported, got %d", len(data), total)
+	}
+}
+
+func TestChmod(t *testing.T) {
+	ctx := context.Background()
+	node, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := api.Unixfs().Add(ctx, files.NewBytesFile([]byte(helloStr)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rp, err := api.Unixfs().Chmod(ctx, p, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rp.Cid().Equals(p.Cid()) {
+		t.Fatal("expected chmod to produce a new cid")
+	}
+
+	nd, err := node.DAG.Get(ctx, rp.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pbnd, ok := nd.(*mdag.ProtoNode)
+	if !ok {
+		t.Fatal("expected a protobuf node")
+	}
+	fsn, err := unixfs.FSNodeFromBytes(pbnd.Data())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fsn.Mode() != 0755 {
+		t.Errorf("expected mode 0755, got %o", fsn.Mode())
+	}
+
+	// content must be untouched
+	f, err := api.Unixfs().GetFile(ctx, rp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != helloStr {
+		t.Errorf("expected %q, got %q", helloStr, string(out))
+	}
+}
+
+var errInterrupted = errors.New("simulated transfer interruption")
+
+// interruptReader fails once limit bytes were read, standing in for a source
+// that dies mid-transfer.
+type interruptReader struct {
+	r     *bytes.Reader
+	limit int
+	read  int
+}
+
+func (r *interruptReader) Read(p []byte) (int, error) {
+	if r.read >= r.limit {
+		return 0, errInterrupted
+	}
+	if len(p) > r.limit-r.read {
+		p = p[:r.limit-r.read]
+	}
+	n, err := r.r.Read(p)
+	r.read += n
+	return n, err
+}
+
+func (r *interruptReader) Seek(offset int64, whence int) (int64, error) {
+	return r.r.Seek(offset, whence)
+}
+
+// seekRecorder remembers the last Seek target, so tests can tell whether a
+// resume skipped the checkpointed bytes.
+type seekRecorder struct {
+	*bytes.Reader
+	offset int64
+}
+
+func (r *seekRecorder) Seek(offset int64, whence int) (int64, error) {
+	r.offset = offset
+	return r.Reader.Seek(offset, whence)
+}
+
+func TestResumableAdd(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, api, err := makeAPI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([]byte, 16*1024)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	chunked := options.Unixfs.Chunker("size-1024")
+
+	// hash-only, so the blockstore holds nothing but what the resumable
+	// add below actually writes
+	fresh, err := api.Unixfs().Add(ctx, files.NewBytesFile(data), chunked, options.Unixfs.HashOnly(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "resumable-add")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cpPath := filepath.Join(dir, "add.checkpoint")
+
+	// first attempt dies halfway through the file
+	events, err := api.Unixfs().ResumableAdd(ctx,
+		&interruptReader{r: bytes.NewReader(data), limit: len(data) / 2},
+		chunked, options.Unixfs.CheckpointPath(cpPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last coreiface.AddEvent
+	for e := range events {
+		last = e
+	}
+	if last.Err == nil {
+		t.Fatal("expected the interrupted add to fail")
+	}
+	if _, err := os.Stat(cpPath); err != nil {
+		t.Fatal("expected a checkpoint file to be left behind:", err)
+	}
+
+	// the second attempt picks up from the checkpoint
+	resume := &seekRecorder{Reader: bytes.NewReader(data)}
+	events, err = api.Unixfs().ResumableAdd(ctx, resume,
+		chunked, options.Unixfs.CheckpointPath(cpPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for e := range events {
+		last = e
+	}
+	if last.Err != nil {
+		t.Fatal(last.Err)
+	}
+	if last.Hash != fresh.Cid().String() {
+		t.Fatalf("resumed root %s != fresh add root %s", last.Hash, fresh.Cid())
+	}
+	if last.Bytes != int64(len(data)) {
+		t.Fatalf("expected all %d bytes to be covered, got %d", len(data), last.Bytes)
+	}
+	if resume.offset == 0 {
+		t.Fatal("expected the resume to seek past the checkpointed bytes")
+	}
+	if _, err := os.Stat(cpPath); !os.IsNotExist(err) {
+		t.Fatal("expected the checkpoint file to be removed on success")
+	}
+
+	// the resumed import must have stored the file completely
+	f, err := api.Unixfs().GetFile(ctx, fresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Fatal("resumed file content differs from the original")
+	}
+}
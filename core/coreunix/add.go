@@ -272,7 +272,7 @@ func (adder *Adder) outputDirs(path string, fsn mfs.FSNode) error {
 			return err
 		}
 
-		return outputDagnode(adder.Out, path, nd)
+		return outputDagnode(adder.ctx, adder.Out, path, nd)
 	default:
 		return fmt.Errorf("unrecognized fsn type: %#v", fsn)
 	}
@@ -393,7 +393,7 @@ func (adder *Adder) addNode(node ipld.Node, path string) error {
 	}
 
 	if !adder.Silent {
-		return outputDagnode(adder.Out, path, node)
+		return outputDagnode(adder.ctx, adder.Out, path, node)
 	}
 	return nil
 }
@@ -497,7 +497,7 @@ func (adder *Adder) addFile(path string, file files.File) error {
 	// progress updates to the client (over the output channel)
 	var reader io.Reader = file
 	if adder.Progress {
-		rdr := &progressReader{file: reader, path: path, out: adder.Out}
+		rdr := &progressReader{ctx: adder.ctx, file: reader, path: path, out: adder.Out}
 		if fi, ok := file.(files.FileInfo); ok {
 			reader = &progressReader2{rdr, fi}
 		} else {
@@ -568,8 +568,9 @@ func (adder *Adder) maybePauseForGC() error {
 	return nil
 }
 
-// outputDagnode sends dagnode info over the output channel
-func outputDagnode(out chan<- interface{}, name string, dn ipld.Node) error {
+// outputDagnode sends dagnode info over the output channel. The send honors
+// the context so a stalled consumer can't wedge the add.
+func outputDagnode(ctx context.Context, out chan<- interface{}, name string, dn ipld.Node) error {
 	if out == nil {
 		return nil
 	}
@@ -579,10 +580,14 @@ func outputDagnode(out chan<- interface{}, name string, dn ipld.Node) error {
 		return err
 	}
 
-	out <- &coreiface.AddEvent{
+	select {
+	case out <- &coreiface.AddEvent{
 		Hash: o.Hash,
 		Name: name,
 		Size: o.Size,
+	}:
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 
 	return nil
@@ -613,6 +618,7 @@ func getOutput(dagnode ipld.Node) (*Object, error) {
 }
 
 type progressReader struct {
+	ctx          context.Context
 	file         io.Reader
 	path         string
 	out          chan<- interface{}
@@ -626,9 +632,13 @@ func (i *progressReader) Read(p []byte) (int, error) {
 	i.bytes += int64(n)
 	if i.bytes-i.lastProgress >= progressReaderIncrement || err == io.EOF {
 		i.lastProgress = i.bytes
-		i.out <- &coreiface.AddEvent{
+		select {
+		case i.out <- &coreiface.AddEvent{
 			Name:  i.path,
 			Bytes: i.bytes,
+		}:
+		case <-i.ctx.Done():
+			return n, i.ctx.Err()
 		}
 	}
 
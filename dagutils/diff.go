@@ -100,6 +100,14 @@ func ApplyChange(ctx context.Context, ds ipld.DAGService, nd *dag.ProtoNode, cs
 // 2. both of two nodes are ProtoNode.
 // Otherwise, it compares the cid and emits a Mod change object.
 func Diff(ctx context.Context, ds ipld.DAGService, a, b ipld.Node) ([]*Change, error) {
+	return DiffDepth(ctx, ds, a, b, -1)
+}
+
+// DiffDepth is like Diff but limits how many levels of links below the roots
+// changed subtrees are expanded into. At the cutoff a single Mod change is
+// emitted carrying the before and after cids of the entire subtree, which
+// avoids fetching it. A depth below 1 means no limit.
+func DiffDepth(ctx context.Context, ds ipld.DAGService, a, b ipld.Node, depth int) ([]*Change, error) {
 	// Base case where both nodes are leaves, just compare
 	// their CIDs.
 	if len(a.Links()) == 0 && len(b.Links()) == 0 {
@@ -119,6 +127,14 @@ func Diff(ctx context.Context, ds ipld.DAGService, a, b ipld.Node) ([]*Change, e
 		if err == nil {
 			if l.Cid.Equals(lnk.Cid) {
 				// no change... ignore it
+			} else if depth == 1 {
+				// cutoff reached, emit the subtree as a single change
+				out = append(out, &Change{
+					Type:   Mod,
+					Path:   lnk.Name,
+					Before: lnk.Cid,
+					After:  l.Cid,
+				})
 			} else {
 				anode, err := lnk.GetNode(ctx, ds)
 				if err != nil {
@@ -130,7 +146,12 @@ func Diff(ctx context.Context, ds ipld.DAGService, a, b ipld.Node) ([]*Change, e
 					return nil, err
 				}
 
-				sub, err := Diff(ctx, ds, anode, bnode)
+				next := depth
+				if next > 0 {
+					next--
+				}
+
+				sub, err := DiffDepth(ctx, ds, anode, bnode, next)
 				if err != nil {
 					return nil, err
 				}
@@ -14,6 +14,7 @@ import (
 	bsnet "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/network"
 	notifications "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/notifications"
 	bssm "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/sessionmanager"
+	wantlist "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/wantlist"
 	bswm "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/wantmanager"
 
 	blocks "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-block-format"
@@ -59,17 +60,156 @@ func init() {
 		HasBlockBufferSize = 64
 		provideKeysBufferSize = 512
 		provideWorkerMax = 16
+		ProvideBacklogMax = 16 * 1024
 	}
 }
 
 var rebroadcastDelay = delay.Fixed(time.Minute)
 
+// ProvideBacklogMax caps the number of keys the provide collector buffers
+// while waiting on the provide workers. When the DHT is slow this backlog
+// would otherwise grow without bound. Zero means unbounded.
+var ProvideBacklogMax = 64 * 1024
+
+// ProvideDropPolicy selects which key is discarded when the provide backlog
+// is full.
+type ProvideDropPolicy int
+
+const (
+	// DropOldest discards the longest-queued key to make room for the new
+	// one, biasing provides towards recently added blocks.
+	DropOldest ProvideDropPolicy = iota
+
+	// DropNewest discards the incoming key, preserving the keys already
+	// waiting in the queue.
+	DropNewest
+
+	// BlockProducer refuses to take new keys while the backlog is full,
+	// so HasBlock callers back up on the newBlocks buffer instead of
+	// losing announcements. Adds slow down to the provide rate.
+	BlockProducer
+)
+
+// ProvideBacklogDrop is the drop policy applied when the provide backlog
+// exceeds ProvideBacklogMax.
+var ProvideBacklogDrop = DropOldest
+
+// ShutdownGracePeriod is how long Close lets envelope sends already in
+// flight finish before the hard context cancellation aborts them.
+var ShutdownGracePeriod = 2 * time.Second
+
+// ProvideStrategy selects which of the blocks announced to bitswap are
+// provided to the routing system.
+type ProvideStrategy int
+
+const (
+	// ProvideAll announces every new block. This is the default.
+	ProvideAll ProvideStrategy = iota
+
+	// ProvideRootsOnly only announces keys explicitly flagged with
+	// ProvideRoots; other new blocks are not provided at all. This makes
+	// large adds cheap at the cost of leaf discoverability.
+	ProvideRootsOnly
+)
+
+// Option modifies the configuration of the Bitswap instance being constructed.
+type Option func(*Bitswap)
+
+// WithTaskWorkerCount overrides the default number of task workers serving
+// block requests from other peers, so nodes sharing a process can be tuned
+// independently of the TaskWorkerCount package variable.
+func WithTaskWorkerCount(n int) Option {
+	return func(bs *Bitswap) {
+		if n > 0 {
+			bs.taskWorkerCount = n
+		}
+	}
+}
+
+// WithPeerSendConcurrency overrides the default number of envelopes that may
+// be in flight to a single peer at once, so nodes sharing a process can be
+// tuned independently of the PeerSendConcurrency package variable.
+func WithPeerSendConcurrency(n int) Option {
+	return func(bs *Bitswap) {
+		if n > 0 {
+			bs.peerSendConcurrency = n
+		}
+	}
+}
+
+// WithProviderQueryWorkers overrides the default number of workers searching
+// providers for wanted blocks, see ProviderQueryWorkerCount.
+func WithProviderQueryWorkers(n int) Option {
+	return func(bs *Bitswap) {
+		if n > 0 {
+			bs.providerQueryWorkers = n
+		}
+	}
+}
+
+// WithProviderDialLimit overrides the default cap on concurrent connection
+// attempts to discovered providers, see ProviderDialMax.
+func WithProviderDialLimit(n int) Option {
+	return func(bs *Bitswap) {
+		if n > 0 {
+			bs.providerDialLimit = n
+		}
+	}
+}
+
+// WithProvideStrategy selects which announced blocks are provided to the
+// routing system. Keys flagged with ProvideRoots are announced ahead of
+// ordinary new blocks under every strategy.
+func WithProvideStrategy(s ProvideStrategy) Option {
+	return func(bs *Bitswap) {
+		bs.provideStrategy = s
+	}
+}
+
+// WithShutdownGracePeriod overrides how long Close lets in-flight envelope
+// sends finish before aborting them, see ShutdownGracePeriod.
+func WithShutdownGracePeriod(d time.Duration) Option {
+	return func(bs *Bitswap) {
+		if d > 0 {
+			bs.shutdownGrace = d
+		}
+	}
+}
+
+// approxQueueEntrySize is a rough estimate of the resident cost in bytes of
+// one queued key, counting the cid itself plus queue bookkeeping. It is used
+// to translate a memory budget into entry caps.
+const approxQueueEntrySize = 256
+
+// WithMemoryBudget bounds the memory held by bitswap's internal queues on
+// memory-constrained nodes. The budget, in bytes, is split evenly between
+// the provide backlog and the wantlist and translated into entry caps. When
+// a queue is full further load is shed: provide announcements are dropped
+// (per ProvideBacklogDrop) and new wants are refused until older ones
+// resolve. The tradeoff is reduced discoverability under pressure — fewer
+// blocks get announced and outstanding fetches are capped. Shed events are
+// counted in Stat and the bitswap metrics.
+func WithMemoryBudget(budget int64) Option {
+	return func(bs *Bitswap) {
+		if budget <= 0 {
+			return
+		}
+
+		entries := int(budget / 2 / approxQueueEntrySize)
+		if entries < 1 {
+			entries = 1
+		}
+		bs.provideBacklogMax = entries
+		bs.maxWants = entries
+	}
+}
+
 // New initializes a BitSwap instance that communicates over the provided
 // BitSwapNetwork. This function registers the returned instance as the network
 // delegate.
 // Runs until context is cancelled.
 func New(parent context.Context, network bsnet.BitSwapNetwork,
-	bstore blockstore.Blockstore) exchange.Interface {
+	bstore blockstore.Blockstore, opts ...Option) exchange.Interface {
 
 	// important to use provided parent context (since it may include important
 	// loggable data). It's probably not a good idea to allow bitswap to be
@@ -92,6 +232,7 @@ func New(parent context.Context, network bsnet.BitSwapNetwork,
 	})
 
 	bs := &Bitswap{
+		ctx:           ctx,
 		blockstore:    bstore,
 		notifications: notif,
 		engine:        decision.NewEngine(ctx, bstore), // TODO close the engine with Close() method
@@ -99,14 +240,29 @@ func New(parent context.Context, network bsnet.BitSwapNetwork,
 		findKeys:      make(chan *blockRequest, sizeBatchRequestChan),
 		process:       px,
 		newBlocks:     make(chan cid.Cid, HasBlockBufferSize),
+		rootBlocks:    make(chan cid.Cid, HasBlockBufferSize),
 		provideKeys:   make(chan cid.Cid, provideKeysBufferSize),
 		wm:            bswm.New(ctx, network),
 		sm:            bssm.New(),
 		counters:      new(counters),
+		history:       newStatHistory(StatHistoryLength),
+		sendsInFlight: make(map[peer.ID]int),
+		sendSlotFreed: make(chan struct{}, 1),
 
 		dupMetric: dupHist,
 		allMetric: allHist,
+
+		taskWorkerCount: TaskWorkerCount,
+	}
+
+	for _, opt := range opts {
+		opt(bs)
+	}
+
+	if bs.maxWants > 0 {
+		bs.wm.SetMaxWants(bs.maxWants)
 	}
+
 	go bs.wm.Run()
 	network.SetDelegate(bs)
 
@@ -117,6 +273,7 @@ func New(parent context.Context, network bsnet.BitSwapNetwork,
 	// do it over here to avoid closing before all setup is done.
 	go func() {
 		<-px.Closing() // process closes first
+		bs.drainSends()
 		cancelFunc()
 	}()
 	procctx.CloseAfterContext(px, ctx) // parent cancelled first
@@ -136,6 +293,11 @@ type Bitswap struct {
 	// network delivers messages on behalf of the session
 	network bsnet.BitSwapNetwork
 
+	// ctx is the instance context. On Close it is cancelled only after the
+	// shutdown grace period, so in-flight envelope sends can drain while the
+	// process closing signal already keeps workers from taking new work
+	ctx context.Context
+
 	// blockstore is the local database
 	// NB: ensure threadsafety
 	blockstore blockstore.Blockstore
@@ -150,14 +312,42 @@ type Bitswap struct {
 	// network.  blocks pushed down this channel get buffered and fed to the
 	// provideKeys channel later on to avoid too much network activity
 	newBlocks chan cid.Cid
+	// rootBlocks carries keys explicitly flagged via ProvideRoots, which
+	// the provide collector serves ahead of ordinary new blocks
+	rootBlocks chan cid.Cid
 	// provideKeys directly feeds provide workers
 	provideKeys chan cid.Cid
 
 	process process.Process
 
-	// Counters for various statistics
-	counterLk sync.Mutex
-	counters  *counters
+	// Counters for various statistics. counterLk also guards sendsInFlight,
+	// the number of envelopes currently being sent to each peer.
+	counterLk     sync.Mutex
+	counters      *counters
+	sendsInFlight map[peer.ID]int
+
+	// history keeps periodic counter snapshots for trend inspection
+	history *statHistory
+
+	// sendSlotFreed is pulsed when a send to a peer completes, waking task
+	// workers that had to set envelopes aside for saturated peers
+	sendSlotFreed chan struct{}
+
+	// peerSendConcurrency caps in-flight sends per peer, zero means the
+	// package level default applies
+	peerSendConcurrency int
+
+	// providerQueryWorkers sizes the provider query worker pool, zero means
+	// the package level default applies
+	providerQueryWorkers int
+
+	// providerDialLimit caps concurrent connects to discovered providers,
+	// zero means the package level default applies
+	providerDialLimit int
+
+	// provideStrategy selects which announced blocks are provided to the
+	// routing system
+	provideStrategy ProvideStrategy
 
 	// Metrics interface metrics
 	dupMetric metrics.Histogram
@@ -165,6 +355,32 @@ type Bitswap struct {
 
 	// the sessionmanager manages tracking sessions
 	sm *bssm.SessionManager
+
+	// taskWorkerLk guards the task worker pool so it can be resized at
+	// runtime, taskWorkerStop holds one stop channel per running worker
+	taskWorkerLk    sync.Mutex
+	taskWorkerCount int
+	taskWorkerStop  []chan struct{}
+
+	// provideStatLk guards the provide backlog statistics, updated by the
+	// provide collector and read by Stat
+	provideStatLk   sync.Mutex
+	provideQueueLen int
+	provideDropped  uint64
+
+	// provSkipLk guards the count of provider dials skipped due to dial
+	// backoff, updated by the provider query workers and read by Stat
+	provSkipLk       sync.Mutex
+	provDialsSkipped uint64
+
+	// per-instance queue caps derived from WithMemoryBudget, zero means the
+	// package level defaults apply
+	provideBacklogMax int
+	maxWants          int
+
+	// shutdownGrace overrides ShutdownGracePeriod for this instance, zero
+	// means the package level default applies
+	shutdownGrace time.Duration
 }
 
 type counters struct {
@@ -180,6 +396,11 @@ type counters struct {
 type blockRequest struct {
 	Cid cid.Cid
 	Ctx context.Context
+
+	// Ses, when non-zero, identifies the GetBlocks session the request
+	// belongs to so the providerQueryManager can reuse providers already
+	// found for earlier keys of the same session.
+	Ses uint64
 }
 
 // GetBlock attempts to retrieve a particular block from peers within the
@@ -188,6 +409,9 @@ func (bs *Bitswap) GetBlock(parent context.Context, k cid.Cid) (blocks.Block, er
 	return getBlock(parent, k, bs.GetBlocks)
 }
 
+// WantlistForPeer returns the keys the given peer is currently asking us
+// for, as tracked by the decision engine's ledger. Safe to call concurrently
+// with the task workers, the ledger is read under the partner lock.
 func (bs *Bitswap) WantlistForPeer(p peer.ID) []cid.Cid {
 	var out []cid.Cid
 	for _, e := range bs.engine.WantlistForPeer(p) {
@@ -250,6 +474,7 @@ func (bs *Bitswap) GetBlocks(ctx context.Context, keys []cid.Cid) (<-chan blocks
 		req := &blockRequest{
 			Cid: keys[0],
 			Ctx: ctx,
+			Ses: mses,
 		}
 
 		var findProvsReqCh chan<- *blockRequest
@@ -304,6 +529,24 @@ func (bs *Bitswap) HasBlock(blk blocks.Block) error {
 	return bs.receiveBlockFrom(blk, "")
 }
 
+// ProvideRoots flags keys the caller considers roots of a larger structure,
+// queueing them for providing ahead of ordinary new blocks. Roots are
+// announced under every provide strategy, so a caller adding a large DAG can
+// flag just the root cids and run with ProvideRootsOnly to avoid providing
+// every leaf.
+func (bs *Bitswap) ProvideRoots(ctx context.Context, ks []cid.Cid) error {
+	for _, k := range ks {
+		select {
+		case bs.rootBlocks <- k:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-bs.process.Closing():
+			return errors.New("bitswap is closed")
+		}
+	}
+	return nil
+}
+
 // TODO: Some of this stuff really only needs to be done when adding a block
 // from the user, not when receiving it from the network.
 // In case you run `git blame` on this comment, I'll save you some time: ask
@@ -456,6 +699,12 @@ func (bs *Bitswap) GetWantlist() []cid.Cid {
 	return out
 }
 
+// WantlistEntries returns the local wantlist with its bookkeeping, letting
+// callers inspect how long each want has been outstanding.
+func (bs *Bitswap) WantlistEntries() []*wantlist.Entry {
+	return bs.wm.CurrentWants()
+}
+
 func (bs *Bitswap) IsOnline() bool {
 	return true
 }
@@ -4,13 +4,18 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	decision "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/decision"
 	"github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/message"
+	bsnet "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/network"
 	tn "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/testnet"
+	wantlist "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/wantlist"
+	bswm "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/wantmanager"
 
 	blocks "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-block-format"
 	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
@@ -19,6 +24,9 @@ import (
 	blocksutil "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-blocksutil"
 	delay "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-delay"
 	mockrouting "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-routing/mock"
+	ds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore"
+	ds_sync "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore/sync"
+	peer "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peer"
 	p2ptestutil "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-netutil"
 	tu "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-testutil"
 	travis "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-testutil/ci/travis"
@@ -45,6 +53,100 @@ func TestClose(t *testing.T) {
 	bitswap.Exchange.GetBlock(context.Background(), block.Cid())
 }
 
+// blockingSendNetwork wraps a bitswap network, parking every envelope send
+// on a gate the test controls so a send is reliably in flight.
+type blockingSendNetwork struct {
+	bsnet.BitSwapNetwork
+	started chan struct{}
+	release chan struct{}
+}
+
+func (bn *blockingSendNetwork) SendMessage(ctx context.Context, p peer.ID, msg message.BitSwapMessage) error {
+	bn.started <- struct{}{}
+	<-bn.release
+	return bn.BitSwapNetwork.SendMessage(ctx, p, msg)
+}
+
+func TestCloseDrainsInflightSends(t *testing.T) {
+	net := tn.VirtualNetwork(mockrouting.NewServer(), delay.Fixed(0))
+	bgen := blocksutil.NewBlockGenerator()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rid, err := p2ptestutil.RandTestBogusIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver := MkSession(ctx, net, rid)
+	defer receiver.Exchange.Close()
+
+	// hand-build the sending instance so the test controls when its
+	// envelope sends finish
+	sid, err := p2ptestutil.RandTestBogusIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	adapter := &blockingSendNetwork{
+		BitSwapNetwork: net.Adapter(sid),
+		started:        make(chan struct{}),
+		release:        make(chan struct{}),
+	}
+	bstore := blockstore.NewBlockstore(ds_sync.MutexWrap(ds.NewMapDatastore()))
+	sender := New(ctx, adapter, bstore).(*Bitswap)
+
+	block := bgen.Next()
+	if err := sender.HasBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := receiver.Exchange.GetBlocks(ctx, []cid.Cid{block.Cid()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// wait for the sender's envelope send to park on the network stub
+	select {
+	case <-adapter.started:
+	case <-ctx.Done():
+		t.Fatal("sender never started sending")
+	}
+
+	// close the sender while the send is in flight; the grace period
+	// should let the send finish instead of aborting it
+	closed := make(chan struct{})
+	go func() {
+		sender.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("close returned while a send was still in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(adapter.release)
+
+	select {
+	case blk, ok := <-out:
+		if !ok {
+			t.Fatal("GetBlocks channel closed without delivering the block")
+		}
+		if !blk.Cid().Equals(block.Cid()) {
+			t.Fatal("received wrong block")
+		}
+	case <-ctx.Done():
+		t.Fatal("did not receive the already-dequeued block after close")
+	}
+
+	select {
+	case <-closed:
+	case <-ctx.Done():
+		t.Fatal("close did not return after the send drained")
+	}
+}
+
 func TestProviderForKeyButNetworkCannotFind(t *testing.T) { // TODO revisit this
 
 	rs := mockrouting.NewServer()
@@ -705,3 +807,539 @@ func TestBitswapLedgerTwoWay(t *testing.T) {
 		}
 	}
 }
+
+func TestProviderConnectRetry(t *testing.T) {
+	ctx := context.Background()
+
+	oldBackoff := ProviderConnectBackoff
+	ProviderConnectBackoff = time.Millisecond
+	defer func() { ProviderConnectBackoff = oldBackoff }()
+
+	var attempts int
+	connect := func(ctx context.Context, p peer.ID) error {
+		attempts++
+		if attempts == 1 {
+			return fmt.Errorf("transient dial failure")
+		}
+		return nil
+	}
+
+	if err := connectWithRetry(ctx, connect, peer.ID("provider")); err != nil {
+		t.Fatalf("expected second dial attempt to succeed, got: %s", err)
+	}
+
+	if attempts != 2 {
+		t.Fatalf("expected 2 dial attempts, got %d", attempts)
+	}
+
+	// a provider that never dials successfully is eventually dropped
+	attempts = 0
+	alwaysFail := func(ctx context.Context, p peer.ID) error {
+		attempts++
+		return fmt.Errorf("dial failure")
+	}
+
+	if err := connectWithRetry(ctx, alwaysFail, peer.ID("provider")); err == nil {
+		t.Fatal("expected error after exhausting retries")
+	}
+
+	if attempts != ProviderConnectRetries+1 {
+		t.Fatalf("expected %d dial attempts, got %d", ProviderConnectRetries+1, attempts)
+	}
+}
+
+func TestSetTaskWorkerCount(t *testing.T) {
+	net := tn.VirtualNetwork(mockrouting.NewServer(), delay.Fixed(kNetworkDelay))
+	block := blocks.NewBlock([]byte("block"))
+	g := NewTestSessionGenerator(net)
+	defer g.Close()
+
+	peers := g.Instances(2)
+	hasBlock := peers[0]
+	defer hasBlock.Exchange.Close()
+
+	workers := func(bs *Bitswap) int {
+		bs.taskWorkerLk.Lock()
+		defer bs.taskWorkerLk.Unlock()
+		return len(bs.taskWorkerStop)
+	}
+
+	if n := workers(hasBlock.Exchange); n != TaskWorkerCount {
+		t.Fatalf("expected %d task workers, got %d", TaskWorkerCount, n)
+	}
+
+	hasBlock.Exchange.SetTaskWorkerCount(2)
+	if n := workers(hasBlock.Exchange); n != 2 {
+		t.Fatalf("expected pool to shrink to 2 workers, got %d", n)
+	}
+
+	hasBlock.Exchange.SetTaskWorkerCount(TaskWorkerCount * 2)
+	if n := workers(hasBlock.Exchange); n != TaskWorkerCount*2 {
+		t.Fatalf("expected pool to grow to %d workers, got %d", TaskWorkerCount*2, n)
+	}
+
+	// the resized pool must still serve blocks
+	if err := hasBlock.Exchange.HasBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	wantsBlock := peers[1]
+	defer wantsBlock.Exchange.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	received, err := wantsBlock.Exchange.GetBlock(ctx, block.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(block.RawData(), received.RawData()) {
+		t.Fatal("Data doesn't match")
+	}
+}
+
+func TestRebroadcastBatch(t *testing.T) {
+	bgen := blocksutil.NewBlockGenerator()
+
+	var entries []*wantlist.Entry
+	for i := 0; i < RebroadcastBatchSize*2; i++ {
+		e := wantlist.NewRefEntry(bgen.Next().Cid(), i)
+		e.Created = time.Unix(int64(1000-i), 0)
+		entries = append(entries, e)
+	}
+
+	batch := rebroadcastBatch(entries)
+
+	if len(batch) != RebroadcastBatchSize {
+		t.Fatalf("expected batch of %d keys, got %d", RebroadcastBatchSize, len(batch))
+	}
+
+	for i := 1; i < len(batch); i++ {
+		if batch[i].Created.Before(batch[i-1].Created) {
+			t.Fatal("batch is not sorted oldest-wanted first")
+		}
+	}
+
+	// the oldest entry was created last in the loop above
+	if !batch[0].Cid.Equals(entries[len(entries)-1].Cid) {
+		t.Fatal("expected the oldest wanted key first")
+	}
+}
+
+func TestProvideBacklogBounded(t *testing.T) {
+	const keys = 1000000
+	const backlog = 128
+
+	oldMax, oldDrop := ProvideBacklogMax, ProvideBacklogDrop
+	ProvideBacklogMax = backlog
+	ProvideBacklogDrop = DropOldest
+	defer func() {
+		ProvideBacklogMax, ProvideBacklogDrop = oldMax, oldDrop
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// a collector with a stalled provideKeys consumer: nothing ever reads
+	// from provideKeys beyond the single key parked in nextKey
+	bs := &Bitswap{
+		newBlocks:   make(chan cid.Cid),
+		provideKeys: make(chan cid.Cid),
+	}
+	go bs.provideCollector(ctx)
+
+	bgen := blocksutil.NewBlockGenerator()
+	cids := make([]cid.Cid, 16)
+	for i := range cids {
+		cids[i] = bgen.Next().Cid()
+	}
+
+	for i := 0; i < keys; i++ {
+		bs.newBlocks <- cids[i%len(cids)]
+	}
+
+	// the last send returns before the collector finishes processing it,
+	// give the counters a moment to settle
+	expected := uint64(keys - backlog - 1)
+	var qlen int
+	var ndropped uint64
+	for i := 0; i < 100; i++ {
+		bs.provideStatLk.Lock()
+		qlen, ndropped = bs.provideQueueLen, bs.provideDropped
+		bs.provideStatLk.Unlock()
+		if ndropped == expected {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if qlen > backlog {
+		t.Fatalf("provide backlog exceeded its cap: %d > %d", qlen, backlog)
+	}
+
+	// everything except the capped backlog and the key parked in nextKey
+	// must have been counted as dropped
+	if ndropped != expected {
+		t.Fatalf("expected %d dropped provides, got %d", expected, ndropped)
+	}
+}
+
+func TestProvideBacklogBlocksProducer(t *testing.T) {
+	const backlog = 16
+
+	oldMax, oldDrop := ProvideBacklogMax, ProvideBacklogDrop
+	ProvideBacklogMax = backlog
+	ProvideBacklogDrop = BlockProducer
+	defer func() {
+		ProvideBacklogMax, ProvideBacklogDrop = oldMax, oldDrop
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// a collector with a stalled provideKeys consumer, like above
+	bs := &Bitswap{
+		newBlocks:   make(chan cid.Cid),
+		provideKeys: make(chan cid.Cid),
+	}
+	go bs.provideCollector(ctx)
+
+	bgen := blocksutil.NewBlockGenerator()
+	var accepted int64
+	go func() {
+		for {
+			select {
+			case bs.newBlocks <- bgen.Next().Cid():
+				atomic.AddInt64(&accepted, 1)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// the collector takes the capped backlog plus the key parked in
+	// nextKey, then must stop accepting
+	expected := int64(backlog + 1)
+	deadline := time.Now().Add(10 * time.Second)
+	for atomic.LoadInt64(&accepted) < expected {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out with %d accepted keys", atomic.LoadInt64(&accepted))
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// give the producer a chance to overshoot, it has to stay blocked
+	time.Sleep(50 * time.Millisecond)
+	if got := atomic.LoadInt64(&accepted); got != expected {
+		t.Fatalf("expected the producer to block at %d keys, got %d", expected, got)
+	}
+
+	// nothing may be dropped under this policy
+	bs.provideStatLk.Lock()
+	ndropped := bs.provideDropped
+	bs.provideStatLk.Unlock()
+	if ndropped != 0 {
+		t.Fatalf("expected no dropped provides, got %d", ndropped)
+	}
+
+	// draining one key frees exactly one slot for the producer
+	<-bs.provideKeys
+	expected++
+	for atomic.LoadInt64(&accepted) < expected {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the freed slot to be refilled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := atomic.LoadInt64(&accepted); got != expected {
+		t.Fatalf("expected exactly one more accepted key, got %d of %d", got, expected)
+	}
+}
+
+func TestMemoryBudgetCaps(t *testing.T) {
+	bs := &Bitswap{}
+
+	// a tiny budget still leaves room for at least one entry per queue
+	WithMemoryBudget(1)(bs)
+	if bs.provideBacklogMax != 1 || bs.maxWants != 1 {
+		t.Fatalf("expected caps of 1, got %d / %d", bs.provideBacklogMax, bs.maxWants)
+	}
+
+	bs = &Bitswap{}
+	WithMemoryBudget(1 << 20)(bs)
+	expected := int(1 << 20 / 2 / approxQueueEntrySize)
+	if bs.provideBacklogMax != expected || bs.maxWants != expected {
+		t.Fatalf("expected caps of %d, got %d / %d", expected, bs.provideBacklogMax, bs.maxWants)
+	}
+
+	// a zero budget leaves the package defaults in place
+	bs = &Bitswap{}
+	WithMemoryBudget(0)(bs)
+	if bs.provideBacklogMax != 0 || bs.maxWants != 0 {
+		t.Fatal("expected a zero budget to leave the caps unset")
+	}
+}
+
+func TestMemoryBudgetShedsWants(t *testing.T) {
+	const maxWants = 4
+	const wants = 32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	wm := bswm.New(ctx, nil)
+	wm.SetMaxWants(maxWants)
+	go wm.Run()
+
+	bgen := blocksutil.NewBlockGenerator()
+	ks := make([]cid.Cid, wants)
+	for i := range ks {
+		ks[i] = bgen.Next().Cid()
+	}
+
+	wm.WantBlocks(ctx, ks, nil, 1)
+
+	var count int
+	var shed uint64
+	for i := 0; i < 100; i++ {
+		count = wm.WantCount()
+		shed = wm.WantsShed()
+		if shed == wants-maxWants {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if count != maxWants {
+		t.Fatalf("expected the wantlist to be capped at %d, got %d", maxWants, count)
+	}
+	if shed != wants-maxWants {
+		t.Fatalf("expected %d shed wants, got %d", wants-maxWants, shed)
+	}
+
+	// cancelling a capped want frees room for a new one
+	wm.CancelWants(ctx, ks[:1], nil, 1)
+	wm.WantBlocks(ctx, ks[wants-1:], nil, 1)
+
+	for i := 0; i < 100; i++ {
+		if wm.WantCount() == maxWants && wm.IsWanted(ks[wants-1]) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !wm.IsWanted(ks[wants-1]) {
+		t.Fatal("expected a new want to be accepted after a cancel freed room")
+	}
+}
+
+func TestPerPeerSendSlots(t *testing.T) {
+	bs := &Bitswap{
+		sendsInFlight: make(map[peer.ID]int),
+		sendSlotFreed: make(chan struct{}, 1),
+	}
+
+	slow := peer.ID("slow peer")
+	other := peer.ID("other peer")
+
+	// the default limit applies when no option was given
+	for i := 0; i < PeerSendConcurrency; i++ {
+		if !bs.tryAcquireSendSlot(slow) {
+			t.Fatalf("send %d should have gotten a slot", i)
+		}
+	}
+	if bs.tryAcquireSendSlot(slow) {
+		t.Fatal("exceeded the per-peer send limit")
+	}
+
+	// a saturated peer doesn't block sends to other peers
+	if !bs.tryAcquireSendSlot(other) {
+		t.Fatal("other peer should not be limited")
+	}
+
+	bs.releaseSendSlot(slow)
+	if !bs.tryAcquireSendSlot(slow) {
+		t.Fatal("releasing a slot should allow a new send")
+	}
+
+	// releasing pulses sendSlotFreed to wake waiting workers
+	select {
+	case <-bs.sendSlotFreed:
+	default:
+		t.Fatal("expected a slot-freed pulse")
+	}
+
+	// the option overrides the package default
+	bs = &Bitswap{
+		sendsInFlight: make(map[peer.ID]int),
+		sendSlotFreed: make(chan struct{}, 1),
+	}
+	WithPeerSendConcurrency(1)(bs)
+	if !bs.tryAcquireSendSlot(slow) {
+		t.Fatal("first send should have gotten a slot")
+	}
+	if bs.tryAcquireSendSlot(slow) {
+		t.Fatal("expected a limit of 1 in-flight send")
+	}
+
+	bs.releaseSendSlot(slow)
+	bs.counterLk.Lock()
+	if len(bs.sendsInFlight) != 0 {
+		t.Fatal("expected idle peers to be dropped from the map")
+	}
+	bs.counterLk.Unlock()
+}
+
+func TestGetCompressedBlockFromPeer(t *testing.T) {
+	net := tn.VirtualNetwork(mockrouting.NewServer(), delay.Fixed(kNetworkDelay))
+	block := blocks.NewBlock([]byte("stored compressed, served plain"))
+	g := NewTestSessionGenerator(net)
+	defer g.Close()
+
+	peers := g.Instances(2)
+	hasBlock := peers[0]
+	defer hasBlock.Exchange.Close()
+
+	// the serving peer keeps the block compressed at rest
+	cb, err := blockstore.NewCompressedBlock(block, blockstore.CompressionGzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hasBlock.Blockstore().Put(cb); err != nil {
+		t.Fatal(err)
+	}
+	if err := hasBlock.Exchange.HasBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	wantsBlock := peers[1]
+	defer wantsBlock.Exchange.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	received, err := wantsBlock.Exchange.GetBlock(ctx, block.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the wire and the receiver only ever see the uncompressed bytes
+	if !bytes.Equal(block.RawData(), received.RawData()) {
+		t.Fatal("expected the uncompressed content to be served")
+	}
+}
+
+func TestProviderQueryManagerBounded(t *testing.T) {
+	net := getVirtualNetwork()
+	sg := NewTestSessionGenerator(net)
+	defer sg.Close()
+
+	bs := sg.Instances(1)[0].Exchange
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	before := runtime.NumGoroutine()
+
+	// flood the query manager with far more requests than workers
+	go func() {
+		for i := 0; i < 10000; i++ {
+			blk := blocks.NewBlock([]byte(fmt.Sprintf("provider query flood %d", i)))
+			select {
+			case bs.findKeys <- &blockRequest{Cid: blk.Cid(), Ctx: ctx}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// with the worker pool the goroutine count must stay within the pool
+	// size plus the dial semaphore, not grow with the request count
+	limit := before + ProviderQueryWorkerCount + ProviderDialMax + 100
+	for i := 0; i < 50; i++ {
+		if n := runtime.NumGoroutine(); n > limit {
+			t.Fatalf("goroutine count grew with the request count: %d > %d", n, limit)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+// findCountingNetwork wraps a bitswap network, counting provider searches.
+type findCountingNetwork struct {
+	bsnet.BitSwapNetwork
+	finds int32
+}
+
+func (cn *findCountingNetwork) FindProvidersAsync(ctx context.Context, c cid.Cid, max int) <-chan peer.ID {
+	atomic.AddInt32(&cn.finds, 1)
+	return cn.BitSwapNetwork.FindProvidersAsync(ctx, c, max)
+}
+
+func TestSessionProviderCache(t *testing.T) {
+	rs := mockrouting.NewServer()
+	net := tn.VirtualNetwork(rs, delay.Fixed(kNetworkDelay))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pid, err := p2ptestutil.RandTestBogusIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := MkSession(ctx, net, pid)
+	defer provider.Exchange.Close()
+
+	// register the provider for every key up front, all blocks of the
+	// "file" live on the same peer
+	bgen := blocksutil.NewBlockGenerator()
+	blks := bgen.Blocks(1000)
+	for _, blk := range blks {
+		if err := rs.Client(pid).Provide(ctx, blk.Cid(), true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// hand-build the fetching instance so provider searches can be counted
+	fid, err := p2ptestutil.RandTestBogusIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	adapter := &findCountingNetwork{BitSwapNetwork: net.Adapter(fid)}
+	bstore := blockstore.NewBlockstore(ds_sync.MutexWrap(ds.NewMapDatastore()))
+	bs := New(ctx, adapter, bstore).(*Bitswap)
+	defer bs.Close()
+
+	const ses = uint64(1)
+
+	// the first key of the session has to walk the routing system and
+	// populates the session cache with the connected provider
+	select {
+	case bs.findKeys <- &blockRequest{Cid: blks[0].Cid(), Ctx: ctx, Ses: ses}:
+	case <-ctx.Done():
+		t.Fatal("timed out sending the first request")
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for atomic.LoadInt32(&adapter.finds) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the first provider search never started")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	// let the dial finish so the provider lands in the session cache
+	time.Sleep(time.Millisecond * 100)
+
+	// every other key of the session should be served from the cache
+	for _, blk := range blks[1:] {
+		select {
+		case bs.findKeys <- &blockRequest{Cid: blk.Cid(), Ctx: ctx, Ses: ses}:
+		case <-ctx.Done():
+			t.Fatal("timed out queueing requests")
+		}
+	}
+	time.Sleep(time.Millisecond * 500)
+
+	if finds := atomic.LoadInt32(&adapter.finds); finds > int32(ProviderQueryWorkerCount) {
+		t.Fatalf("expected O(1) provider searches for %d keys of one session, got %d", len(blks), finds)
+	}
+}
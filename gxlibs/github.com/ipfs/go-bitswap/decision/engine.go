@@ -201,6 +201,31 @@ func (e *Engine) nextEnvelope(ctx context.Context) (*Envelope, error) {
 	}
 }
 
+// Requeue puts the blocks of an undelivered envelope back on the peer's
+// request queue so they can be popped again later. The envelope's Sent
+// callback must have been invoked first, otherwise the blocks are still
+// marked active and the push is ignored. Priorities are recovered from the
+// peer's wantlist; blocks the peer no longer wants are dropped.
+func (e *Engine) Requeue(env *Envelope) {
+	l := e.findOrCreate(env.Peer)
+
+	l.lk.Lock()
+	var entries []*wl.Entry
+	for _, b := range env.Message.Blocks() {
+		if entry, ok := l.WantListContains(b.Cid()); ok {
+			entries = append(entries, entry)
+		}
+	}
+	l.lk.Unlock()
+
+	if len(entries) == 0 {
+		return
+	}
+
+	e.peerRequestQueue.Push(env.Peer, entries...)
+	e.signalNewWork()
+}
+
 // Outbox returns a channel of one-time use Envelope channels.
 func (e *Engine) Outbox() <-chan (<-chan *Envelope) {
 	return e.outbox
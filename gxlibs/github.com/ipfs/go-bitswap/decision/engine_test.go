@@ -7,6 +7,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	message "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/message"
 
@@ -241,3 +242,47 @@ func stringsComplement(set, subset []string) []string {
 	}
 	return complement
 }
+
+func TestRequeue(t *testing.T) {
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	block := blocks.NewBlock([]byte("requeue me"))
+	if err := bs.Put(block); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewEngine(context.Background(), bs)
+	partner := testutil.RandPeerIDFatal(t)
+	partnerWants(e, []string{"requeue me"}, partner)
+
+	next := <-e.Outbox()
+	envelope := <-next
+	if len(envelope.Message.Blocks()) != 1 {
+		t.Fatal("expected an envelope with the block")
+	}
+
+	// put it back undelivered and pop it again
+	envelope.Sent()
+	e.Requeue(envelope)
+
+	next = <-e.Outbox()
+	envelope = <-next
+	if len(envelope.Message.Blocks()) != 1 || !envelope.Message.Blocks()[0].Cid().Equals(block.Cid()) {
+		t.Fatal("expected the requeued block")
+	}
+	envelope.Sent()
+
+	// a cancelled want is not requeued
+	partnerCancels(e, []string{"requeue me"}, partner)
+	e.Requeue(envelope)
+	select {
+	case next = <-e.Outbox():
+		select {
+		case envelope, ok := <-next:
+			if ok && envelope != nil {
+				t.Fatal("got an envelope for a cancelled want")
+			}
+		case <-time.After(time.Millisecond * 50):
+		}
+	case <-time.After(time.Millisecond * 50):
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"context"
 	"errors"
 
-	notifications "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/notifications"
-
 	blocks "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-block-format"
 	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
 	blockstore "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-blockstore"
@@ -49,52 +47,3 @@ func getBlock(p context.Context, k cid.Cid, gb getBlocksFunc) (blocks.Block, err
 	}
 }
 
-type wantFunc func(context.Context, []cid.Cid)
-
-func getBlocksImpl(ctx context.Context, keys []cid.Cid, notif notifications.PubSub, want wantFunc, cwants func([]cid.Cid)) (<-chan blocks.Block, error) {
-	if len(keys) == 0 {
-		out := make(chan blocks.Block)
-		close(out)
-		return out, nil
-	}
-
-	remaining := cid.NewSet()
-	promise := notif.Subscribe(ctx, keys...)
-	for _, k := range keys {
-		log.Event(ctx, "Bitswap.GetBlockRequest.Start", k)
-		remaining.Add(k)
-	}
-
-	want(ctx, keys)
-
-	out := make(chan blocks.Block)
-	go handleIncoming(ctx, remaining, promise, out, cwants)
-	return out, nil
-}
-
-func handleIncoming(ctx context.Context, remaining *cid.Set, in <-chan blocks.Block, out chan blocks.Block, cfun func([]cid.Cid)) {
-	ctx, cancel := context.WithCancel(ctx)
-	defer func() {
-		cancel()
-		close(out)
-		// can't just defer this call on its own, arguments are resolved *when* the defer is created
-		cfun(remaining.Keys())
-	}()
-	for {
-		select {
-		case blk, ok := <-in:
-			if !ok {
-				return
-			}
-
-			remaining.Remove(blk.Cid())
-			select {
-			case out <- blk:
-			case <-ctx.Done():
-				return
-			}
-		case <-ctx.Done():
-			return
-		}
-	}
-}
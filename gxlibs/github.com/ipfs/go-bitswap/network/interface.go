@@ -34,6 +34,10 @@ type BitSwapNetwork interface {
 
 	ConnectTo(context.Context, peer.ID) error
 
+	// Connected reports whether there currently is an open connection to
+	// the given peer.
+	Connected(peer.ID) bool
+
 	NewMessageSender(context.Context, peer.ID) (MessageSender, error)
 
 	ConnectionManager() ifconnmgr.ConnManager
@@ -145,6 +145,10 @@ func (bsnet *impl) SetDelegate(r Receiver) {
 	bsnet.receiver = r
 }
 
+func (bsnet *impl) Connected(p peer.ID) bool {
+	return bsnet.host.Network().Connectedness(p) == inet.Connected
+}
+
 func (bsnet *impl) ConnectTo(ctx context.Context, p peer.ID) error {
 	return bsnet.host.Connect(ctx, pstore.PeerInfo{ID: p})
 }
@@ -2,7 +2,9 @@ package bitswap
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	notifications "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/notifications"
@@ -18,6 +20,13 @@ import (
 
 const activeWantsLimit = 16
 
+// defaultWantTTL bounds how long a session's wants stay on the wantlist
+// without being served or re-requested. Wants leaked by operations whose
+// contexts were never cancelled are dropped after this long instead of
+// being rebroadcast forever; the periodic session tick refreshes the
+// deadline of wants that are still live.
+const defaultWantTTL = time.Hour
+
 // Session holds state for an individual bitswap transfer operation.
 // This allows bitswap to make smarter decisions about who to send wantlist
 // info to, and who to request blocks from
@@ -29,12 +38,13 @@ type Session struct {
 
 	bs           *Bitswap
 	incoming     chan blkRecv
-	newReqs      chan []cid.Cid
+	newReqs      chan wantReq
 	cancelKeys   chan []cid.Cid
 	interestReqs chan interestReq
 
 	interest  *lru.Cache
 	liveWants map[cid.Cid]time.Time
+	wantPrio  map[cid.Cid]int
 
 	tick          *time.Timer
 	baseTickDelay time.Duration
@@ -56,7 +66,8 @@ func (bs *Bitswap) NewSession(ctx context.Context) exchange.Fetcher {
 	s := &Session{
 		activePeers:   make(map[peer.ID]struct{}),
 		liveWants:     make(map[cid.Cid]time.Time),
-		newReqs:       make(chan []cid.Cid),
+		wantPrio:      make(map[cid.Cid]int),
+		newReqs:       make(chan wantReq),
 		cancelKeys:    make(chan []cid.Cid),
 		tofetch:       newCidQueue(),
 		interestReqs:  make(chan interestReq),
@@ -175,9 +186,13 @@ func (s *Session) run(ctx context.Context) {
 			s.receiveBlock(ctx, blk.blk)
 
 			s.resetTick()
-		case keys := <-s.newReqs:
+		case req := <-s.newReqs:
+			keys := req.keys
 			for _, k := range keys {
 				s.interest.Add(k, nil)
+				if req.prio != 0 {
+					s.wantPrio[k] = req.prio
+				}
 			}
 			if len(s.liveWants) < activeWantsLimit {
 				toadd := activeWantsLimit - len(s.liveWants)
@@ -205,7 +220,7 @@ func (s *Session) run(ctx context.Context) {
 			}
 
 			// Broadcast these keys to everyone we're connected to
-			s.bs.wm.WantBlocks(ctx, live, nil, s.id)
+			s.bs.wm.WantBlocksTTL(ctx, live, nil, s.id, defaultWantTTL)
 
 			if len(live) > 0 {
 				go func(k cid.Cid) {
@@ -256,6 +271,7 @@ func (s *Session) receiveBlock(ctx context.Context, blk blocks.Block) {
 		} else {
 			s.tofetch.Remove(c)
 		}
+		delete(s.wantPrio, c)
 		s.fetchcnt++
 		s.notif.Publish(blk)
 
@@ -270,12 +286,29 @@ func (s *Session) wantBlocks(ctx context.Context, ks []cid.Cid) {
 	for _, c := range ks {
 		s.liveWants[c] = now
 	}
-	s.bs.wm.WantBlocks(ctx, ks, s.activePeersArr, s.id)
+
+	var def []cid.Cid
+	prioritized := make(map[int][]cid.Cid)
+	for _, c := range ks {
+		if p, ok := s.wantPrio[c]; ok {
+			prioritized[p] = append(prioritized[p], c)
+		} else {
+			def = append(def, c)
+		}
+	}
+
+	if len(def) > 0 {
+		s.bs.wm.WantBlocksTTL(ctx, def, s.activePeersArr, s.id, defaultWantTTL)
+	}
+	for p, keys := range prioritized {
+		s.bs.wm.WantBlocksPrio(ctx, keys, s.activePeersArr, p, s.id, defaultWantTTL)
+	}
 }
 
 func (s *Session) cancel(keys []cid.Cid) {
 	for _, c := range keys {
 		s.tofetch.Remove(c)
+		delete(s.wantPrio, c)
 	}
 }
 
@@ -286,20 +319,139 @@ func (s *Session) cancelWants(keys []cid.Cid) {
 	}
 }
 
-func (s *Session) fetch(ctx context.Context, keys []cid.Cid) {
+// wantReq is a request for a set of keys at an optional base priority, where
+// a zero priority means the wantmanager default.
+type wantReq struct {
+	keys []cid.Cid
+	prio int
+}
+
+func (s *Session) fetch(ctx context.Context, keys []cid.Cid, prio int) {
 	select {
-	case s.newReqs <- keys:
+	case s.newReqs <- wantReq{keys: keys, prio: prio}:
 	case <-ctx.Done():
 	case <-s.ctx.Done():
 	}
 }
 
+// BlockResult is the terminal outcome of a single wanted key, carrying either
+// the received block or the error that ended the want.
+type BlockResult struct {
+	Block blocks.Block
+	Err   error
+}
+
+// WantBlocksFutures requests the given keys within the session at the given
+// base priority (zero means the default) and returns a future channel per
+// cid. Each future receives exactly one BlockResult — the block on success,
+// or the error that terminated the want (such as the context expiring before
+// the block became available) — and is then closed.
+func (s *Session) WantBlocksFutures(ctx context.Context, keys []cid.Cid, prio int) map[cid.Cid]<-chan BlockResult {
+	out := make(map[cid.Cid]<-chan BlockResult, len(keys))
+	if len(keys) == 0 {
+		return out
+	}
+
+	ctx = logging.ContextWithLoggable(ctx, s.uuid)
+
+	chans := make(map[cid.Cid]chan BlockResult, len(keys))
+	remaining := cid.NewSet()
+	for _, k := range keys {
+		log.Event(ctx, "Bitswap.GetBlockRequest.Start", k)
+		ch := make(chan BlockResult, 1)
+		chans[k] = ch
+		out[k] = ch
+		remaining.Add(k)
+	}
+
+	promise := s.notif.Subscribe(ctx, keys...)
+	s.fetch(ctx, keys, prio)
+
+	go func() {
+		defer func() {
+			s.cancelWants(remaining.Keys())
+
+			err := ctx.Err()
+			if err == nil {
+				err = s.ctx.Err()
+			}
+			if err == nil {
+				err = errors.New("promise channel was closed")
+			}
+			for _, k := range remaining.Keys() {
+				chans[k] <- BlockResult{Err: err}
+				close(chans[k])
+			}
+		}()
+
+		for remaining.Len() > 0 {
+			select {
+			case blk, ok := <-promise:
+				if !ok {
+					return
+				}
+
+				c := blk.Cid()
+				if !remaining.Has(c) {
+					continue
+				}
+				remaining.Remove(c)
+				chans[c] <- BlockResult{Block: blk}
+				close(chans[c])
+			case <-ctx.Done():
+				return
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
 // GetBlocks fetches a set of blocks within the context of this session and
 // returns a channel that found blocks will be returned on. No order is
-// guaranteed on the returned blocks.
+// guaranteed on the returned blocks. It is implemented in terms of
+// WantBlocksFutures, dropping per-key errors to keep the channel semantics
+// of the Fetcher interface.
 func (s *Session) GetBlocks(ctx context.Context, keys []cid.Cid) (<-chan blocks.Block, error) {
-	ctx = logging.ContextWithLoggable(ctx, s.uuid)
-	return getBlocksImpl(ctx, keys, s.notif, s.fetch, s.cancelWants)
+	out := make(chan blocks.Block)
+	if len(keys) == 0 {
+		close(out)
+		return out, nil
+	}
+
+	futures := s.WantBlocksFutures(ctx, keys, 0)
+
+	go func() {
+		defer close(out)
+
+		var wg sync.WaitGroup
+		wg.Add(len(futures))
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		for _, fut := range futures {
+			go func(fut <-chan BlockResult) {
+				defer wg.Done()
+				res, ok := <-fut
+				if !ok || res.Err != nil {
+					return
+				}
+				select {
+				case out <- res.Block:
+				case <-ctx.Done():
+				}
+			}(fut)
+		}
+
+		<-done
+	}()
+
+	return out, nil
 }
 
 // GetBlock fetches a single block
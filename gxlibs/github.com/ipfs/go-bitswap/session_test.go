@@ -323,3 +323,125 @@ func TestWantlistClearsOnCancel(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestSessionWantBlocksFutures(t *testing.T) {
+	vnet := getVirtualNetwork()
+	sesgen := NewTestSessionGenerator(vnet)
+	defer sesgen.Close()
+	bgen := blocksutil.NewBlockGenerator()
+
+	inst := sesgen.Instances(2)
+
+	a := inst[0]
+	b := inst[1]
+
+	available := bgen.Blocks(3)
+	for _, blk := range available {
+		if err := b.Blockstore().Put(blk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	missing := bgen.Next()
+
+	keys := []cid.Cid{missing.Cid()}
+	for _, blk := range available {
+		keys = append(keys, blk.Cid())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ses := a.Exchange.NewSession(ctx).(*Session)
+	futures := ses.WantBlocksFutures(ctx, keys, 0)
+
+	if len(futures) != len(keys) {
+		t.Fatalf("expected %d futures, got %d", len(keys), len(futures))
+	}
+
+	for _, blk := range available {
+		res, ok := <-futures[blk.Cid()]
+		if !ok {
+			t.Fatal("future closed without a result")
+		}
+		if res.Err != nil {
+			t.Fatalf("unexpected error for available key: %s", res.Err)
+		}
+		if !res.Block.Cid().Equals(blk.Cid()) {
+			t.Fatal("got wrong block")
+		}
+	}
+
+	// the missing key resolves with a terminal error once the context expires
+	res, ok := <-futures[missing.Cid()]
+	if !ok {
+		t.Fatal("future closed without a result")
+	}
+	if res.Err == nil {
+		t.Fatal("expected an error for the missing key")
+	}
+	if _, ok := <-futures[missing.Cid()]; ok {
+		t.Fatal("future yielded more than one result")
+	}
+}
+
+func TestOverlappingSessions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vnet := getVirtualNetwork()
+	sesgen := NewTestSessionGenerator(vnet)
+	defer sesgen.Close()
+	bgen := blocksutil.NewBlockGenerator()
+
+	inst := sesgen.Instances(2)
+
+	blks := bgen.Blocks(50)
+	if err := inst[0].Blockstore().PutMany(blks); err != nil {
+		t.Fatal(err)
+	}
+
+	var cids []cid.Cid
+	for _, blk := range blks {
+		cids = append(cids, blk.Cid())
+	}
+
+	// two concurrent sessions on the same node fetch the same set of blocks
+	ses1 := inst[1].Exchange.NewSession(ctx)
+	ses2 := inst[1].Exchange.NewSession(ctx)
+
+	ch1, err := ses1.GetBlocks(ctx, cids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch2, err := ses2.GetBlocks(ctx, cids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got1, got2 []blocks.Block
+	for i := 0; i < len(blks); i++ {
+		got1 = append(got1, <-ch1)
+		got2 = append(got2, <-ch2)
+	}
+
+	if err := assertBlockLists(got1, blks); err != nil {
+		t.Fatal(err)
+	}
+	if err := assertBlockLists(got2, blks); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := inst[1].Exchange.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the second session's wants were answered from the first session's
+	// outstanding requests instead of going out again
+	if st.DupWantsSuppressed == 0 {
+		t.Fatal("expected overlapping wants to be suppressed")
+	}
+	if st.DupBlksReceived != 0 {
+		t.Fatalf("expected each block to arrive once, got %d duplicates", st.DupBlksReceived)
+	}
+}
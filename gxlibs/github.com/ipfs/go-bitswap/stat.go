@@ -7,21 +7,42 @@ import (
 )
 
 type Stat struct {
-	ProvideBufLen    int
-	Wantlist         []cid.Cid
-	Peers            []string
-	BlocksReceived   uint64
-	DataReceived     uint64
-	BlocksSent       uint64
-	DataSent         uint64
-	DupBlksReceived  uint64
-	DupDataReceived  uint64
-	MessagesReceived uint64
+	ProvideBufLen        int
+	ProvideBacklog       int
+	ProvidesDropped      uint64
+	ProviderDialsSkipped uint64
+	WantsShed            uint64
+	DupWantsSuppressed   uint64
+	WantsExpired         uint64
+	Wantlist             []cid.Cid
+	Peers                []string
+	BlocksReceived       uint64
+	DataReceived         uint64
+	BlocksSent           uint64
+	DataSent             uint64
+	DupBlksReceived      uint64
+	DupDataReceived      uint64
+	MessagesReceived     uint64
+	SendsInFlight        map[string]int
+
+	// History holds periodic counter snapshots when the caller asked for
+	// them, see Bitswap.StatHistory
+	History []StatSnapshot `json:",omitempty"`
 }
 
 func (bs *Bitswap) Stat() (*Stat, error) {
 	st := new(Stat)
 	st.ProvideBufLen = len(bs.newBlocks)
+	bs.provideStatLk.Lock()
+	st.ProvideBacklog = bs.provideQueueLen
+	st.ProvidesDropped = bs.provideDropped
+	bs.provideStatLk.Unlock()
+	bs.provSkipLk.Lock()
+	st.ProviderDialsSkipped = bs.provDialsSkipped
+	bs.provSkipLk.Unlock()
+	st.WantsShed = bs.wm.WantsShed()
+	st.DupWantsSuppressed = bs.wm.DupWantsSuppressed()
+	st.WantsExpired = bs.wm.WantsExpired()
 	st.Wantlist = bs.GetWantlist()
 	bs.counterLk.Lock()
 	c := bs.counters
@@ -32,6 +53,10 @@ func (bs *Bitswap) Stat() (*Stat, error) {
 	st.DataSent = c.dataSent
 	st.DataReceived = c.dataRecvd
 	st.MessagesReceived = c.messagesRecvd
+	st.SendsInFlight = make(map[string]int, len(bs.sendsInFlight))
+	for p, n := range bs.sendsInFlight {
+		st.SendsInFlight[p.Pretty()] = n
+	}
 	bs.counterLk.Unlock()
 
 	peers := bs.engine.Peers()
@@ -44,3 +69,11 @@ func (bs *Bitswap) Stat() (*Stat, error) {
 
 	return st, nil
 }
+
+// ResetStats zeroes the transfer counters reported by Stat, so callers can
+// measure the traffic of a bounded phase of work in isolation.
+func (bs *Bitswap) ResetStats() {
+	bs.counterLk.Lock()
+	bs.counters = new(counters)
+	bs.counterLk.Unlock()
+}
@@ -0,0 +1,116 @@
+package bitswap
+
+import (
+	"sync"
+	"time"
+
+	process "github.com/ipsn/go-ipfs/gxlibs/github.com/jbenet/goprocess"
+)
+
+// StatHistoryInterval is how often a snapshot of the transfer counters is
+// recorded into the in-memory history.
+var StatHistoryInterval = time.Minute
+
+// StatHistoryLength bounds how many snapshots are kept. The history is a
+// ring, older snapshots get overwritten, so its memory use is fixed.
+var StatHistoryLength = 180
+
+// StatSnapshot is one periodic sample of the bitswap counters, used to see
+// how throughput develops over time rather than just the current totals.
+type StatSnapshot struct {
+	Timestamp      time.Time
+	BlocksSent     uint64
+	DataSent       uint64
+	BlocksReceived uint64
+	DataReceived   uint64
+	WantlistSize   int
+	Peers          int
+}
+
+// statHistory is a fixed-size ring buffer of counter snapshots.
+type statHistory struct {
+	lk   sync.Mutex
+	buf  []StatSnapshot
+	next int
+	full bool
+}
+
+func newStatHistory(length int) *statHistory {
+	return &statHistory{buf: make([]StatSnapshot, length)}
+}
+
+func (h *statHistory) add(s StatSnapshot) {
+	h.lk.Lock()
+	h.buf[h.next] = s
+	h.next++
+	if h.next == len(h.buf) {
+		h.next = 0
+		h.full = true
+	}
+	h.lk.Unlock()
+}
+
+// since returns the recorded snapshots stamped at or after t, oldest first.
+func (h *statHistory) since(t time.Time) []StatSnapshot {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+
+	start, n := 0, h.next
+	if h.full {
+		start, n = h.next, len(h.buf)
+	}
+
+	var out []StatSnapshot
+	for i := 0; i < n; i++ {
+		s := h.buf[(start+i)%len(h.buf)]
+		if s.Timestamp.Before(t) {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// StatHistory returns the recorded counter snapshots covering the last span,
+// oldest first. A non-positive span returns the whole buffer.
+func (bs *Bitswap) StatHistory(span time.Duration) []StatSnapshot {
+	if span <= 0 {
+		return bs.history.since(time.Time{})
+	}
+	return bs.history.since(time.Now().Add(-span))
+}
+
+// recordStatSnapshot samples the counters into the history, stamped with the
+// given time. The stat history worker calls this on every tick; tests call
+// it directly with fabricated timestamps.
+func (bs *Bitswap) recordStatSnapshot(now time.Time) {
+	snap := StatSnapshot{Timestamp: now}
+
+	bs.counterLk.Lock()
+	c := bs.counters
+	snap.BlocksSent = c.blocksSent
+	snap.DataSent = c.dataSent
+	snap.BlocksReceived = c.blocksRecvd
+	snap.DataReceived = c.dataRecvd
+	bs.counterLk.Unlock()
+
+	snap.WantlistSize = len(bs.GetWantlist())
+	snap.Peers = len(bs.engine.Peers())
+
+	bs.history.add(snap)
+}
+
+// statHistoryWorker records a counter snapshot every StatHistoryInterval.
+func (bs *Bitswap) statHistoryWorker(px process.Process) {
+	tick := time.NewTicker(StatHistoryInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case now := <-tick.C:
+			bs.recordStatSnapshot(now)
+		case <-px.Closing():
+			return
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package bitswap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tn "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/testnet"
+
+	blocksutil "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-blocksutil"
+	delay "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-delay"
+	mockrouting "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-routing/mock"
+)
+
+func TestStatHistoryRing(t *testing.T) {
+	base := time.Unix(1500000000, 0)
+	h := newStatHistory(4)
+
+	for i := 0; i < 6; i++ {
+		h.add(StatSnapshot{Timestamp: base.Add(time.Duration(i) * time.Minute), BlocksSent: uint64(i)})
+	}
+
+	// the ring keeps only the last 4 snapshots, oldest first
+	all := h.since(time.Time{})
+	if len(all) != 4 {
+		t.Fatalf("expected 4 snapshots, got %d", len(all))
+	}
+	for i, s := range all {
+		if s.BlocksSent != uint64(i+2) {
+			t.Errorf("unexpected snapshot %d: %d", i, s.BlocksSent)
+		}
+	}
+
+	// the since filter cuts off older samples
+	recent := h.since(base.Add(4 * time.Minute))
+	if len(recent) != 2 || recent[0].BlocksSent != 4 {
+		t.Fatalf("unexpected filtered snapshots: %v", recent)
+	}
+}
+
+func TestStatHistoryReflectsTraffic(t *testing.T) {
+	net := tn.VirtualNetwork(mockrouting.NewServer(), delay.Fixed(kNetworkDelay))
+	sg := NewTestSessionGenerator(net)
+	defer sg.Close()
+	bg := blocksutil.NewBlockGenerator()
+
+	instances := sg.Instances(2)
+	blocks := bg.Blocks(1)
+
+	// a fake clock: snapshots are stamped by hand so the test does not
+	// depend on the worker's real ticker
+	clock := time.Unix(1500000000, 0)
+
+	// quiet window, before any traffic
+	instances[1].Exchange.recordStatSnapshot(clock)
+
+	if err := instances[0].Exchange.HasBlock(blocks[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if _, err := instances[1].Exchange.GetBlock(ctx, blocks[0].Cid()); err != nil {
+		t.Fatal(err)
+	}
+
+	// active window, after the fetch
+	clock = clock.Add(StatHistoryInterval)
+	instances[1].Exchange.recordStatSnapshot(clock)
+
+	series := instances[1].Exchange.StatHistory(0)
+	if len(series) != 2 {
+		t.Fatalf("expected 2 snapshots, got %d", len(series))
+	}
+
+	if series[0].BlocksReceived != 0 {
+		t.Errorf("expected no blocks received in the quiet window, got %d", series[0].BlocksReceived)
+	}
+	if series[1].BlocksReceived != 1 {
+		t.Errorf("expected 1 block received in the active window, got %d", series[1].BlocksReceived)
+	}
+	if series[1].DataReceived == 0 {
+		t.Error("expected received bytes in the active window")
+	}
+	if !series[0].Timestamp.Before(series[1].Timestamp) {
+		t.Error("expected the series to be ordered oldest first")
+	}
+
+	for _, inst := range instances {
+		if err := inst.Exchange.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
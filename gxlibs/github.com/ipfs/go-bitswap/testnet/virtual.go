@@ -209,6 +209,14 @@ func (nc *networkClient) SetDelegate(r bsnet.Receiver) {
 	nc.Receiver = r
 }
 
+func (nc *networkClient) Connected(p peer.ID) bool {
+	nc.network.mu.Lock()
+	defer nc.network.mu.Unlock()
+
+	_, ok := nc.network.conns[tagForPeers(nc.local, p)]
+	return ok
+}
+
 func (nc *networkClient) ConnectTo(_ context.Context, p peer.ID) error {
 	nc.network.mu.Lock()
 
@@ -5,6 +5,7 @@ package wantlist
 import (
 	"sort"
 	"sync"
+	"time"
 
 	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
 )
@@ -23,6 +24,13 @@ type Entry struct {
 	Cid      cid.Cid
 	Priority int
 
+	// Created is when the entry was first added to the wantlist
+	Created time.Time
+
+	// Expires is the deadline after which the want may be dropped without
+	// being served, the zero value means it never expires
+	Expires time.Time
+
 	SesTrk map[uint64]struct{}
 	// Trash in a book-keeping field
 	Trash bool
@@ -33,10 +41,27 @@ func NewRefEntry(c cid.Cid, p int) *Entry {
 	return &Entry{
 		Cid:      c,
 		Priority: p,
+		Created:  time.Now(),
 		SesTrk:   make(map[uint64]struct{}),
 	}
 }
 
+// SetTTL marks the entry to expire ttl after now. A non-positive ttl clears
+// the deadline, letting the entry live until it is cancelled.
+func (e *Entry) SetTTL(ttl time.Duration, now time.Time) {
+	if ttl <= 0 {
+		e.Expires = time.Time{}
+		return
+	}
+	e.Expires = now.Add(ttl)
+}
+
+// Expired reports whether the entry's deadline has passed at now. Entries
+// without a deadline never expire.
+func (e *Entry) Expired(now time.Time) bool {
+	return !e.Expires.IsZero() && now.After(e.Expires)
+}
+
 type entrySlice []*Entry
 
 func (es entrySlice) Len() int           { return len(es) }
@@ -74,6 +99,7 @@ func (w *ThreadSafe) Add(c cid.Cid, priority int, ses uint64) bool {
 	w.set[c] = &Entry{
 		Cid:      c,
 		Priority: priority,
+		Created:  time.Now(),
 		SesTrk:   map[uint64]struct{}{ses: struct{}{}},
 	}
 
@@ -86,6 +112,11 @@ func (w *ThreadSafe) AddEntry(e *Entry, ses uint64) bool {
 	defer w.lk.Unlock()
 	if ex, ok := w.set[e.Cid]; ok {
 		ex.SesTrk[ses] = struct{}{}
+		// a re-request refreshes the deadline so active interest is never
+		// expired out from under a live session
+		if e.Expires.IsZero() || ex.Expires.Before(e.Expires) {
+			ex.Expires = e.Expires
+		}
 		return false
 	}
 	w.set[e.Cid] = e
@@ -113,6 +144,38 @@ func (w *ThreadSafe) Remove(c cid.Cid, ses uint64) bool {
 	return false
 }
 
+// TrackedByOthers returns true if the given cid is in the wantlist tracked
+// by at least one session other than 'ses'.
+func (w *ThreadSafe) TrackedByOthers(c cid.Cid, ses uint64) bool {
+	w.lk.RLock()
+	defer w.lk.RUnlock()
+	e, ok := w.set[c]
+	if !ok {
+		return false
+	}
+	for s := range e.SesTrk {
+		if s != ses {
+			return true
+		}
+	}
+	return false
+}
+
+// Expire removes every entry whose deadline has passed at now, regardless of
+// the sessions still tracking it, and returns the removed entries.
+func (w *ThreadSafe) Expire(now time.Time) []*Entry {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	var expired []*Entry
+	for c, e := range w.set {
+		if e.Expired(now) {
+			delete(w.set, c)
+			expired = append(expired, e)
+		}
+	}
+	return expired
+}
+
 // Contains returns true if the given cid is in the wantlist tracked by one or
 // more sessions
 func (w *ThreadSafe) Contains(k cid.Cid) (*Entry, bool) {
@@ -156,6 +219,7 @@ func (w *Wantlist) Add(c cid.Cid, priority int) bool {
 	w.set[c] = &Entry{
 		Cid:      c,
 		Priority: priority,
+		Created:  time.Now(),
 	}
 
 	return true
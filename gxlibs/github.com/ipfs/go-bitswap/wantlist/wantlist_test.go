@@ -2,6 +2,7 @@ package wantlist
 
 import (
 	"testing"
+	"time"
 
 	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
 )
@@ -82,6 +83,64 @@ func TestBasicWantlist(t *testing.T) {
 	}
 }
 
+func TestWantExpiry(t *testing.T) {
+	wl := NewThreadSafe()
+	// all deadlines are computed from this fixed instant, the clock is
+	// advanced by passing later times to Expire
+	now := time.Now()
+
+	e := NewRefEntry(testcids[0], 5)
+	e.SetTTL(time.Hour, now)
+	if !wl.AddEntry(e, 1) {
+		t.Fatal("should have added")
+	}
+
+	// nothing expires before the deadline
+	if exp := wl.Expire(now.Add(30 * time.Minute)); len(exp) != 0 {
+		t.Fatalf("expired %d entries before the deadline", len(exp))
+	}
+	assertHasCid(t, wl, testcids[0])
+
+	// a re-request from another session pushes the deadline out
+	re := NewRefEntry(testcids[0], 5)
+	re.SetTTL(2*time.Hour, now)
+	if wl.AddEntry(re, 2) {
+		t.Fatal("shouldnt have added")
+	}
+	if exp := wl.Expire(now.Add(90 * time.Minute)); len(exp) != 0 {
+		t.Fatal("expired an entry whose deadline was refreshed")
+	}
+	assertHasCid(t, wl, testcids[0])
+
+	// past the refreshed deadline the entry is dropped for all sessions
+	exp := wl.Expire(now.Add(3 * time.Hour))
+	if len(exp) != 1 || !exp[0].Cid.Equals(testcids[0]) {
+		t.Fatalf("expected the entry to expire, got %v", exp)
+	}
+	assertNotHasCid(t, wl, testcids[0])
+
+	// entries without a deadline never expire
+	if !wl.AddEntry(NewRefEntry(testcids[1], 5), 1) {
+		t.Fatal("should have added")
+	}
+	if exp := wl.Expire(now.Add(1000 * time.Hour)); len(exp) != 0 {
+		t.Fatal("expired an entry without a deadline")
+	}
+
+	// a deadline-less re-request clears the deadline entirely
+	e = NewRefEntry(testcids[2], 5)
+	e.SetTTL(time.Minute, now)
+	if !wl.AddEntry(e, 1) {
+		t.Fatal("should have added")
+	}
+	if wl.AddEntry(NewRefEntry(testcids[2], 5), 2) {
+		t.Fatal("shouldnt have added")
+	}
+	if exp := wl.Expire(now.Add(time.Hour)); len(exp) != 0 {
+		t.Fatal("expired an entry after its deadline was cleared")
+	}
+}
+
 func TestSesRefWantlist(t *testing.T) {
 	wl := NewThreadSafe()
 
@@ -3,6 +3,8 @@ package wantmanager
 import (
 	"context"
 	"math"
+	"sync/atomic"
+	"time"
 
 	engine "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/decision"
 	bsmsg "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/message"
@@ -21,6 +23,10 @@ var log = logging.Logger("bitswap")
 const (
 	// kMaxPriority is the max priority as defined by the bitswap protocol
 	kMaxPriority = math.MaxInt32
+
+	// wantExpiryInterval is how often the wantlist is swept for wants whose
+	// TTL has lapsed
+	wantExpiryInterval = time.Minute
 )
 
 var (
@@ -42,8 +48,24 @@ type WantManager struct {
 	ctx     context.Context
 	cancel  func()
 
-	wantlistGauge metrics.Gauge
-	sentHistogram metrics.Histogram
+	// maxWants caps the number of distinct keys held in the wantlist, zero
+	// means unbounded. Must be set before Run is started.
+	maxWants  int
+	wantsShed uint64
+
+	// dupWantsSuppressed counts wants that were not sent out because the key
+	// was already wanted by another live session
+	dupWantsSuppressed uint64
+
+	// wantsExpired counts wants dropped because their TTL lapsed before
+	// they were served or cancelled
+	wantsExpired uint64
+
+	wantlistGauge  metrics.Gauge
+	shedCounter    metrics.Counter
+	dupCounter     metrics.Counter
+	expiredCounter metrics.Counter
+	sentHistogram  metrics.Histogram
 }
 
 type peerStatus struct {
@@ -55,29 +77,79 @@ func New(ctx context.Context, network bsnet.BitSwapNetwork) *WantManager {
 	ctx, cancel := context.WithCancel(ctx)
 	wantlistGauge := metrics.NewCtx(ctx, "wantlist_total",
 		"Number of items in wantlist.").Gauge()
+	shedCounter := metrics.NewCtx(ctx, "wants_shed_total",
+		"Number of wants refused because the wantlist was at its cap.").Counter()
+	dupCounter := metrics.NewCtx(ctx, "dup_wants_suppressed_total",
+		"Number of wants not sent because another session already wants the key.").Counter()
+	expiredCounter := metrics.NewCtx(ctx, "wants_expired_total",
+		"Number of wants dropped because their TTL lapsed.").Counter()
 	sentHistogram := metrics.NewCtx(ctx, "sent_all_blocks_bytes", "Histogram of blocks sent by"+
 		" this bitswap").Histogram(metricsBuckets)
 	return &WantManager{
-		incoming:      make(chan *wantSet, 10),
-		connectEvent:  make(chan peerStatus, 10),
-		peerReqs:      make(chan chan []peer.ID),
-		peers:         make(map[peer.ID]*bsmq.MessageQueue),
-		wl:            wantlist.NewThreadSafe(),
-		bcwl:          wantlist.NewThreadSafe(),
-		network:       network,
-		ctx:           ctx,
-		cancel:        cancel,
-		wantlistGauge: wantlistGauge,
-		sentHistogram: sentHistogram,
+		incoming:       make(chan *wantSet, 10),
+		connectEvent:   make(chan peerStatus, 10),
+		peerReqs:       make(chan chan []peer.ID),
+		peers:          make(map[peer.ID]*bsmq.MessageQueue),
+		wl:             wantlist.NewThreadSafe(),
+		bcwl:           wantlist.NewThreadSafe(),
+		network:        network,
+		ctx:            ctx,
+		cancel:         cancel,
+		wantlistGauge:  wantlistGauge,
+		shedCounter:    shedCounter,
+		dupCounter:     dupCounter,
+		expiredCounter: expiredCounter,
+		sentHistogram:  sentHistogram,
 	}
 }
 
+// SetMaxWants caps the number of distinct keys the wantlist will hold. New
+// wants beyond the cap are shed until older ones resolve. Must be called
+// before Run is started.
+func (wm *WantManager) SetMaxWants(n int) {
+	wm.maxWants = n
+}
+
+// WantsShed returns the number of wants refused because the wantlist was at
+// its configured cap.
+func (wm *WantManager) WantsShed() uint64 {
+	return atomic.LoadUint64(&wm.wantsShed)
+}
+
+// DupWantsSuppressed returns the number of wants that were not sent to the
+// network because the key was already wanted by another live session.
+func (wm *WantManager) DupWantsSuppressed() uint64 {
+	return atomic.LoadUint64(&wm.dupWantsSuppressed)
+}
+
+// WantsExpired returns the number of wants dropped because their TTL lapsed
+// before they were served or cancelled.
+func (wm *WantManager) WantsExpired() uint64 {
+	return atomic.LoadUint64(&wm.wantsExpired)
+}
+
 // WantBlocks adds the given cids to the wantlist, tracked by the given session
 func (wm *WantManager) WantBlocks(ctx context.Context, ks []cid.Cid, peers []peer.ID, ses uint64) {
 	log.Infof("want blocks: %s", ks)
 	wm.addEntries(ctx, ks, peers, false, ses)
 }
 
+// WantBlocksTTL is like WantBlocks but drops the wants after ttl if they are
+// still unresolved by then. A re-request of the same key extends the
+// deadline. A non-positive ttl means the wants never expire.
+func (wm *WantManager) WantBlocksTTL(ctx context.Context, ks []cid.Cid, peers []peer.ID, ses uint64, ttl time.Duration) {
+	log.Infof("want blocks (ttl %s): %s", ttl, ks)
+	wm.addEntriesPrio(ctx, ks, peers, kMaxPriority, false, ses, ttl)
+}
+
+// WantBlocksPrio is like WantBlocks but assigns the given base priority to
+// the new entries instead of the default maximum, and bounds their lifetime
+// by ttl like WantBlocksTTL does.
+func (wm *WantManager) WantBlocksPrio(ctx context.Context, ks []cid.Cid, peers []peer.ID, prio int, ses uint64, ttl time.Duration) {
+	log.Infof("want blocks (prio %d): %s", prio, ks)
+	wm.addEntriesPrio(ctx, ks, peers, prio, false, ses, ttl)
+}
+
 // CancelWants removes the given cids from the wantlist, tracked by the given session
 func (wm *WantManager) CancelWants(ctx context.Context, ks []cid.Cid, peers []peer.ID, ses uint64) {
 	wm.addEntries(context.Background(), ks, peers, true, ses)
@@ -90,11 +162,18 @@ type wantSet struct {
 }
 
 func (wm *WantManager) addEntries(ctx context.Context, ks []cid.Cid, targets []peer.ID, cancel bool, ses uint64) {
+	wm.addEntriesPrio(ctx, ks, targets, kMaxPriority, cancel, ses, 0)
+}
+
+func (wm *WantManager) addEntriesPrio(ctx context.Context, ks []cid.Cid, targets []peer.ID, prio int, cancel bool, ses uint64, ttl time.Duration) {
+	now := time.Now()
 	entries := make([]*bsmsg.Entry, 0, len(ks))
 	for i, k := range ks {
+		e := wantlist.NewRefEntry(k, prio-i)
+		e.SetTTL(ttl, now)
 		entries = append(entries, &bsmsg.Entry{
 			Cancel: cancel,
-			Entry:  wantlist.NewRefEntry(k, kMaxPriority-i),
+			Entry:  e,
 		})
 	}
 	select {
@@ -176,6 +255,9 @@ func (wm *WantManager) Disconnected(p peer.ID) {
 func (wm *WantManager) Run() {
 	// NOTE: Do not open any streams or connections from anywhere in this
 	// event loop. Really, just don't do anything likely to block.
+	expiry := time.NewTicker(wantExpiryInterval)
+	defer expiry.Stop()
+
 	for {
 		select {
 		case ws := <-wm.incoming:
@@ -183,7 +265,9 @@ func (wm *WantManager) Run() {
 			// is this a broadcast or not?
 			brdc := len(ws.targets) == 0
 
-			// add changes to our wantlist
+			// add changes to our wantlist, dropping new wants over the
+			// configured cap so they are neither stored nor sent out
+			kept := make([]*bsmsg.Entry, 0, len(ws.entries))
 			for _, e := range ws.entries {
 				if e.Cancel {
 					if brdc {
@@ -192,21 +276,46 @@ func (wm *WantManager) Run() {
 
 					if wm.wl.Remove(e.Cid, ws.from) {
 						wm.wantlistGauge.Dec()
+					} else if _, still := wm.wl.Contains(e.Cid); still {
+						// another live session still wants the key; don't
+						// cancel its interest with our peers
+						continue
 					}
 				} else {
+					if wm.maxWants > 0 && wm.wl.Len() >= wm.maxWants {
+						if _, known := wm.wl.Contains(e.Cid); !known {
+							atomic.AddUint64(&wm.wantsShed, 1)
+							wm.shedCounter.Inc()
+							continue
+						}
+					}
 					if brdc {
 						wm.bcwl.AddEntry(e.Entry, ws.from)
 					}
 					if wm.wl.AddEntry(e.Entry, ws.from) {
 						wm.wantlistGauge.Inc()
+					} else if wm.wl.TrackedByOthers(e.Cid, ws.from) {
+						// a different live session already wants the key,
+						// so its want is out on the network and the block
+						// will be shared via the notification engine; the
+						// peer queues hold the want and won't resend it.
+						// Keep the entry so a session's rebroadcast still
+						// reaches peers discovered since the first want.
+						atomic.AddUint64(&wm.dupWantsSuppressed, 1)
+						wm.dupCounter.Inc()
 					}
 				}
+				kept = append(kept, e)
+			}
+
+			if len(kept) == 0 {
+				continue
 			}
 
 			// broadcast those wantlist changes
 			if len(ws.targets) == 0 {
 				for _, p := range wm.peers {
-					p.AddMessage(ws.entries, ws.from)
+					p.AddMessage(kept, ws.from)
 				}
 			} else {
 				for _, t := range ws.targets {
@@ -215,7 +324,7 @@ func (wm *WantManager) Run() {
 						log.Infof("tried sending wantlist change to non-partner peer: %s", t)
 						continue
 					}
-					p.AddMessage(ws.entries, ws.from)
+					p.AddMessage(kept, ws.from)
 				}
 			}
 
@@ -231,12 +340,43 @@ func (wm *WantManager) Run() {
 				peers = append(peers, p)
 			}
 			req <- peers
+		case <-expiry.C:
+			wm.expireWants()
 		case <-wm.ctx.Done():
 			return
 		}
 	}
 }
 
+// expireWants drops every want whose TTL has lapsed, cancelling it with our
+// peers. Expired wants are removed for all tracking sessions; a session
+// still interested in the key will re-request it and start a fresh TTL.
+func (wm *WantManager) expireWants() {
+	now := time.Now()
+	expired := wm.wl.Expire(now)
+	if len(expired) == 0 {
+		return
+	}
+	// the broadcast wantlist shares its entries with the main one
+	wm.bcwl.Expire(now)
+
+	cancels := make([]*bsmsg.Entry, 0, len(expired))
+	for _, e := range expired {
+		log.Debugf("expiring want for %s", e.Cid)
+		atomic.AddUint64(&wm.wantsExpired, 1)
+		wm.expiredCounter.Inc()
+		wm.wantlistGauge.Dec()
+		cancels = append(cancels, &bsmsg.Entry{
+			Cancel: true,
+			Entry:  e,
+		})
+	}
+
+	for _, p := range wm.peers {
+		p.AddMessage(cancels, 0)
+	}
+}
+
 func (wm *WantManager) IsWanted(c cid.Cid) bool {
 	_, isWanted := wm.wl.Contains(c)
 	return isWanted
@@ -3,10 +3,13 @@ package bitswap
 import (
 	"context"
 	"math/rand"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	bsmsg "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/message"
+	wantlist "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/wantlist"
 
 	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
 	logging "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-log"
@@ -17,6 +20,54 @@ import (
 
 var TaskWorkerCount = 8
 
+// PeerSendConcurrency is the maximum number of envelopes task workers will
+// send to a single peer concurrently, so one slow peer cannot occupy the
+// whole worker pool. Envelopes for saturated peers are put back on the
+// engine's queue.
+var PeerSendConcurrency = 2
+
+// RebroadcastBatchSize is the maximum number of wantlist keys provider
+// searches are started for on each rebroadcast interval. The interval itself
+// is governed by rebroadcastDelay.
+var RebroadcastBatchSize = 16
+
+// RebroadcastMaxDelay caps the per-key exponential backoff between provider
+// searches for a key that keeps going unreceived.
+var RebroadcastMaxDelay = 30 * time.Minute
+
+// ProviderConnectRetries is the number of times a failed connection to a
+// discovered provider is retried before the provider is dropped.
+var ProviderConnectRetries = 2
+
+// ProviderConnectBackoff is the initial delay before retrying a failed
+// provider connection. It is doubled on each subsequent retry.
+var ProviderConnectBackoff = time.Millisecond * 200
+
+// ProviderQueryWorkerCount is the number of workers serving blockRequests
+// from the findKeys channel. Each worker handles one key at a time, so it
+// also bounds the number of provider searches in flight.
+var ProviderQueryWorkerCount = 6
+
+// ProviderDialMax bounds the number of concurrent connection attempts to
+// discovered providers, shared across all provider searches, so a burst of
+// cache misses can't exhaust file descriptors with dial goroutines.
+var ProviderDialMax = 32
+
+// ProviderDialBackoff is how long a provider that could not be dialed is
+// skipped by the provider query workers before it is tried again, so a
+// persistently unreachable provider isn't re-dialed for every key it is
+// listed for.
+var ProviderDialBackoff = 5 * time.Minute
+
+// SessionProviderCacheTTL is how long providers found for a GetBlocks
+// session stay cached without the session requesting another search. Blocks
+// of a single fetch almost always live on the same providers, so later keys
+// of a session try the cached peers before walking the routing system again.
+var SessionProviderCacheTTL = 2 * time.Minute
+
+// sessionProviderCacheSize caps the providers remembered per session.
+const sessionProviderCacheSize = 8
+
 func (bs *Bitswap) startWorkers(px process.Process, ctx context.Context) {
 	// Start up a worker to handle block requests this node is making
 	px.Go(func(px process.Process) {
@@ -24,12 +75,11 @@ func (bs *Bitswap) startWorkers(px process.Process, ctx context.Context) {
 	})
 
 	// Start up workers to handle requests from other nodes for the data on this node
-	for i := 0; i < TaskWorkerCount; i++ {
-		i := i
-		px.Go(func(px process.Process) {
-			bs.taskWorker(ctx, i)
-		})
+	bs.taskWorkerLk.Lock()
+	for i := 0; i < bs.taskWorkerCount; i++ {
+		bs.startTaskWorker(i)
 	}
+	bs.taskWorkerLk.Unlock()
 
 	// Start up a worker to manage periodically resending our wantlist out to peers
 	px.Go(func(px process.Process) {
@@ -45,9 +95,118 @@ func (bs *Bitswap) startWorkers(px process.Process, ctx context.Context) {
 	// consider increasing number if providing blocks bottlenecks
 	// file transfers
 	px.Go(bs.provideWorker)
+
+	// Start up a worker to periodically snapshot the transfer counters
+	px.Go(bs.statHistoryWorker)
 }
 
-func (bs *Bitswap) taskWorker(ctx context.Context, id int) {
+// startTaskWorker spawns a single task worker on the bitswap process group.
+// The caller must hold taskWorkerLk. Workers get the instance context rather
+// than a process-bound one: on Close the process closing signal stops them
+// from taking new envelopes, while the instance context stays live for the
+// shutdown grace period so a send already started can finish.
+func (bs *Bitswap) startTaskWorker(id int) {
+	stop := make(chan struct{})
+	bs.taskWorkerStop = append(bs.taskWorkerStop, stop)
+	bs.process.Go(func(px process.Process) {
+		bs.taskWorker(bs.ctx, id, stop)
+	})
+}
+
+// drainSends blocks until no envelope sends are in flight, up to the
+// configured grace period. It runs between the process starting to close and
+// the hard context cancellation, so useful work already pulled off the
+// engine's outbox isn't cut off mid-send. Sends outliving the grace period
+// are logged and aborted by the cancellation that follows.
+func (bs *Bitswap) drainSends() {
+	grace := bs.shutdownGrace
+	if grace == 0 {
+		grace = ShutdownGracePeriod
+	}
+
+	deadline := time.After(grace)
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+
+	for {
+		inflight := 0
+		bs.counterLk.Lock()
+		for _, n := range bs.sendsInFlight {
+			inflight += n
+		}
+		bs.counterLk.Unlock()
+
+		if inflight == 0 {
+			return
+		}
+
+		select {
+		case <-deadline:
+			log.Warningf("bitswap shutdown: aborting %d envelope sends still in flight after %s", inflight, grace)
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// SetTaskWorkerCount resizes the pool of task workers serving other peers'
+// block requests, spawning new workers or signalling excess ones to exit
+// after they finish their current envelope. The count is clamped to at
+// least one worker.
+func (bs *Bitswap) SetTaskWorkerCount(n int) {
+	if n < 1 {
+		n = 1
+	}
+
+	bs.taskWorkerLk.Lock()
+	defer bs.taskWorkerLk.Unlock()
+
+	for len(bs.taskWorkerStop) > n {
+		last := len(bs.taskWorkerStop) - 1
+		close(bs.taskWorkerStop[last])
+		bs.taskWorkerStop = bs.taskWorkerStop[:last]
+	}
+	for len(bs.taskWorkerStop) < n {
+		bs.startTaskWorker(len(bs.taskWorkerStop))
+	}
+	bs.taskWorkerCount = n
+}
+
+// tryAcquireSendSlot reserves one of the peer's send slots, returning false
+// when the peer already has the maximum number of envelopes in flight.
+func (bs *Bitswap) tryAcquireSendSlot(p peer.ID) bool {
+	max := bs.peerSendConcurrency
+	if max == 0 {
+		max = PeerSendConcurrency
+	}
+
+	bs.counterLk.Lock()
+	defer bs.counterLk.Unlock()
+
+	if bs.sendsInFlight[p] >= max {
+		return false
+	}
+	bs.sendsInFlight[p]++
+	return true
+}
+
+// releaseSendSlot returns the peer's send slot and wakes a worker that may
+// have set an envelope aside while all slots were taken.
+func (bs *Bitswap) releaseSendSlot(p peer.ID) {
+	bs.counterLk.Lock()
+	bs.sendsInFlight[p]--
+	if bs.sendsInFlight[p] <= 0 {
+		delete(bs.sendsInFlight, p)
+	}
+	bs.counterLk.Unlock()
+
+	select {
+	case bs.sendSlotFreed <- struct{}{}:
+	default:
+	}
+}
+
+func (bs *Bitswap) taskWorker(ctx context.Context, id int, stop <-chan struct{}) {
 	idmap := logging.LoggableMap{"ID": id}
 	defer log.Debug("bitswap task worker shutting down...")
 	for {
@@ -59,6 +218,29 @@ func (bs *Bitswap) taskWorker(ctx context.Context, id int) {
 				if !ok {
 					continue
 				}
+				if !bs.tryAcquireSendSlot(envelope.Peer) {
+					// this peer already has the maximum number of
+					// sends in flight; mark the task done, put the
+					// blocks back on the queue and keep this worker
+					// free for other peers
+					envelope.Sent()
+					bs.engine.Requeue(envelope)
+
+					// wait for a slot to free up before popping the
+					// queue again, or we'd spin when the saturated
+					// peer is the only one with pending work
+					select {
+					case <-bs.sendSlotFreed:
+					case <-time.After(time.Millisecond * 10):
+					case <-stop:
+						return
+					case <-bs.process.Closing():
+						return
+					case <-ctx.Done():
+						return
+					}
+					continue
+				}
 				// update the BS ledger to reflect sent message
 				// TODO: Should only track *useful* messages in ledger
 				outgoing := bsmsg.New(false)
@@ -75,15 +257,24 @@ func (bs *Bitswap) taskWorker(ctx context.Context, id int) {
 				bs.engine.MessageSent(envelope.Peer, outgoing)
 
 				bs.wm.SendBlocks(ctx, envelope)
+				bs.releaseSendSlot(envelope.Peer)
 				bs.counterLk.Lock()
 				for _, block := range envelope.Message.Blocks() {
 					bs.counters.blocksSent++
 					bs.counters.dataSent += uint64(len(block.RawData()))
 				}
 				bs.counterLk.Unlock()
+			case <-stop:
+				return
+			case <-bs.process.Closing():
+				return
 			case <-ctx.Done():
 				return
 			}
+		case <-stop:
+			return
+		case <-bs.process.Closing():
+			return
 		case <-ctx.Done():
 			return
 		}
@@ -138,32 +329,112 @@ func (bs *Bitswap) provideWorker(px process.Process) {
 
 func (bs *Bitswap) provideCollector(ctx context.Context) {
 	defer close(bs.provideKeys)
-	var toProvide []cid.Cid
+	var toProvide, rootsToProvide []cid.Cid
 	var nextKey cid.Cid
 	var keysOut chan cid.Cid
 
+	syncBacklog := func() {
+		bs.provideStatLk.Lock()
+		bs.provideQueueLen = len(toProvide) + len(rootsToProvide)
+		bs.provideStatLk.Unlock()
+	}
+	dropped := func(k cid.Cid) {
+		bs.provideStatLk.Lock()
+		bs.provideDropped++
+		n := bs.provideDropped
+		bs.provideStatLk.Unlock()
+
+		// warn on the first drop and then periodically, a flood of new
+		// blocks would otherwise drown the log
+		if n == 1 || n%1024 == 0 {
+			log.Warningf("provide backlog full, dropped %d provides so far (last: %s)", n, k)
+		}
+	}
+	backlogMax := func() int {
+		max := bs.provideBacklogMax
+		if max == 0 {
+			max = ProvideBacklogMax
+		}
+		return max
+	}
+
 	for {
+		newBlocksIn := bs.newBlocks
+		if max := backlogMax(); max > 0 && ProvideBacklogDrop == BlockProducer && len(toProvide) >= max {
+			// full: stop accepting keys so HasBlock callers back up on
+			// the newBlocks buffer until the provide workers catch up
+			newBlocksIn = nil
+		}
+
 		select {
-		case blkey, ok := <-bs.newBlocks:
+		case blkey, ok := <-newBlocksIn:
 			if !ok {
 				log.Debug("newBlocks channel closed")
 				return
 			}
 
+			if bs.provideStrategy == ProvideRootsOnly {
+				// ordinary blocks are not provided at all under this
+				// strategy, only keys flagged via ProvideRoots
+				continue
+			}
+
 			if keysOut == nil {
 				nextKey = blkey
 				keysOut = bs.provideKeys
 			} else {
+				if max := backlogMax(); max > 0 && len(toProvide) >= max {
+					if ProvideBacklogDrop == DropNewest {
+						dropped(blkey)
+						continue
+					}
+					// DropOldest. Reslicing keeps the backing array
+					// bounded: append reallocates a cap-sized array
+					// once the front of the old one is exhausted.
+					dropped(toProvide[0])
+					toProvide = toProvide[1:]
+				}
 				toProvide = append(toProvide, blkey)
+				syncBacklog()
+			}
+		case rkey, ok := <-bs.rootBlocks:
+			if !ok {
+				log.Debug("rootBlocks channel closed")
+				return
+			}
+
+			// roots jump the queue of ordinary new blocks and are never
+			// dropped: they are flagged explicitly and there are few of
+			// them compared to leaves
+			if keysOut == nil {
+				nextKey = rkey
+				keysOut = bs.provideKeys
+			} else {
+				rootsToProvide = append(rootsToProvide, rkey)
+				syncBacklog()
 			}
 		case keysOut <- nextKey:
-			if len(toProvide) > 0 {
+			switch {
+			case len(rootsToProvide) > 0:
+				nextKey = rootsToProvide[0]
+				rootsToProvide = rootsToProvide[1:]
+				syncBacklog()
+			case len(toProvide) > 0:
 				nextKey = toProvide[0]
 				toProvide = toProvide[1:]
-			} else {
+				syncBacklog()
+			default:
 				keysOut = nil
 			}
 		case <-ctx.Done():
+			// keys still queued at shutdown never reach the provide
+			// workers, count and log them rather than losing them silently
+			if n := len(toProvide) + len(rootsToProvide); n > 0 {
+				log.Infof("bitswap shutdown: dropping %d queued provides", n)
+				bs.provideStatLk.Lock()
+				bs.provideDropped += uint64(n)
+				bs.provideStatLk.Unlock()
+			}
 			return
 		}
 	}
@@ -173,7 +444,9 @@ func (bs *Bitswap) rebroadcastWorker(parent context.Context) {
 	ctx, cancel := context.WithCancel(parent)
 	defer cancel()
 
-	broadcastSignal := time.NewTicker(rebroadcastDelay.Get())
+	sched := newRebroadcastScheduler(rebroadcastDelay.Get(), RebroadcastMaxDelay)
+
+	broadcastSignal := time.NewTimer(jitteredInterval(rebroadcastDelay.Get()))
 	defer broadcastSignal.Stop()
 
 	tick := time.NewTicker(10 * time.Second)
@@ -189,17 +462,24 @@ func (bs *Bitswap) rebroadcastWorker(parent context.Context) {
 			}
 		case <-broadcastSignal.C: // resend unfulfilled wantlist keys
 			log.Event(ctx, "Bitswap.Rebroadcast.active")
+			broadcastSignal.Reset(jitteredInterval(rebroadcastDelay.Get()))
+
 			entries := bs.wm.CurrentWants()
 			if len(entries) == 0 {
 				continue
 			}
 
-			// TODO: come up with a better strategy for determining when to search
-			// for new providers for blocks.
-			i := rand.Intn(len(entries))
-			bs.findKeys <- &blockRequest{
-				Cid: entries[i].Cid,
-				Ctx: ctx,
+			// search for providers for a batch of the oldest wanted keys
+			// whose backoff has elapsed, the providerQueryManager dedups
+			// against queries in flight
+			now := time.Now()
+			batch := rebroadcastBatch(sched.due(entries, now))
+			sched.searched(batch, now)
+			for _, e := range batch {
+				bs.findKeys <- &blockRequest{
+					Cid: e.Cid,
+					Ctx: ctx,
+				}
 			}
 		case <-parent.Done():
 			return
@@ -207,50 +487,417 @@ func (bs *Bitswap) rebroadcastWorker(parent context.Context) {
 	}
 }
 
+// jitteredInterval spreads the rebroadcast interval by up to ±20%, so a
+// fleet of nodes restarted together doesn't re-search the DHT in lockstep.
+func jitteredInterval(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	return d - d/5 + time.Duration(rand.Int63n(int64(2*d/5)+1))
+}
+
+// rebroadcastScheduler tracks how many provider searches each wanted key
+// has been through without being received, backing the key off
+// exponentially (capped at max) so genuinely unavailable keys aren't
+// re-searched forever at the full rate.
+type rebroadcastScheduler struct {
+	base    time.Duration
+	max     time.Duration
+	entries map[cid.Cid]*rebroadcastEntry
+}
+
+type rebroadcastEntry struct {
+	attempts int
+	next     time.Time
+}
+
+func newRebroadcastScheduler(base, max time.Duration) *rebroadcastScheduler {
+	return &rebroadcastScheduler{
+		base:    base,
+		max:     max,
+		entries: make(map[cid.Cid]*rebroadcastEntry),
+	}
+}
+
+// due filters entries down to the keys whose backoff delay has elapsed at
+// now. It also drops backoff state for keys that left the wantlist, so a
+// key that was received (or cancelled) and is wanted again later starts
+// over at the full rate.
+func (rs *rebroadcastScheduler) due(entries []*wantlist.Entry, now time.Time) []*wantlist.Entry {
+	wanted := cid.NewSet()
+	for _, e := range entries {
+		wanted.Add(e.Cid)
+	}
+	for c := range rs.entries {
+		if !wanted.Has(c) {
+			delete(rs.entries, c)
+		}
+	}
+
+	var out []*wantlist.Entry
+	for _, e := range entries {
+		if re, ok := rs.entries[e.Cid]; ok && now.Before(re.next) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// searched records a provider search for each entry, doubling the delay
+// before the key comes due again.
+func (rs *rebroadcastScheduler) searched(entries []*wantlist.Entry, now time.Time) {
+	for _, e := range entries {
+		re, ok := rs.entries[e.Cid]
+		if !ok {
+			re = &rebroadcastEntry{}
+			rs.entries[e.Cid] = re
+		}
+		re.attempts++
+		re.next = now.Add(rs.delay(re.attempts))
+	}
+}
+
+// delay returns the backoff before the next search for a key that has been
+// searched attempts times: the base interval, doubled on each unsuccessful
+// attempt after the first, capped at max.
+func (rs *rebroadcastScheduler) delay(attempts int) time.Duration {
+	d := rs.base
+	for i := 1; i < attempts && d < rs.max; i++ {
+		d *= 2
+	}
+	if d > rs.max {
+		d = rs.max
+	}
+	return d
+}
+
+// rebroadcastBatch selects up to RebroadcastBatchSize wantlist entries to
+// search providers for, oldest-wanted first so no key starves. The caller's
+// slice is left untouched.
+func rebroadcastBatch(entries []*wantlist.Entry) []*wantlist.Entry {
+	batch := make([]*wantlist.Entry, len(entries))
+	copy(batch, entries)
+	sort.Slice(batch, func(i, j int) bool {
+		return batch[i].Created.Before(batch[j].Created)
+	})
+	if len(batch) > RebroadcastBatchSize {
+		batch = batch[:RebroadcastBatchSize]
+	}
+	return batch
+}
+
+// connectWithRetry calls connect for the given peer, retrying transient dial
+// failures with exponential backoff up to ProviderConnectRetries times so a
+// flaky provider isn't permanently excluded from serving blocks.
+func connectWithRetry(ctx context.Context, connect func(context.Context, peer.ID) error, p peer.ID) error {
+	backoff := ProviderConnectBackoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = connect(ctx, p)
+		if err == nil || attempt >= ProviderConnectRetries {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+/// providerQueryState is shared between the provider query workers: the set
+// of keys with a search already in flight, the semaphore bounding dials to
+// discovered providers across all searches, and the per-session provider
+// cache.
+type providerQueryState struct {
+	lk        sync.Mutex
+	kset      *cid.Set
+	dialLimit chan struct{}
+	sessions  map[uint64]*sessionProviders
+	backoffs  map[peer.ID]time.Time
+}
+
+// sessionProviders remembers providers that were successfully connected to
+// while searching keys for one GetBlocks session.
+type sessionProviders struct {
+	peers    []peer.ID
+	lastUsed time.Time
+}
+
+// recordSessionProvider caches a successfully connected provider for the
+// given session, capped at sessionProviderCacheSize peers.
+func (st *providerQueryState) recordSessionProvider(ses uint64, p peer.ID) {
+	st.lk.Lock()
+	defer st.lk.Unlock()
+
+	sp, ok := st.sessions[ses]
+	if !ok {
+		sp = &sessionProviders{}
+		st.sessions[ses] = sp
+	}
+	sp.lastUsed = time.Now()
+	for _, q := range sp.peers {
+		if q == p {
+			return
+		}
+	}
+	if len(sp.peers) < sessionProviderCacheSize {
+		sp.peers = append(sp.peers, p)
+	}
+}
+
+// sessionProvidersFor returns the providers cached for a session, marking
+// the session as recently used.
+func (st *providerQueryState) sessionProvidersFor(ses uint64) []peer.ID {
+	st.lk.Lock()
+	defer st.lk.Unlock()
+
+	sp, ok := st.sessions[ses]
+	if !ok {
+		return nil
+	}
+	sp.lastUsed = time.Now()
+	return append([]peer.ID(nil), sp.peers...)
+}
+
+// expireSessions drops session caches that haven't been used within the TTL.
+func (st *providerQueryState) expireSessions(now time.Time) {
+	st.lk.Lock()
+	defer st.lk.Unlock()
+
+	for ses, sp := range st.sessions {
+		if now.Sub(sp.lastUsed) > SessionProviderCacheTTL {
+			delete(st.sessions, ses)
+		}
+	}
+}
+
+// inDialBackoff reports whether dialing p should be skipped because a
+// recent attempt to connect to it failed. Expired entries are dropped on
+// access.
+func (st *providerQueryState) inDialBackoff(p peer.ID, now time.Time) bool {
+	st.lk.Lock()
+	defer st.lk.Unlock()
+
+	until, ok := st.backoffs[p]
+	if !ok {
+		return false
+	}
+	if now.After(until) {
+		delete(st.backoffs, p)
+		return false
+	}
+	return true
+}
+
+// recordDialFailure puts p in dial backoff for ProviderDialBackoff.
+func (st *providerQueryState) recordDialFailure(p peer.ID) {
+	st.lk.Lock()
+	defer st.lk.Unlock()
+	st.backoffs[p] = time.Now().Add(ProviderDialBackoff)
+}
+
+// recordDialSuccess clears any dial history for p.
+func (st *providerQueryState) recordDialSuccess(p peer.ID) {
+	st.lk.Lock()
+	defer st.lk.Unlock()
+	delete(st.backoffs, p)
+}
+
+// expireBackoffs drops dial-backoff entries whose window has passed, so the
+// table doesn't accumulate peers that are never listed as providers again.
+func (st *providerQueryState) expireBackoffs(now time.Time) {
+	st.lk.Lock()
+	defer st.lk.Unlock()
+
+	for p, until := range st.backoffs {
+		if now.After(until) {
+			delete(st.backoffs, p)
+		}
+	}
+}
+
 func (bs *Bitswap) providerQueryManager(ctx context.Context) {
-	var activeLk sync.Mutex
-	kset := cid.NewSet()
+	workers := bs.providerQueryWorkers
+	if workers == 0 {
+		workers = ProviderQueryWorkerCount
+	}
+	dials := bs.providerDialLimit
+	if dials == 0 {
+		dials = ProviderDialMax
+	}
 
+	st := &providerQueryState{
+		kset:      cid.NewSet(),
+		dialLimit: make(chan struct{}, dials),
+		sessions:  make(map[uint64]*sessionProviders),
+		backoffs:  make(map[peer.ID]time.Time),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tick := time.NewTicker(SessionProviderCacheTTL / 2)
+		defer tick.Stop()
+		for {
+			select {
+			case now := <-tick.C:
+				st.expireSessions(now)
+				st.expireBackoffs(now)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bs.providerQueryWorker(ctx, st)
+		}()
+	}
+	wg.Wait()
+}
+
+func (bs *Bitswap) providerQueryWorker(ctx context.Context, st *providerQueryState) {
 	for {
 		select {
 		case e := <-bs.findKeys:
-			select { // make sure its not already cancelled
+			select { // skip requests cancelled while queued
 			case <-e.Ctx.Done():
 				continue
 			default:
 			}
 
-			activeLk.Lock()
-			if kset.Has(e.Cid) {
-				activeLk.Unlock()
+			st.lk.Lock()
+			if st.kset.Has(e.Cid) {
+				st.lk.Unlock()
 				continue
 			}
-			kset.Add(e.Cid)
-			activeLk.Unlock()
-
-			go func(e *blockRequest) {
-				child, cancel := context.WithTimeout(e.Ctx, providerRequestTimeout)
-				defer cancel()
-				providers := bs.network.FindProvidersAsync(child, e.Cid, maxProvidersPerRequest)
-				wg := &sync.WaitGroup{}
-				for p := range providers {
-					wg.Add(1)
-					go func(p peer.ID) {
-						defer wg.Done()
-						err := bs.network.ConnectTo(child, p)
-						if err != nil {
-							log.Debug("failed to connect to provider %s: %s", p, err)
-						}
-					}(p)
-				}
-				wg.Wait()
-				activeLk.Lock()
-				kset.Remove(e.Cid)
-				activeLk.Unlock()
-			}(e)
+			st.kset.Add(e.Cid)
+			st.lk.Unlock()
+
+			// providers found for earlier keys of the same session
+			// are very likely to have this key too, so try them
+			// before walking the routing system
+			if !bs.connectSessionProviders(e, st) {
+				bs.findProviders(e, st)
+			}
+
+			st.lk.Lock()
+			st.kset.Remove(e.Cid)
+			st.lk.Unlock()
 
 		case <-ctx.Done():
 			return
 		}
 	}
 }
+
+// connectSessionProviders tries to connect to the providers cached for the
+// request's session instead of launching a new provider search. It returns
+// true when at least one cached provider could be connected to; on false the
+// caller should fall back to findProviders.
+func (bs *Bitswap) connectSessionProviders(e *blockRequest, st *providerQueryState) bool {
+	if e.Ses == 0 {
+		return false
+	}
+	peers := st.sessionProvidersFor(e.Ses)
+	if len(peers) == 0 {
+		return false
+	}
+
+	child, cancel := context.WithTimeout(e.Ctx, providerRequestTimeout)
+	defer cancel()
+
+	var connected int32
+	wg := &sync.WaitGroup{}
+	for _, p := range peers {
+		select {
+		case st.dialLimit <- struct{}{}:
+		case <-child.Done():
+			wg.Wait()
+			return atomic.LoadInt32(&connected) > 0
+		}
+		wg.Add(1)
+		go func(p peer.ID) {
+			defer wg.Done()
+			defer func() { <-st.dialLimit }()
+			if err := connectWithRetry(child, bs.network.ConnectTo, p); err == nil {
+				atomic.AddInt32(&connected, 1)
+			}
+		}(p)
+	}
+	wg.Wait()
+	return atomic.LoadInt32(&connected) > 0
+}
+
+// findProviders searches providers for a single key and connects to each of
+// them, holding a shared dial slot for the duration of every connection
+// attempt. Providers whose last dial recently failed are skipped for
+// ProviderDialBackoff, and providers with an open connection are dialed
+// ahead of cold ones. It returns once all dials have finished.
+func (bs *Bitswap) findProviders(e *blockRequest, st *providerQueryState) {
+	child, cancel := context.WithTimeout(e.Ctx, providerRequestTimeout)
+	defer cancel()
+	providers := bs.network.FindProvidersAsync(child, e.Cid, maxProvidersPerRequest)
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+
+	dial := func(p peer.ID) bool {
+		select {
+		case st.dialLimit <- struct{}{}:
+		case <-child.Done():
+			return false
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-st.dialLimit }()
+			err := connectWithRetry(child, bs.network.ConnectTo, p)
+			if err != nil {
+				// don't penalize the peer when the query as a whole
+				// was cancelled or timed out
+				if child.Err() == nil {
+					st.recordDialFailure(p)
+				}
+				log.Debug("failed to connect to provider %s: %s", p, err)
+				return
+			}
+			st.recordDialSuccess(p)
+			if e.Ses != 0 {
+				st.recordSessionProvider(e.Ses, p)
+			}
+		}()
+		return true
+	}
+
+	// cold providers are held back until the channel drains so a provider
+	// we already hold a connection to is never queued behind a cold dial
+	var cold []peer.ID
+	for p := range providers {
+		if st.inDialBackoff(p, time.Now()) {
+			bs.provSkipLk.Lock()
+			bs.provDialsSkipped++
+			bs.provSkipLk.Unlock()
+			continue
+		}
+		if bs.network.Connected(p) {
+			if !dial(p) {
+				return
+			}
+			continue
+		}
+		cold = append(cold, p)
+	}
+	for _, p := range cold {
+		if !dial(p) {
+			return
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package bitswap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	bsmsg "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/message"
+	bsnet "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/network"
+	wantlist "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-bitswap/wantlist"
+
+	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
+	ds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore"
+	dssync "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore/sync"
+	blockstore "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-blockstore"
+	blocksutil "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-blocksutil"
+	ifconnmgr "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-interface-connmgr"
+	peer "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peer"
+)
+
+func makeWantlistEntries(n int) []*wantlist.Entry {
+	bg := blocksutil.NewBlockGenerator()
+	entries := make([]*wantlist.Entry, n)
+	for i := range entries {
+		entries[i] = wantlist.NewRefEntry(bg.Next().Cid(), n-i)
+	}
+	return entries
+}
+
+func TestRebroadcastSchedulerBackoff(t *testing.T) {
+	base := time.Second
+	sched := newRebroadcastScheduler(base, 8*time.Second)
+	entries := makeWantlistEntries(2)
+	now := time.Now()
+
+	// keys the scheduler has never seen are due immediately
+	if due := sched.due(entries, now); len(due) != 2 {
+		t.Fatalf("expected all fresh keys to be due, got %d", len(due))
+	}
+
+	sched.searched(entries, now)
+	if due := sched.due(entries, now); len(due) != 0 {
+		t.Fatalf("expected no keys due right after a search, got %d", len(due))
+	}
+
+	// after one search the delay is the base interval
+	if due := sched.due(entries, now.Add(base)); len(due) != 2 {
+		t.Fatal("expected keys to come due after the base interval")
+	}
+
+	// each further search doubles the delay, capped at max
+	for i, want := range []time.Duration{2 * base, 4 * base, 8 * base, 8 * time.Second} {
+		sched.searched(entries, now)
+		if due := sched.due(entries, now.Add(want-time.Millisecond)); len(due) != 0 {
+			t.Fatalf("attempt %d: keys due before %v elapsed", i+2, want)
+		}
+		if due := sched.due(entries, now.Add(want)); len(due) != 2 {
+			t.Fatalf("attempt %d: keys not due after %v", i+2, want)
+		}
+	}
+}
+
+func TestRebroadcastSchedulerReset(t *testing.T) {
+	sched := newRebroadcastScheduler(time.Second, time.Minute)
+	entries := makeWantlistEntries(2)
+	now := time.Now()
+
+	sched.searched(entries, now)
+	sched.searched(entries, now)
+
+	// the first key is received (or cancelled), so it leaves the wantlist
+	// and its backoff state is dropped on the next pass
+	sched.due(entries[1:], now)
+
+	// when wanted again later it starts over at the full rate
+	if due := sched.due(entries, now); len(due) != 1 || !due[0].Cid.Equals(entries[0].Cid) {
+		t.Fatalf("expected only the reset key to be due, got %v", due)
+	}
+}
+
+func TestJitteredInterval(t *testing.T) {
+	d := time.Minute
+	for i := 0; i < 1000; i++ {
+		j := jitteredInterval(d)
+		if j < d-d/5 || j > d+d/5 {
+			t.Fatalf("jittered interval %v outside ±20%% of %v", j, d)
+		}
+	}
+}
+
+// countingNetwork is a no-op BitSwapNetwork that counts Provide calls.
+type countingNetwork struct {
+	provides int64
+}
+
+func (cn *countingNetwork) SendMessage(context.Context, peer.ID, bsmsg.BitSwapMessage) error {
+	return nil
+}
+
+func (cn *countingNetwork) SetDelegate(bsnet.Receiver) {}
+
+func (cn *countingNetwork) ConnectTo(context.Context, peer.ID) error { return nil }
+
+func (cn *countingNetwork) Connected(peer.ID) bool { return false }
+
+func (cn *countingNetwork) NewMessageSender(context.Context, peer.ID) (bsnet.MessageSender, error) {
+	return nil, nil
+}
+
+func (cn *countingNetwork) ConnectionManager() ifconnmgr.ConnManager { return &ifconnmgr.NullConnMgr{} }
+
+func (cn *countingNetwork) Stats() bsnet.NetworkStats { return bsnet.NetworkStats{} }
+
+func (cn *countingNetwork) FindProvidersAsync(context.Context, cid.Cid, int) <-chan peer.ID {
+	ch := make(chan peer.ID)
+	close(ch)
+	return ch
+}
+
+func (cn *countingNetwork) Provide(context.Context, cid.Cid) error {
+	atomic.AddInt64(&cn.provides, 1)
+	return nil
+}
+
+// dialNetwork hands out a fixed provider set for every key and instruments
+// ConnectTo so tests can observe dial concurrency.
+type dialNetwork struct {
+	countingNetwork
+	providers []peer.ID
+	dialDelay time.Duration
+
+	active  int64
+	maxSeen int64
+	dials   int64
+}
+
+func (dn *dialNetwork) FindProvidersAsync(ctx context.Context, c cid.Cid, max int) <-chan peer.ID {
+	ch := make(chan peer.ID)
+	go func() {
+		defer close(ch)
+		for _, p := range dn.providers {
+			select {
+			case ch <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+func (dn *dialNetwork) ConnectTo(ctx context.Context, p peer.ID) error {
+	cur := atomic.AddInt64(&dn.active, 1)
+	defer atomic.AddInt64(&dn.active, -1)
+	for {
+		seen := atomic.LoadInt64(&dn.maxSeen)
+		if cur <= seen || atomic.CompareAndSwapInt64(&dn.maxSeen, seen, cur) {
+			break
+		}
+	}
+	atomic.AddInt64(&dn.dials, 1)
+
+	select {
+	case <-time.After(dn.dialDelay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestProviderDialLimit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const nproviders = 40
+	const limit = 4
+
+	providers := make([]peer.ID, nproviders)
+	for i := range providers {
+		providers[i] = peer.ID(fmt.Sprintf("provider-%d", i))
+	}
+
+	net := &dialNetwork{providers: providers, dialDelay: 5 * time.Millisecond}
+	bstore := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	bs := New(ctx, net, bstore, WithProviderDialLimit(limit)).(*Bitswap)
+	defer bs.Close()
+
+	bg := blocksutil.NewBlockGenerator()
+	bs.findKeys <- &blockRequest{Cid: bg.Next().Cid(), Ctx: ctx}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for atomic.LoadInt64(&net.dials) < nproviders {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out after %d of %d dials", atomic.LoadInt64(&net.dials), nproviders)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if seen := atomic.LoadInt64(&net.maxSeen); seen > limit {
+		t.Fatalf("expected at most %d concurrent dials, saw %d", limit, seen)
+	}
+}
+
+func TestProviderDialCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const limit = 2
+
+	providers := make([]peer.ID, 10)
+	for i := range providers {
+		providers[i] = peer.ID(fmt.Sprintf("provider-%d", i))
+	}
+
+	// dials block until their context is torn down
+	net := &dialNetwork{providers: providers, dialDelay: time.Hour}
+	bstore := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	bs := New(ctx, net, bstore, WithProviderDialLimit(limit)).(*Bitswap)
+	defer bs.Close()
+
+	reqCtx, reqCancel := context.WithCancel(ctx)
+	bg := blocksutil.NewBlockGenerator()
+	bs.findKeys <- &blockRequest{Cid: bg.Next().Cid(), Ctx: reqCtx}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for atomic.LoadInt64(&net.active) < limit {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the dial slots to fill")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// cancelling the request must unblock the in-flight dials and stop the
+	// fan-out at the slots already taken
+	reqCancel()
+
+	for atomic.LoadInt64(&net.active) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for cancelled dials to finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if dials := atomic.LoadInt64(&net.dials); dials > limit {
+		t.Fatalf("expected at most %d dials before cancellation, got %d", limit, dials)
+	}
+}
+
+func BenchmarkProvideStrategies(b *testing.B) {
+	const nblocks = 10000
+
+	for _, bc := range []struct {
+		name     string
+		strategy ProvideStrategy
+		expected int64
+	}{
+		{"all", ProvideAll, nblocks + 1},
+		{"roots-only", ProvideRootsOnly, 1},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ctx, cancel := context.WithCancel(context.Background())
+				net := &countingNetwork{}
+				bstore := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+				bs := New(ctx, net, bstore, WithProvideStrategy(bc.strategy)).(*Bitswap)
+
+				// a synthetic add: many leaves, one explicitly flagged root
+				bg := blocksutil.NewBlockGenerator()
+				root := bg.Next()
+				if err := bs.HasBlock(root); err != nil {
+					b.Fatal(err)
+				}
+				for j := 1; j < nblocks; j++ {
+					if err := bs.HasBlock(bg.Next()); err != nil {
+						b.Fatal(err)
+					}
+				}
+				if err := bs.ProvideRoots(ctx, []cid.Cid{root.Cid()}); err != nil {
+					b.Fatal(err)
+				}
+
+				deadline := time.Now().Add(30 * time.Second)
+				for atomic.LoadInt64(&net.provides) < bc.expected {
+					if time.Now().After(deadline) {
+						b.Fatalf("timed out with %d of %d provides", atomic.LoadInt64(&net.provides), bc.expected)
+					}
+					time.Sleep(time.Millisecond)
+				}
+				if got := atomic.LoadInt64(&net.provides); got != bc.expected {
+					b.Fatalf("expected %d provides, got %d", bc.expected, got)
+				}
+
+				bs.Close()
+				cancel()
+			}
+		})
+	}
+}
+
+// unreachableNetwork hands out a single provider that can never be dialed,
+// counting the connection attempts.
+type unreachableNetwork struct {
+	countingNetwork
+	provider peer.ID
+	dials    int64
+}
+
+func (un *unreachableNetwork) FindProvidersAsync(ctx context.Context, c cid.Cid, max int) <-chan peer.ID {
+	ch := make(chan peer.ID, 1)
+	ch <- un.provider
+	close(ch)
+	return ch
+}
+
+func (un *unreachableNetwork) ConnectTo(ctx context.Context, p peer.ID) error {
+	atomic.AddInt64(&un.dials, 1)
+	return errors.New("connection refused")
+}
+
+func TestProviderDialBackoff(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// keep the retry delays out of the test runtime
+	defer func(d time.Duration) { ProviderConnectBackoff = d }(ProviderConnectBackoff)
+	ProviderConnectBackoff = time.Millisecond
+
+	net := &unreachableNetwork{provider: peer.ID("unreachable-provider")}
+	bstore := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	bs := New(ctx, net, bstore).(*Bitswap)
+	defer bs.Close()
+
+	const queries = 64
+	bg := blocksutil.NewBlockGenerator()
+	for i := 0; i < queries; i++ {
+		select {
+		case bs.findKeys <- &blockRequest{Cid: bg.Next().Cid(), Ctx: ctx}:
+		case <-ctx.Done():
+			t.Fatal("timed out queueing requests")
+		}
+	}
+
+	// every query either dialed the provider (with retries) or skipped it,
+	// wait until all of them are accounted for
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		st, err := bs.Stat()
+		if err != nil {
+			t.Fatal(err)
+		}
+		dialed := uint64(atomic.LoadInt64(&net.dials)) / uint64(ProviderConnectRetries+1)
+		if dialed+st.ProviderDialsSkipped >= queries {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out with %d dials and %d skips", atomic.LoadInt64(&net.dials), st.ProviderDialsSkipped)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// only the queries picked up before the first failure was recorded may
+	// dial, everything after must hit the backoff
+	limit := int64((ProviderConnectRetries + 1) * ProviderQueryWorkerCount)
+	if dials := atomic.LoadInt64(&net.dials); dials > limit {
+		t.Fatalf("expected dial attempts to be bounded by the backoff: %d > %d", dials, limit)
+	}
+
+	st, err := bs.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.ProviderDialsSkipped == 0 {
+		t.Fatal("expected skipped provider dials to be reported in stats")
+	}
+}
@@ -127,6 +127,12 @@ func (bs *blockstore) Get(k cid.Cid) (blocks.Block, error) {
 	if err != nil {
 		return nil, err
 	}
+	// stored entries may be compressed; the cid always names the
+	// uncompressed content, so decode before any verification
+	bdata, err = decodeBlockData(bdata)
+	if err != nil {
+		return nil, err
+	}
 	if bs.rehash {
 		rbcid, err := k.Prefix().Sum(bdata)
 		if err != nil {
@@ -150,7 +156,13 @@ func (bs *blockstore) Put(block blocks.Block) error {
 	if err == nil && exists {
 		return nil // already stored.
 	}
-	return bs.datastore.Put(k, block.RawData())
+
+	data := block.RawData()
+	if _, ok := block.(*compressedBlock); !ok {
+		// raw data colliding with the compression header must be escaped
+		data = escapeBlockData(data)
+	}
+	return bs.datastore.Put(k, data)
 }
 
 func (bs *blockstore) PutMany(blocks []blocks.Block) error {
@@ -165,7 +177,11 @@ func (bs *blockstore) PutMany(blocks []blocks.Block) error {
 			continue
 		}
 
-		err = t.Put(k, b.RawData())
+		data := b.RawData()
+		if _, ok := b.(*compressedBlock); !ok {
+			data = escapeBlockData(data)
+		}
+		err = t.Put(k, data)
 		if err != nil {
 			return err
 		}
@@ -178,11 +194,17 @@ func (bs *blockstore) Has(k cid.Cid) (bool, error) {
 }
 
 func (bs *blockstore) GetSize(k cid.Cid) (int, error) {
-	size, err := bs.datastore.GetSize(dshelp.CidToDsKey(k))
+	// stored entries may be compressed or escaped, so the datastore's size
+	// for them is not the size of the content the cid names; the entry has
+	// to be read and decoded to measure it
+	data, err := bs.datastore.Get(dshelp.CidToDsKey(k))
 	if err == ds.ErrNotFound {
 		return -1, ErrNotFound
 	}
-	return size, err
+	if err != nil {
+		return -1, err
+	}
+	return decodedBlockSize(data)
 }
 
 func (bs *blockstore) DeleteBlock(k cid.Cid) error {
@@ -272,3 +272,100 @@ func (c *queryTestDS) Query(q dsq.Query) (dsq.Results, error) {
 func (c *queryTestDS) Batch() (ds.Batch, error) {
 	return ds.NewBasicBatch(c), nil
 }
+
+func TestCompressedPutThenGetBlock(t *testing.T) {
+	d := ds_sync.MutexWrap(ds.NewMapDatastore())
+	bs := NewBlockstore(d)
+	block := blocks.NewBlock([]byte("some highly compressible data some highly compressible data"))
+
+	cb, err := NewCompressedBlock(block, CompressionGzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cb.Cid() != block.Cid() {
+		t.Fatal("compression must not change the cid")
+	}
+
+	if err := bs.Put(cb); err != nil {
+		t.Fatal(err)
+	}
+
+	// the stored entry is the compressed representation, not the raw bytes
+	res, err := d.Query(dsq.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for e := range res.Next() {
+		if e.Error != nil {
+			t.Fatal(e.Error)
+		}
+		if !bytes.HasPrefix(e.Value, compressionMagic) {
+			t.Fatal("expected the stored entry to carry the compression header")
+		}
+		if bytes.Contains(e.Value, block.RawData()) {
+			t.Fatal("expected the stored entry to be compressed")
+		}
+	}
+
+	blockFromBlockstore, err := bs.Get(block.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(block.RawData(), blockFromBlockstore.RawData()) {
+		t.Fatal("expected transparent decompression on read")
+	}
+
+	// verification happens post-decompression
+	bs.HashOnRead(true)
+	if _, err := bs.Get(block.Cid()); err != nil {
+		t.Fatalf("rehash of a compressed block failed: %s", err)
+	}
+
+	// sizes report the content the cid names, not the stored entry
+	size, err := bs.GetSize(block.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != len(block.RawData()) {
+		t.Fatalf("expected the uncompressed size %d, got %d", len(block.RawData()), size)
+	}
+}
+
+func TestCompressionCodecValidation(t *testing.T) {
+	block := blocks.NewBlock([]byte("some data"))
+
+	if b, err := NewCompressedBlock(block, CompressionNone); err != nil || b != block {
+		t.Fatal("'none' must be a no-op")
+	}
+	if _, err := NewCompressedBlock(block, "zstd"); err == nil {
+		t.Fatal("expected an error for an unsupported codec")
+	}
+}
+
+func TestCompressionMagicEscaped(t *testing.T) {
+	bs := NewBlockstore(ds_sync.MutexWrap(ds.NewMapDatastore()))
+
+	// a raw block that starts with the compression magic must round-trip
+	data := append(append([]byte{}, compressionMagic...), []byte("innocent block")...)
+	block := blocks.NewBlock(data)
+
+	if err := bs.Put(block); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := bs.Get(block.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.RawData(), data) {
+		t.Fatal("escaped block did not round-trip")
+	}
+
+	size, err := bs.GetSize(block.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != len(data) {
+		t.Fatalf("expected the escape prefix to be excluded from the size, got %d != %d", size, len(data))
+	}
+}
@@ -0,0 +1,136 @@
+package blockstore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	blocks "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-block-format"
+)
+
+// Codecs accepted for compressed block storage. Compression is strictly a
+// storage concern: CIDs keep referring to the uncompressed content and every
+// read decompresses transparently, so consumers like bitswap and GC never
+// see the compressed representation.
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+)
+
+// compressionMagic prefixes compressed datastore entries, followed by a
+// single codec byte and the codec's stream. Raw blocks that happen to begin
+// with the magic are escaped with codecRaw on write so reads stay
+// unambiguous.
+var compressionMagic = []byte("\x00ipfs-cmp\x00")
+
+const (
+	codecRaw  = 0x00
+	codecGzip = 0x01
+)
+
+// compressedBlock wraps a block so its stored representation is the
+// compressed data while the CID still names the uncompressed content.
+type compressedBlock struct {
+	blocks.Block
+	data []byte
+}
+
+func (cb *compressedBlock) RawData() []byte {
+	return cb.data
+}
+
+// NewCompressedBlock returns a block that stores the compressed
+// representation of b under b's CID. Putting it into a blockstore writes the
+// compressed bytes; reading the CID back returns the original content.
+func NewCompressedBlock(b blocks.Block, codec string) (blocks.Block, error) {
+	switch codec {
+	case CompressionNone, "":
+		return b, nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+		buf.Write(compressionMagic)
+		buf.WriteByte(codecGzip)
+
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(b.RawData()); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+
+		return &compressedBlock{Block: b, data: buf.Bytes()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression codec: %s", codec)
+	}
+}
+
+// escapeBlockData prefixes raw data that collides with the compression magic
+// so it cannot be mistaken for a compressed entry.
+func escapeBlockData(data []byte) []byte {
+	if !bytes.HasPrefix(data, compressionMagic) {
+		return data
+	}
+
+	out := make([]byte, 0, len(compressionMagic)+1+len(data))
+	out = append(out, compressionMagic...)
+	out = append(out, codecRaw)
+	return append(out, data...)
+}
+
+// decodedBlockSize returns the size of the block bytes a stored entry
+// decodes to, without materializing the decompressed content.
+func decodedBlockSize(data []byte) (int, error) {
+	if !bytes.HasPrefix(data, compressionMagic) {
+		return len(data), nil
+	}
+
+	rest := data[len(compressionMagic):]
+	if len(rest) == 0 {
+		return 0, fmt.Errorf("truncated compressed block")
+	}
+
+	switch rest[0] {
+	case codecRaw:
+		return len(rest) - 1, nil
+	case codecGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(rest[1:]))
+		if err != nil {
+			return 0, err
+		}
+		defer zr.Close()
+		n, err := io.Copy(ioutil.Discard, zr)
+		return int(n), err
+	default:
+		return 0, fmt.Errorf("unknown compression codec byte: %d", rest[0])
+	}
+}
+
+// decodeBlockData undoes escapeBlockData and NewCompressedBlock, returning
+// the original block bytes.
+func decodeBlockData(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, compressionMagic) {
+		return data, nil
+	}
+
+	rest := data[len(compressionMagic):]
+	if len(rest) == 0 {
+		return nil, fmt.Errorf("truncated compressed block")
+	}
+
+	switch rest[0] {
+	case codecRaw:
+		return rest[1:], nil
+	case codecGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(rest[1:]))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return ioutil.ReadAll(zr)
+	default:
+		return nil, fmt.Errorf("unknown compression codec byte: %d", rest[0])
+	}
+}
@@ -5,4 +5,9 @@ type Ipns struct {
 	RecordLifetime  string
 
 	ResolveCacheSize int
+
+	// FilesPublishKey is the name of the keystore key under which the MFS
+	// files root is published. When empty the files root is only persisted
+	// locally.
+	FilesPublishKey string `json:",omitempty"`
 }
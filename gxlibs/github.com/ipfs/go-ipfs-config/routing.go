@@ -4,4 +4,9 @@ package config
 type Routing struct {
 	// Type sets default daemon routing mode.
 	Type string
+
+	// DelegateTimeout bounds each constituent router when provider
+	// operations fan out over multiple routers. It takes a duration
+	// string ("30s", "2m"); when empty a default is used.
+	DelegateTimeout string
 }
@@ -13,6 +13,10 @@ type SwarmConfig struct {
 	// if true, then an AutoNATService will be instantiated to facilitate autorelay
 	EnableAutoNATService bool
 
+	// BitswapTaskWorkers overrides the number of bitswap workers serving
+	// other peers' block requests. Zero uses the bitswap default.
+	BitswapTaskWorkers int
+
 	ConnMgr ConnMgr
 }
 
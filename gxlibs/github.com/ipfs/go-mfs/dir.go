@@ -72,6 +72,62 @@ func (d *Directory) SetCidBuilder(b cid.Builder) {
 	d.unixfsDir.SetCidBuilder(b)
 }
 
+// SetMetadata stores unix permission bits and/or a modification time on the
+// directory's unixfs node and propagates the change to the parent. A zero
+// mode or mtime leaves the respective field untouched.
+func (d *Directory) SetMetadata(mode os.FileMode, mtime time.Time) error {
+	d.lock.Lock()
+	nd, err := d.unixfsDir.GetNode()
+	if err != nil {
+		d.lock.Unlock()
+		return err
+	}
+
+	pbnd, ok := nd.(*dag.ProtoNode)
+	if !ok {
+		d.lock.Unlock()
+		return dag.ErrNotProtobuf
+	}
+
+	fsn, err := ft.FSNodeFromBytes(pbnd.Data())
+	if err != nil {
+		d.lock.Unlock()
+		return err
+	}
+
+	if mode != 0 {
+		fsn.SetMode(mode)
+	}
+	if !mtime.IsZero() {
+		fsn.SetMtime(mtime)
+	}
+
+	data, err := fsn.GetBytes()
+	if err != nil {
+		d.lock.Unlock()
+		return err
+	}
+
+	upd := pbnd.Copy().(*dag.ProtoNode)
+	upd.SetData(data)
+	upd.SetCidBuilder(d.unixfsDir.GetCidBuilder())
+
+	if err := d.dserv.Add(d.ctx, upd); err != nil {
+		d.lock.Unlock()
+		return err
+	}
+
+	db, err := uio.NewDirectoryFromNode(d.dserv, upd)
+	if err != nil {
+		d.lock.Unlock()
+		return err
+	}
+	d.unixfsDir = db
+	d.lock.Unlock()
+
+	return d.parent.closeChild(d.name, upd, true)
+}
+
 // closeChild updates the child by the given name to the dag node 'nd'
 // and changes its own dag node
 func (d *Directory) closeChild(name string, nd ipld.Node, sync bool) error {
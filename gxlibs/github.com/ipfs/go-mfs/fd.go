@@ -60,7 +60,7 @@ func (fi *fileDescriptor) Write(b []byte) (int, error) {
 
 // Read reads into the given buffer from the current offset
 func (fi *fileDescriptor) Read(b []byte) (int, error) {
-	if fi.perms == OpenWriteOnly {
+	if fi.perms == OpenWriteOnly || fi.perms == OpenExclusive {
 		return 0, fmt.Errorf("cannot read on write-only descriptor")
 	}
 	return fi.mod.Read(b)
@@ -68,7 +68,7 @@ func (fi *fileDescriptor) Read(b []byte) (int, error) {
 
 // Read reads into the given buffer from the current offset
 func (fi *fileDescriptor) CtxReadFull(ctx context.Context, b []byte) (int, error) {
-	if fi.perms == OpenWriteOnly {
+	if fi.perms == OpenWriteOnly || fi.perms == OpenExclusive {
 		return 0, fmt.Errorf("cannot read on write-only descriptor")
 	}
 	return fi.mod.CtxReadFull(ctx, b)
@@ -83,6 +83,9 @@ func (fi *fileDescriptor) Close() error {
 			fi.inode.desclock.RUnlock()
 		case OpenWriteOnly, OpenReadWrite:
 			fi.inode.desclock.Unlock()
+		case OpenExclusive:
+			fi.inode.desclock.Unlock()
+			fi.inode.writelk.Unlock()
 		}
 	}()
 
@@ -3,7 +3,9 @@ package mfs
 import (
 	"context"
 	"fmt"
+	"os"
 	"sync"
+	"time"
 
 	dag "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-merkledag"
 	ft "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs"
@@ -20,6 +22,11 @@ type File struct {
 
 	desclock sync.RWMutex
 
+	// writelk serializes descriptors opened with OpenExclusive, making a
+	// whole open-write-close cycle atomic with respect to other exclusive
+	// writers
+	writelk sync.Mutex
+
 	dserv  ipld.DAGService
 	node   ipld.Node
 	nodelk sync.Mutex
@@ -46,9 +53,42 @@ const (
 	OpenReadOnly = iota
 	OpenWriteOnly
 	OpenReadWrite
+
+	// OpenExclusive is OpenWriteOnly with a per-file write lock held from
+	// Open until Close, so concurrent exclusive writers are fully
+	// serialized instead of interleaving their modifications
+	OpenExclusive
 )
 
 func (fi *File) Open(flags int, sync bool) (FileDescriptor, error) {
+	switch flags {
+	case OpenReadOnly:
+		fi.desclock.RLock()
+	case OpenWriteOnly, OpenReadWrite:
+		fi.desclock.Lock()
+	case OpenExclusive:
+		fi.writelk.Lock()
+		fi.desclock.Lock()
+	default:
+		// TODO: support other modes
+		return nil, fmt.Errorf("mode not supported")
+	}
+
+	unlock := func() {
+		switch flags {
+		case OpenReadOnly:
+			fi.desclock.RUnlock()
+		case OpenWriteOnly, OpenReadWrite:
+			fi.desclock.Unlock()
+		case OpenExclusive:
+			fi.desclock.Unlock()
+			fi.writelk.Unlock()
+		}
+	}
+
+	// snapshot the node only once the descriptor lock is held, so writers
+	// queued behind a previous writer build on its flushed result rather
+	// than a stale node
 	fi.nodelk.Lock()
 	node := fi.node
 	fi.nodelk.Unlock()
@@ -57,13 +97,16 @@ func (fi *File) Open(flags int, sync bool) (FileDescriptor, error) {
 	case *dag.ProtoNode:
 		fsn, err := ft.FSNodeFromBytes(node.Data())
 		if err != nil {
+			unlock()
 			return nil, err
 		}
 
 		switch fsn.Type() {
 		default:
+			unlock()
 			return nil, fmt.Errorf("unsupported fsnode type for 'file'")
 		case ft.TSymlink:
+			unlock()
 			return nil, fmt.Errorf("symlinks not yet supported")
 		case ft.TFile, ft.TRaw:
 			// OK case
@@ -72,18 +115,9 @@ func (fi *File) Open(flags int, sync bool) (FileDescriptor, error) {
 		// Ok as well.
 	}
 
-	switch flags {
-	case OpenReadOnly:
-		fi.desclock.RLock()
-	case OpenWriteOnly, OpenReadWrite:
-		fi.desclock.Lock()
-	default:
-		// TODO: support other modes
-		return nil, fmt.Errorf("mode not supported")
-	}
-
 	dmod, err := mod.NewDagModifier(context.TODO(), node, fi.dserv, chunker.DefaultSplitter)
 	if err != nil {
+		unlock()
 		return nil, err
 	}
 	dmod.RawLeaves = fi.RawLeaves
@@ -114,6 +148,50 @@ func (fi *File) Size() (int64, error) {
 	}
 }
 
+// SetMetadata stores unix permission bits and/or a modification time on the
+// file's root unixfs node and propagates the change to the parent. Only the
+// root node's Data field is rewritten; the file content blocks are left
+// untouched. A zero mode or mtime leaves the respective field untouched.
+func (fi *File) SetMetadata(mode os.FileMode, mtime time.Time) error {
+	fi.nodelk.Lock()
+	pbnd, ok := fi.node.(*dag.ProtoNode)
+	if !ok {
+		fi.nodelk.Unlock()
+		return dag.ErrNotProtobuf
+	}
+
+	fsn, err := ft.FSNodeFromBytes(pbnd.Data())
+	if err != nil {
+		fi.nodelk.Unlock()
+		return err
+	}
+
+	if mode != 0 {
+		fsn.SetMode(mode)
+	}
+	if !mtime.IsZero() {
+		fsn.SetMtime(mtime)
+	}
+
+	data, err := fsn.GetBytes()
+	if err != nil {
+		fi.nodelk.Unlock()
+		return err
+	}
+
+	upd := pbnd.Copy().(*dag.ProtoNode)
+	upd.SetData(data)
+
+	if err := fi.dserv.Add(context.TODO(), upd); err != nil {
+		fi.nodelk.Unlock()
+		return err
+	}
+	fi.node = upd
+	fi.nodelk.Unlock()
+
+	return fi.parent.closeChild(fi.name, upd, true)
+}
+
 // GetNode returns the dag node associated with this file
 func (fi *File) GetNode() (ipld.Node, error) {
 	fi.nodelk.Lock()
@@ -1006,6 +1006,122 @@ func TestConcurrentWrites(t *testing.T) {
 	wg.Wait()
 }
 
+func TestExclusiveWrites(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ds, rt := setupRoot(ctx, t)
+	dir := rt.GetDirectory()
+
+	nd := dag.NodeWithData(ft.FilePBData(nil, 0))
+	fi, err := NewFile("testex", nd, dir, ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputA := bytes.Repeat([]byte{'a'}, 2048)
+	inputB := bytes.Repeat([]byte{'b'}, 1024)
+
+	fd, err := fi.Open(OpenExclusive, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := fd.Write(inputA); err != nil {
+		t.Fatal(err)
+	}
+
+	// a second exclusive open must block until the first writer is done
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wfd, err := fi.Open(OpenExclusive, true)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer wfd.Close()
+
+		if err := wfd.Truncate(0); err != nil {
+			t.Error(err)
+			return
+		}
+		if _, err := wfd.Write(inputB); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("second exclusive open didn't block while the first was held")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	if err := fd.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("second exclusive writer never completed")
+	}
+
+	if t.Failed() {
+		return
+	}
+
+	// race two chunked exclusive writers; the survivor must be exactly one
+	// of the inputs, never an interleaving
+	inputs := [][]byte{
+		bytes.Repeat([]byte{'x'}, 1000),
+		bytes.Repeat([]byte{'y'}, 500),
+	}
+
+	var wg sync.WaitGroup
+	for i := range inputs {
+		wg.Add(1)
+		go func(data []byte) {
+			defer wg.Done()
+			wfd, err := fi.Open(OpenExclusive, true)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer wfd.Close()
+
+			if err := wfd.Truncate(0); err != nil {
+				t.Error(err)
+				return
+			}
+			half := len(data) / 2
+			if _, err := wfd.Write(data[:half]); err != nil {
+				t.Error(err)
+				return
+			}
+			time.Sleep(time.Millisecond * 10)
+			if _, err := wfd.Write(data[half:]); err != nil {
+				t.Error(err)
+			}
+		}(inputs[i])
+	}
+	wg.Wait()
+
+	size, err := fi.Size()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, size)
+	if err := readFile(rt, "/testex", 0, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(buf, inputs[0]) && !bytes.Equal(buf, inputs[1]) {
+		t.Fatalf("final content is an interleaving of the racing writes (%d bytes)", size)
+	}
+}
+
 func TestFileDescriptors(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
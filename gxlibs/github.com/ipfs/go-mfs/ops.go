@@ -1,10 +1,12 @@
 package mfs
 
 import (
+	"context"
 	"fmt"
 	"os"
 	gopath "path"
 	"strings"
+	"time"
 
 	path "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-path"
 
@@ -97,11 +99,15 @@ func PutNode(r *Root, path string, nd ipld.Node) error {
 	return pdir.AddChild(filename, nd)
 }
 
-// MkdirOpts is used by Mkdir
+// MkdirOpts is used by Mkdir. Mode and Mtime, when non-zero, are stored as
+// unixfs metadata on the created directory; intermediate directories created
+// via Mkparents are left without metadata.
 type MkdirOpts struct {
 	Mkparents  bool
 	Flush      bool
 	CidBuilder cid.Builder
+	Mode       os.FileMode
+	Mtime      time.Time
 }
 
 // Mkdir creates a directory at 'path' under the directory 'd', creating
@@ -161,6 +167,12 @@ func Mkdir(r *Root, pth string, opts MkdirOpts) error {
 		final.SetCidBuilder(opts.CidBuilder)
 	}
 
+	if opts.Mode != 0 || !opts.Mtime.IsZero() {
+		if err := final.SetMetadata(opts.Mode, opts.Mtime); err != nil {
+			return err
+		}
+	}
+
 	if opts.Flush {
 		err := final.Flush()
 		if err != nil {
@@ -218,6 +230,8 @@ func FlushPath(rt *Root, pth string) error {
 		return err
 	}
 
-	rt.repub.WaitPub()
-	return nil
+	if rt.repub == nil {
+		return nil
+	}
+	return rt.repub.WaitPub(context.TODO())
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
+	dag "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-merkledag"
 	ci "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-testutil/ci"
 )
 
@@ -65,13 +66,166 @@ func TestRepublisher(t *testing.T) {
 
 	cancel()
 
+	closeErr := make(chan error, 1)
 	go func() {
-		err := rp.Close()
-		if err != nil {
-			t.Fatal(err)
-		}
+		closeErr <- rp.Close()
+	}()
+
+	// final pub from closing
+	<-pub
+
+	if err := <-closeErr; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepublisherSetTimeouts(t *testing.T) {
+	if ci.IsRunning() {
+		t.Skip("dont run timing tests in CI")
+	}
+
+	ctx := context.TODO()
+
+	pub := make(chan struct{})
+
+	pf := func(ctx context.Context, c cid.Cid) error {
+		pub <- struct{}{}
+		return nil
+	}
+
+	tshort := time.Millisecond * 50
+	tlong := time.Second / 2
+
+	rp := NewRepublisher(ctx, pf, tshort, tlong)
+	go rp.Run()
+
+	if err := rp.SetTimeouts(tlong, tshort); err == nil {
+		t.Fatal("expected error setting short timeout greater than long")
+	}
+
+	rp.Update(cid.Undef)
+
+	// should hit the original short timeout
+	select {
+	case <-time.After(tshort * 2):
+		t.Fatal("publish didnt happen in time")
+	case <-pub:
+	}
+
+	// lengthen the short timeout and verify the new cadence is used
+	newshort := time.Millisecond * 200
+	if err := rp.SetTimeouts(newshort, tlong); err != nil {
+		t.Fatal(err)
+	}
+
+	rp.Update(cid.Undef)
+
+	select {
+	case <-pub:
+		t.Fatal("publish happened on the old cadence")
+	case <-time.After(newshort / 2):
+	}
+	select {
+	case <-pub:
+	case <-time.After(newshort * 2):
+		t.Fatal("waited too long for pub!")
+	}
+
+	closeErr := make(chan error, 1)
+	go func() {
+		closeErr <- rp.Close()
 	}()
 
 	// final pub from closing
 	<-pub
+
+	if err := <-closeErr; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepublisherWaitPub(t *testing.T) {
+	ctx := context.TODO()
+
+	published := make(chan cid.Cid, 1)
+
+	pf := func(ctx context.Context, c cid.Cid) error {
+		published <- c
+		return nil
+	}
+
+	// long timeouts: a publish within this test can only come from WaitPub
+	rp := NewRepublisher(ctx, pf, time.Minute, time.Minute*2)
+	go rp.Run()
+
+	c := dag.NewRawNode([]byte("test")).Cid()
+	rp.Update(c)
+
+	if err := rp.WaitPub(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case got := <-published:
+		if !got.Equals(c) {
+			t.Fatalf("published cid %s doesn't match most recent update %s", got, c)
+		}
+	default:
+		t.Fatal("WaitPub returned without publishing")
+	}
+
+	// consistent state publishes nothing and returns immediately
+	if err := rp.WaitPub(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-published:
+		t.Fatal("unexpected publish")
+	default:
+	}
+}
+
+func TestRepublisherLastPublished(t *testing.T) {
+	ctx := context.TODO()
+
+	pub := make(chan struct{})
+
+	pf := func(ctx context.Context, c cid.Cid) error {
+		pub <- struct{}{}
+		return nil
+	}
+
+	rp := NewRepublisher(ctx, pf, time.Millisecond*50, time.Second/2)
+	go rp.Run()
+
+	if c, at := rp.LastPublished(); c.Defined() || !at.IsZero() {
+		t.Fatal("expected undefined cid and zero time before any publish")
+	}
+
+	c := dag.NewRawNode([]byte("last published")).Cid()
+	rp.Update(c)
+
+	select {
+	case <-pub:
+	case <-time.After(time.Second):
+		t.Fatal("publish didnt happen in time")
+	}
+
+	// lastpub is recorded just after the publish function returns
+	var got cid.Cid
+	var at time.Time
+	for i := 0; i < 100; i++ {
+		got, at = rp.LastPublished()
+		if got.Defined() {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if !got.Equals(c) {
+		t.Fatalf("last published cid %s doesn't match update %s", got, c)
+	}
+	if at.IsZero() {
+		t.Fatal("last published time not set")
+	}
 }
@@ -186,14 +186,15 @@ type Republisher struct {
 	TimeoutShort time.Duration
 	Publish      chan struct{}
 	pubfunc      PubFunc
-	pubnowch     chan chan struct{}
+	pubnowch     chan chan error
 
 	ctx    context.Context
 	cancel func()
 
-	lk      sync.Mutex
-	val     cid.Cid
-	lastpub cid.Cid
+	lk        sync.Mutex
+	val       cid.Cid
+	lastpub   cid.Cid
+	lastpubAt time.Time
 }
 
 // NewRepublisher creates a new Republisher object to republish the given root
@@ -205,31 +206,66 @@ func NewRepublisher(ctx context.Context, pf PubFunc, tshort, tlong time.Duration
 		TimeoutLong:  tlong,
 		Publish:      make(chan struct{}, 1),
 		pubfunc:      pf,
-		pubnowch:     make(chan chan struct{}),
+		pubnowch:     make(chan chan error),
 		ctx:          ctx,
 		cancel:       cancel,
 	}
 }
 
+// SetTimeouts updates the short and long republish timeouts of a running
+// republisher. The `Run` loop picks the new values up when it schedules its
+// next publish, so a pending update is never dropped. An error is returned
+// if `short` is greater than `long`.
+func (p *Republisher) SetTimeouts(short, long time.Duration) error {
+	if short > long {
+		return fmt.Errorf("short timeout (%s) must not exceed long timeout (%s)", short, long)
+	}
+	p.lk.Lock()
+	p.TimeoutShort = short
+	p.TimeoutLong = long
+	p.lk.Unlock()
+	return nil
+}
+
+// timeouts reads the current timeout pair under the lock.
+func (p *Republisher) timeouts() (time.Duration, time.Duration) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	return p.TimeoutShort, p.TimeoutLong
+}
+
 func (p *Republisher) setVal(c cid.Cid) {
 	p.lk.Lock()
 	defer p.lk.Unlock()
 	p.val = c
 }
 
-// WaitPub Returns immediately if `lastpub` value is consistent with the
-// current value `val`, else will block until `val` has been published.
-func (p *Republisher) WaitPub() {
+// WaitPub returns immediately if `lastpub` value is consistent with the
+// current value `val`, else it forces an immediate publish, bypassing the
+// debounce timeouts, and blocks until the publish function has returned,
+// reporting its error. It coalesces with an in-flight publish rather than
+// publishing twice.
+func (p *Republisher) WaitPub(ctx context.Context) error {
 	p.lk.Lock()
 	consistent := p.lastpub == p.val
 	p.lk.Unlock()
 	if consistent {
-		return
+		return nil
 	}
 
-	wait := make(chan struct{})
-	p.pubnowch <- wait
-	<-wait
+	wait := make(chan error, 1)
+	select {
+	case p.pubnowch <- wait:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-wait:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (p *Republisher) Close() error {
@@ -254,17 +290,19 @@ func (np *Republisher) Run() {
 	for {
 		select {
 		case <-np.Publish:
-			quick := time.After(np.TimeoutShort)
-			longer := time.After(np.TimeoutLong)
+			tshort, tlong := np.timeouts()
+			quick := time.After(tshort)
+			longer := time.After(tlong)
 
 		wait:
-			var pubnowresp chan struct{}
+			var pubnowresp chan error
 
 			select {
 			case <-np.ctx.Done():
 				return
 			case <-np.Publish:
-				quick = time.After(np.TimeoutShort)
+				tshort, _ = np.timeouts()
+				quick = time.After(tshort)
 				goto wait
 			case <-quick:
 			case <-longer:
@@ -273,7 +311,7 @@ func (np *Republisher) Run() {
 
 			err := np.publish(np.ctx)
 			if pubnowresp != nil {
-				pubnowresp <- struct{}{}
+				pubnowresp <- err
 			}
 			if err != nil {
 				log.Errorf("republishRoot error: %s", err)
@@ -297,6 +335,16 @@ func (np *Republisher) publish(ctx context.Context) error {
 	}
 	np.lk.Lock()
 	np.lastpub = topub
+	np.lastpubAt = time.Now()
 	np.lk.Unlock()
 	return nil
 }
+
+// LastPublished returns the last successfully published cid and the time it
+// was published. Before any publish has succeeded it returns cid.Undef and
+// the zero time.
+func (np *Republisher) LastPublished() (cid.Cid, time.Time) {
+	np.lk.Lock()
+	defer np.lk.Unlock()
+	return np.lastpub, np.lastpubAt
+}
@@ -7,12 +7,16 @@ import (
 	"io/ioutil"
 	"testing"
 
+	ft "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs"
+	h "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs/importer/helpers"
+	trickle "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs/importer/trickle"
 	uio "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs/io"
 
 	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
 	chunker "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-chunker"
 	u "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-util"
 	ipld "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipld-format"
+	mdag "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-merkledag"
 	mdtest "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-merkledag/test"
 )
 
@@ -96,6 +100,90 @@ func TestBalancedDag(t *testing.T) {
 	}
 }
 
+func TestRelayout(t *testing.T) {
+	ctx := context.Background()
+	ds := mdtest.Mock()
+
+	buf := make([]byte, 1024*1024)
+	u.NewTimeSeededRand().Read(buf)
+
+	trickleNd, err := BuildTrickleDagFromReader(ds, chunker.NewSizeSplitter(bytes.NewReader(buf), 4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readBack := func(c cid.Cid) []byte {
+		nd, err := ds.Get(ctx, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dr, err := uio.NewDagReader(ctx, nd, ds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := ioutil.ReadAll(dr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	// trickle -> balanced
+	balCid, err := Relayout(ctx, ds, trickleNd.Cid(), BalancedLayout, h.DagBuilderParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(readBack(balCid), buf) {
+		t.Fatal("balanced relayout changed the content")
+	}
+
+	// the rebuilt root must carry consistent blocksizes
+	balNd, err := ds.Get(ctx, balCid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pbnd, ok := balNd.(*mdag.ProtoNode)
+	if !ok {
+		t.Fatal("expected a protobuf root node")
+	}
+	fsn, err := ft.FSNodeFromBytes(pbnd.Data())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fsn.FileSize() != uint64(len(buf)) {
+		t.Errorf("unexpected filesize: %d", fsn.FileSize())
+	}
+	var total uint64
+	for i := 0; i < fsn.NumChildren(); i++ {
+		total += fsn.BlockSize(i)
+	}
+	if total != fsn.FileSize() {
+		t.Errorf("blocksizes add up to %d, filesize is %d", total, fsn.FileSize())
+	}
+
+	// balanced -> trickle
+	trCid, err := Relayout(ctx, ds, balCid, TrickleLayout, h.DagBuilderParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(readBack(trCid), buf) {
+		t.Fatal("trickle relayout changed the content")
+	}
+
+	trNd, err := ds.Get(ctx, trCid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = trickle.VerifyTrickleDagStructure(trNd, trickle.VerifyParams{
+		Getter:      ds,
+		Direct:      h.DefaultLinksPerBlock,
+		LayerRepeat: 4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func BenchmarkBalancedReadSmallBlock(b *testing.B) {
 	b.StopTimer()
 	nbytes := int64(10000000)
@@ -0,0 +1,108 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	bal "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs/importer/balanced"
+	h "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs/importer/helpers"
+	trickle "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs/importer/trickle"
+	uio "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-unixfs/io"
+
+	chunker "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-chunker"
+	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
+	ipld "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipld-format"
+)
+
+// Layout selects the shape of the DAG produced by Relayout.
+type Layout int
+
+const (
+	// BalancedLayout is the wide, shallow layout optimized for random access.
+	BalancedLayout Layout = iota
+
+	// TrickleLayout is the layout optimized for sequential streaming.
+	TrickleLayout
+)
+
+// Relayout streams the content of the file rooted at 'c' through a new DAG
+// builder using the requested layout and returns the root of the rebuilt
+// file. The rebuilt file is read back and compared against the original
+// byte for byte before the new root is returned. The original blocks are
+// left in place; reclaiming them is up to the caller.
+func Relayout(ctx context.Context, dserv ipld.DAGService, c cid.Cid, layout Layout, dbp h.DagBuilderParams) (cid.Cid, error) {
+	if dbp.Dagserv == nil {
+		dbp.Dagserv = dserv
+	}
+	if dbp.Maxlinks == 0 {
+		dbp.Maxlinks = h.DefaultLinksPerBlock
+	}
+
+	nd, err := dserv.Get(ctx, c)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	dr, err := uio.NewDagReader(ctx, nd, dserv)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	db := dbp.New(chunker.DefaultSplitter(dr))
+
+	var newNd ipld.Node
+	switch layout {
+	case BalancedLayout:
+		newNd, err = bal.Layout(db)
+	case TrickleLayout:
+		newNd, err = trickle.Layout(db)
+	default:
+		return cid.Undef, fmt.Errorf("unknown layout %d", layout)
+	}
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	if err := verifySameContent(ctx, dserv, nd, newNd); err != nil {
+		return cid.Undef, err
+	}
+
+	return newNd.Cid(), nil
+}
+
+// verifySameContent reads both files back and compares them byte for byte.
+func verifySameContent(ctx context.Context, dserv ipld.DAGService, a, b ipld.Node) error {
+	ar, err := uio.NewDagReader(ctx, a, dserv)
+	if err != nil {
+		return err
+	}
+	br, err := uio.NewDagReader(ctx, b, dserv)
+	if err != nil {
+		return err
+	}
+
+	abuf := make([]byte, 128*1024)
+	bbuf := make([]byte, 128*1024)
+	for {
+		an, aerr := io.ReadFull(ar, abuf)
+		bn, berr := io.ReadFull(br, bbuf)
+		if an != bn || !bytes.Equal(abuf[:an], bbuf[:bn]) {
+			return fmt.Errorf("relayout verification failed: content mismatch")
+		}
+
+		adone := aerr == io.EOF || aerr == io.ErrUnexpectedEOF
+		bdone := berr == io.EOF || berr == io.ErrUnexpectedEOF
+		switch {
+		case adone && bdone:
+			return nil
+		case adone != bdone:
+			return fmt.Errorf("relayout verification failed: length mismatch")
+		case aerr != nil:
+			return aerr
+		case berr != nil:
+			return berr
+		}
+	}
+}
@@ -5,6 +5,8 @@ package unixfs
 
 import (
 	"errors"
+	"os"
+	"time"
 
 	proto "github.com/gogo/protobuf/proto"
 
@@ -284,6 +286,32 @@ func (n *FSNode) UpdateFilesize(filesizeDiff int64) {
 		int64(n.format.GetFilesize()) + filesizeDiff))
 }
 
+// Mode retrieves the unix permission bits stored on this node, zero when
+// no mode metadata was set.
+func (n *FSNode) Mode() os.FileMode {
+	return os.FileMode(n.format.GetMode() & 0xfff)
+}
+
+// SetMode stores the permission bits of the given mode on this node.
+func (n *FSNode) SetMode(m os.FileMode) {
+	n.format.Mode = proto.Uint32(uint32(m & 0xfff))
+}
+
+// Mtime retrieves the modification time stored on this node, the zero time
+// when no mtime metadata was set.
+func (n *FSNode) Mtime() time.Time {
+	if n.format.Mtime == nil {
+		return time.Time{}
+	}
+	return time.Unix(n.format.GetMtime(), 0)
+}
+
+// SetMtime stores the given modification time on this node, with second
+// precision.
+func (n *FSNode) SetMtime(ts time.Time) {
+	n.format.Mtime = proto.Int64(ts.Unix())
+}
+
 // Type retrieves the `Type` field from the internal `format`.
 func (n *FSNode) Type() pb.Data_DataType {
 	return n.format.GetType()
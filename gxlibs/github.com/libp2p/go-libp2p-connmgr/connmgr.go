@@ -32,6 +32,10 @@ type BasicConnMgr struct {
 	gracePeriod time.Duration
 	peers       map[peer.ID]*peerInfo
 
+	// protected peers are never pruned; the nested map tracks which tags
+	// protect the peer so independent subsystems don't clear each other
+	protected map[peer.ID]map[string]struct{}
+
 	// channel-based semaphore that enforces only a single trim is in progress
 	trimRunningCh chan struct{}
 	lastTrim      time.Time
@@ -51,10 +55,43 @@ func NewConnManager(low, hi int, grace time.Duration) *BasicConnMgr {
 		lowWater:      low,
 		gracePeriod:   grace,
 		peers:         make(map[peer.ID]*peerInfo),
+		protected:     make(map[peer.ID]map[string]struct{}),
 		trimRunningCh: make(chan struct{}, 1),
 	}
 }
 
+// Protect marks the peer as protected under the given tag: none of its
+// connections will be closed by TrimOpenConns until every protection tag
+// has been removed again with Unprotect.
+func (cm *BasicConnMgr) Protect(id peer.ID, tag string) {
+	cm.lk.Lock()
+	defer cm.lk.Unlock()
+
+	tags, ok := cm.protected[id]
+	if !ok {
+		tags = make(map[string]struct{}, 2)
+		cm.protected[id] = tags
+	}
+	tags[tag] = struct{}{}
+}
+
+// Unprotect removes the given protection tag from the peer, returning
+// whether the peer remains protected under other tags.
+func (cm *BasicConnMgr) Unprotect(id peer.ID, tag string) (protected bool) {
+	cm.lk.Lock()
+	defer cm.lk.Unlock()
+
+	tags, ok := cm.protected[id]
+	if !ok {
+		return false
+	}
+	if delete(tags, tag); len(tags) == 0 {
+		delete(cm.protected, id)
+		return false
+	}
+	return true
+}
+
 // peerInfo stores metadata for a given peer.
 type peerInfo struct {
 	tags  map[string]int // value for each tag
@@ -110,7 +147,11 @@ func (cm *BasicConnMgr) getConnsToClose(ctx context.Context) []inet.Conn {
 
 	var infos []*peerInfo
 
-	for _, inf := range cm.peers {
+	for pid, inf := range cm.peers {
+		if _, ok := cm.protected[pid]; ok {
+			// protected peers are never candidates for pruning
+			continue
+		}
 		infos = append(infos, inf)
 	}
 
@@ -120,6 +161,10 @@ func (cm *BasicConnMgr) getConnsToClose(ctx context.Context) []inet.Conn {
 	})
 
 	closeCount := len(infos) - cm.lowWater
+	if closeCount <= 0 {
+		// every peer above the low watermark is protected
+		return nil
+	}
 	toclose := infos[:closeCount]
 
 	// 2x number of peers we're disconnecting from because we may have more
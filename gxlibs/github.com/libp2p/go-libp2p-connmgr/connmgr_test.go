@@ -331,3 +331,49 @@ func TestQuickBurstRespectsSilencePeriod(t *testing.T) {
 		t.Fatalf("expected closed connections + open conn count to equal 30, value: %d", total)
 	}
 }
+
+func TestProtected(t *testing.T) {
+	cm := NewConnManager(1, 10, 0)
+	not := cm.Notifee()
+
+	prot := randConn(t, nil)
+	not.Connected(nil, prot)
+
+	var others []inet.Conn
+	for i := 0; i < 2; i++ {
+		rc := randConn(t, nil)
+		others = append(others, rc)
+		not.Connected(nil, rc)
+	}
+
+	// give the protected peer the worst score, so it would be the very
+	// first to be pruned if the protection didn't hold
+	cm.Protect(prot.RemotePeer(), "test")
+	cm.TagPeer(prot.RemotePeer(), "bad", -10)
+
+	cm.TrimOpenConns(context.Background())
+
+	if prot.(*tconn).closed {
+		t.Fatal("the protected connection must survive a trim")
+	}
+
+	var closed int
+	for _, c := range others {
+		if c.(*tconn).closed {
+			closed++
+		}
+	}
+	if closed != 1 {
+		t.Fatalf("expected one unprotected connection to be closed, got %d", closed)
+	}
+
+	// removing an unrelated tag leaves the peer protected
+	if !cm.Unprotect(prot.RemotePeer(), "unrelated") {
+		t.Fatal("expected the peer to remain protected under the original tag")
+	}
+
+	// removing the last tag clears the protection
+	if cm.Unprotect(prot.RemotePeer(), "test") {
+		t.Fatal("expected removing the last tag to clear the protection")
+	}
+}
@@ -31,6 +31,19 @@ type ConnManager interface {
 	// Notifee returns an implementation that can be called back to inform of
 	// opened and closed connections.
 	Notifee() inet.Notifiee
+
+	// Protect protects a peer from having its connection(s) pruned.
+	//
+	// Tagging allows different parts of the system to manage protections without
+	// interfering with one another.
+	Protect(id peer.ID, tag string)
+
+	// Unprotect removes a protection that may have been placed on a peer, under
+	// the specified tag.
+	//
+	// The return value indicates whether the peer continues to be protected after
+	// this call, by way of a different tag.
+	Unprotect(id peer.ID, tag string) (protected bool)
 }
 
 // TagInfo stores metadata associated with a peer.
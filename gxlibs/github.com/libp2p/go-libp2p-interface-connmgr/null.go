@@ -15,6 +15,8 @@ func (_ NullConnMgr) UntagPeer(peer.ID, string)     {}
 func (_ NullConnMgr) GetTagInfo(peer.ID) *TagInfo   { return &TagInfo{} }
 func (_ NullConnMgr) TrimOpenConns(context.Context) {}
 func (_ NullConnMgr) Notifee() inet.Notifiee        { return &cmNotifee{} }
+func (_ NullConnMgr) Protect(peer.ID, string)       {}
+func (_ NullConnMgr) Unprotect(peer.ID, string) bool { return false }
 
 type cmNotifee struct{}
 
@@ -471,6 +471,32 @@ func (dht *IpfsDHT) FindProvidersAsync(ctx context.Context, key cid.Cid, count i
 	return peerOut
 }
 
+// LocalFindProvidersAsync returns providers for the given key known to the
+// local provider store, without issuing any network queries. At most count
+// providers are sent on the returned channel before it is closed.
+func (dht *IpfsDHT) LocalFindProvidersAsync(ctx context.Context, key cid.Cid, count int) <-chan pstore.PeerInfo {
+	log.Event(ctx, "findProvidersLocal", key)
+	peerOut := make(chan pstore.PeerInfo, count)
+	go func() {
+		defer close(peerOut)
+		ps := pset.NewLimited(count)
+		for _, p := range dht.providers.GetProviders(ctx, key) {
+			if !ps.TryAdd(p) {
+				continue
+			}
+			select {
+			case peerOut <- dht.peerstore.PeerInfo(p):
+			case <-ctx.Done():
+				return
+			}
+			if ps.Size() >= count {
+				return
+			}
+		}
+	}()
+	return peerOut
+}
+
 func (dht *IpfsDHT) findProvidersAsyncRoutine(ctx context.Context, key cid.Cid, count int, peerOut chan pstore.PeerInfo) {
 	defer log.EventBegin(ctx, "findProvidersAsync", key).Done()
 	defer close(peerOut)
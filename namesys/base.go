@@ -13,6 +13,7 @@ import (
 type onceResult struct {
 	value path.Path
 	ttl   time.Duration
+	src   Source
 	err   error
 }
 
@@ -69,12 +70,12 @@ func resolveAsync(ctx context.Context, r resolver, name string, options opts.Res
 				}
 				log.Debugf("resolved %s to %s", name, res.value.String())
 				if !strings.HasPrefix(res.value.String(), ipnsPrefix) {
-					emitResult(ctx, outCh, Result{Path: res.value})
+					emitResult(ctx, outCh, Result{Path: res.value, TTL: res.ttl, Source: res.src})
 					break
 				}
 
 				if depth == 1 {
-					emitResult(ctx, outCh, Result{Path: res.value, Err: ErrResolveRecursion})
+					emitResult(ctx, outCh, Result{Path: res.value, TTL: res.ttl, Source: res.src, Err: ErrResolveRecursion})
 					break
 				}
 
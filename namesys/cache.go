@@ -6,14 +6,14 @@ import (
 	path "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-path"
 )
 
-func (ns *mpns) cacheGet(name string) (path.Path, bool) {
+func (ns *mpns) cacheGet(name string) (path.Path, time.Duration, bool) {
 	if ns.cache == nil {
-		return "", false
+		return "", 0, false
 	}
 
 	ientry, ok := ns.cache.Get(name)
 	if !ok {
-		return "", false
+		return "", 0, false
 	}
 
 	entry, ok := ientry.(cacheEntry)
@@ -22,13 +22,13 @@ func (ns *mpns) cacheGet(name string) (path.Path, bool) {
 		log.Panicf("unexpected type %T in cache for %q.", ientry, name)
 	}
 
-	if time.Now().Before(entry.eol) {
-		return entry.val, true
+	if now := time.Now(); now.Before(entry.eol) {
+		return entry.val, entry.eol.Sub(now), true
 	}
 
 	ns.cache.Remove(name)
 
-	return "", false
+	return "", 0, false
 }
 
 func (ns *mpns) cacheSet(name string, val path.Path, ttl time.Duration) {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net"
 	"strings"
+	"time"
 
 	opts "github.com/ipsn/go-ipfs/namesys/opts"
 
@@ -14,16 +15,36 @@ import (
 
 type LookupTXTFunc func(name string) (txt []string, err error)
 
+// LookupTXTTTLFunc resolves TXT records and additionally reports how long
+// the answer may be cached, usually the smallest TTL of the DNS response. A
+// zero ttl disables caching of the result.
+type LookupTXTTTLFunc func(name string) (txt []string, ttl time.Duration, err error)
+
 // DNSResolver implements a Resolver on DNS domains
 type DNSResolver struct {
-	lookupTXT LookupTXTFunc
-	// TODO: maybe some sort of caching?
-	// cache would need a timeout
+	lookupTXT LookupTXTTTLFunc
 }
 
 // NewDNSResolver constructs a name resolver using DNS TXT records.
 func NewDNSResolver() *DNSResolver {
-	return &DNSResolver{lookupTXT: net.LookupTXT}
+	// net.LookupTXT does not expose the record TTL, so results resolved
+	// through it are not cached
+	return &DNSResolver{lookupTXT: wrapLookupTXT(net.LookupTXT)}
+}
+
+// NewDNSResolverWithLookup constructs a name resolver around a custom TXT
+// lookup, e.g. one pointed at an internal DNS server. The reported ttl is
+// honored when caching dnslink results.
+func NewDNSResolverWithLookup(lookup LookupTXTTTLFunc) *DNSResolver {
+	return &DNSResolver{lookupTXT: lookup}
+}
+
+// wrapLookupTXT adapts a ttl-less lookup function, reporting a zero ttl.
+func wrapLookupTXT(lookup LookupTXTFunc) LookupTXTTTLFunc {
+	return func(name string) ([]string, time.Duration, error) {
+		txt, err := lookup(name)
+		return txt, 0, err
+	}
 }
 
 // Resolve implements Resolver.
@@ -38,6 +59,7 @@ func (r *DNSResolver) ResolveAsync(ctx context.Context, name string, options ...
 
 type lookupRes struct {
 	path  path.Path
+	ttl   time.Duration
 	error error
 }
 
@@ -80,7 +102,7 @@ func (r *DNSResolver) resolveOnceAsync(ctx context.Context, name string, options
 				}
 				if subRes.error == nil {
 					p, err := appendPath(subRes.path)
-					emitOnceResult(ctx, out, onceResult{value: p, err: err})
+					emitOnceResult(ctx, out, onceResult{value: p, ttl: subRes.ttl, src: SourceDNSLink, err: err})
 					return
 				}
 			case rootRes, ok := <-rootChan:
@@ -90,7 +112,7 @@ func (r *DNSResolver) resolveOnceAsync(ctx context.Context, name string, options
 				}
 				if rootRes.error == nil {
 					p, err := appendPath(rootRes.path)
-					emitOnceResult(ctx, out, onceResult{value: p, err: err})
+					emitOnceResult(ctx, out, onceResult{value: p, ttl: rootRes.ttl, src: SourceDNSLink, err: err})
 				}
 			case <-ctx.Done():
 				return
@@ -107,21 +129,21 @@ func (r *DNSResolver) resolveOnceAsync(ctx context.Context, name string, options
 func workDomain(r *DNSResolver, name string, res chan lookupRes) {
 	defer close(res)
 
-	txt, err := r.lookupTXT(name)
+	txt, ttl, err := r.lookupTXT(name)
 	if err != nil {
 		// Error is != nil
-		res <- lookupRes{"", err}
+		res <- lookupRes{"", 0, err}
 		return
 	}
 
 	for _, t := range txt {
 		p, err := parseEntry(t)
 		if err == nil {
-			res <- lookupRes{p, nil}
+			res <- lookupRes{p, ttl, nil}
 			return
 		}
 	}
-	res <- lookupRes{"", ErrResolveFailed}
+	res <- lookupRes{"", 0, ErrResolveFailed}
 }
 
 func parseEntry(txt string) (path.Path, error) {
@@ -1,22 +1,29 @@
 package namesys
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	opts "github.com/ipsn/go-ipfs/namesys/opts"
+
+	ds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore"
+	dssync "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore/sync"
+	mockrouting "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-routing/mock"
+	testutil "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-testutil"
 )
 
 type mockDNS struct {
 	entries map[string][]string
 }
 
-func (m *mockDNS) lookupTXT(name string) (txt []string, err error) {
+func (m *mockDNS) lookupTXT(name string) (txt []string, ttl time.Duration, err error) {
 	txt, ok := m.entries[name]
 	if !ok {
-		return nil, fmt.Errorf("no TXT entry for %s", name)
+		return nil, 0, fmt.Errorf("no TXT entry for %s", name)
 	}
-	return txt, nil
+	return txt, 0, nil
 }
 
 func TestDnsEntryParsing(t *testing.T) {
@@ -160,3 +167,59 @@ func TestDNSResolution(t *testing.T) {
 	testResolution(t, r, "double.example.com", opts.DefaultDepthLimit, "/ipfs/QmY3hE8xgFCjGcz6PHgnvJz5HZi1BaKRfPkn1ghZUcYMjD", nil)
 	testResolution(t, r, "conflict.example.com", opts.DefaultDepthLimit, "/ipfs/QmY3hE8xgFCjGcz6PHgnvJz5HZi1BaKRfPkn1ghZUcYMjE", nil)
 }
+
+func TestDNSResolutionTTL(t *testing.T) {
+	ctx := context.Background()
+
+	const domain = "ttl.example.com"
+	target := "/ipfs/QmY3hE8xgFCjGcz6PHgnvJz5HZi1BaKRfPkn1ghZUcYMjD"
+	lookups := 0
+	lookup := func(name string) ([]string, time.Duration, error) {
+		if name != domain {
+			// the "_dnslink." prefixed domain is probed in parallel
+			// on every resolution, only count hits on the root so
+			// each lookup round counts once
+			return nil, 0, fmt.Errorf("no TXT entry for %s", name)
+		}
+		lookups++
+		return []string{"dnslink=" + target}, 50 * time.Millisecond, nil
+	}
+
+	dst := dssync.MutexWrap(ds.NewMapDatastore())
+	routing := mockrouting.NewServer().ClientWithDatastore(ctx, testutil.RandIdentityOrFatal(t), dst)
+	nsys := NewNameSystem(routing, dst, 128, WithDNSResolver(NewDNSResolverWithLookup(lookup)))
+
+	p, err := nsys.Resolve(ctx, "/ipns/"+domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != target {
+		t.Fatalf("resolved to %s != %s", p, target)
+	}
+	if lookups != 1 {
+		t.Fatalf("expected 1 lookup, got %d", lookups)
+	}
+
+	// Within the TTL the cached result is served without hitting DNS.
+	if _, err := nsys.Resolve(ctx, "/ipns/"+domain); err != nil {
+		t.Fatal(err)
+	}
+	if lookups != 1 {
+		t.Fatalf("expected cached resolution, got %d lookups", lookups)
+	}
+
+	// After the TTL expires the record is fetched again, observing updates.
+	target = "/ipfs/QmY3hE8xgFCjGcz6PHgnvJz5HZi1BaKRfPkn1ghZUcYMjE"
+	time.Sleep(100 * time.Millisecond)
+
+	p, err = nsys.Resolve(ctx, "/ipns/"+domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != target {
+		t.Fatalf("resolved to %s != %s", p, target)
+	}
+	if lookups != 2 {
+		t.Fatalf("expected re-resolution after ttl expiry, got %d lookups", lookups)
+	}
+}
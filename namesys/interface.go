@@ -63,10 +63,33 @@ type NameSystem interface {
 	Publisher
 }
 
+// Source identifies the subsystem a resolution result came from.
+type Source string
+
+const (
+	// SourceCache marks results served from the local resolver cache.
+	SourceCache Source = "cache"
+	// SourceDHT marks results retrieved and verified through the routing
+	// system.
+	SourceDHT Source = "dht"
+	// SourceDNSLink marks results read from DNS TXT records.
+	SourceDNSLink Source = "dnslink"
+	// SourceProquint marks results decoded from the name itself.
+	SourceProquint Source = "proquint"
+)
+
 // Result is the return type for Resolver.ResolveAsync.
 type Result struct {
 	Path path.Path
-	Err  error
+
+	// TTL is how long the result may be cached for. Zero means unknown.
+	TTL time.Duration
+
+	// Source reports where the record that produced this result came
+	// from. For recursive lookups it refers to the last step.
+	Source Source
+
+	Err error
 }
 
 // Resolver is an object capable of resolving names.
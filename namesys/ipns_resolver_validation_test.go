@@ -139,9 +139,78 @@ func TestResolverValidation(t *testing.T) {
 	}
 }
 
+func TestResolverValidationPkPublication(t *testing.T) {
+	ctx := context.Background()
+	rid := testutil.RandIdentityOrFatal(t)
+	dstore := dssync.MutexWrap(ds.NewMapDatastore())
+	peerstore := pstoremem.NewPeerstore()
+
+	vstore := newMockValueStore(rid, dstore, peerstore)
+	resolver := NewIpnsResolver(vstore)
+
+	p := path.Path("/ipfs/QmfM2r8seH2GiRaC4esTjeraXEachRt8ZsSeGaWTPLyMoG")
+
+	// In auto mode the publisher stores a public key record whenever the
+	// key cannot be derived from the peer ID. RSA keys never can, so the
+	// record must be there and resolution must work by fetching it.
+	publisher := NewIpnsPublisher(vstore, dstore)
+	privRsa, idRsa, pubkDHTPathRsa, _ := genKeys(t)
+	if err := publisher.Publish(ctx, privRsa, p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vstore.GetValue(ctx, pubkDHTPathRsa); err != nil {
+		t.Fatalf("expected public key record for RSA identity in auto mode: %s", err)
+	}
+	resp, err := resolve(ctx, resolver, idRsa.Pretty(), opts.DefaultResolveOpts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp != p {
+		t.Fatalf("Mismatch between published path %s and resolved path %s", p, resp)
+	}
+
+	// Peer IDs in this tree are always hashes, so even an Ed25519 key
+	// can't be derived from the ID and auto mode publishes a public key
+	// record for it as well.
+	privEd, idEd, pubkDHTPathEd, _ := genKeysWithType(t, ci.Ed25519, 256)
+	if err := publisher.Publish(ctx, privEd, p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vstore.GetValue(ctx, pubkDHTPathEd); err != nil {
+		t.Fatalf("expected public key record for Ed25519 identity in auto mode: %s", err)
+	}
+	resp, err = resolve(ctx, resolver, idEd.Pretty(), opts.DefaultResolveOpts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp != p {
+		t.Fatalf("Mismatch between published path %s and resolved path %s", p, resp)
+	}
+
+	// With pk publication suppressed the IPNS record is stored but no
+	// public key record is, and the resolver reports the missing key
+	// distinctly.
+	neverPub := NewIpnsPublisher(vstore, dstore, WithPkPublishMode(PkPublishNever))
+	privNever, idNever, pubkDHTPathNever, _ := genKeys(t)
+	if err := neverPub.Publish(ctx, privNever, p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vstore.GetValue(ctx, pubkDHTPathNever); err == nil {
+		t.Fatal("expected no public key record with pk publication disabled")
+	}
+	_, err = resolve(ctx, resolver, idNever.Pretty(), opts.DefaultResolveOpts())
+	if err != ErrPublicKeyNotFound {
+		t.Fatalf("expected ErrPublicKeyNotFound, got %v", err)
+	}
+}
+
 func genKeys(t *testing.T) (ci.PrivKey, peer.ID, string, string) {
+	return genKeysWithType(t, ci.RSA, 1024)
+}
+
+func genKeysWithType(t *testing.T, typ, bits int) (ci.PrivKey, peer.ID, string, string) {
 	sr := u.NewTimeSeededRand()
-	priv, _, err := ci.GenerateKeyPairWithReader(ci.RSA, 1024, sr)
+	priv, _, err := ci.GenerateKeyPairWithReader(typ, bits, sr)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -34,20 +34,36 @@ type mpns struct {
 	cache *lru.Cache
 }
 
+// NameSystemOption customizes the name system returned by NewNameSystem.
+type NameSystemOption func(*mpns)
+
+// WithDNSResolver replaces the default DNS resolver, allowing dnslink
+// lookups to go through a custom TXT resolver whose record TTLs are
+// honored when caching results.
+func WithDNSResolver(rslv *DNSResolver) NameSystemOption {
+	return func(ns *mpns) {
+		ns.dnsResolver = rslv
+	}
+}
+
 // NewNameSystem will construct the IPFS naming system based on Routing
-func NewNameSystem(r routing.ValueStore, ds ds.Datastore, cachesize int) NameSystem {
+func NewNameSystem(r routing.ValueStore, ds ds.Datastore, cachesize int, options ...NameSystemOption) NameSystem {
 	var cache *lru.Cache
 	if cachesize > 0 {
 		cache, _ = lru.New(cachesize)
 	}
 
-	return &mpns{
+	ns := &mpns{
 		dnsResolver:      NewDNSResolver(),
 		proquintResolver: new(ProquintResolver),
 		ipnsResolver:     NewIpnsResolver(r),
 		ipnsPublisher:    NewIpnsPublisher(r, ds),
 		cache:            cache,
 	}
+	for _, opt := range options {
+		opt(ns)
+	}
+	return ns
 }
 
 const DefaultResolverCacheTTL = time.Minute
@@ -69,13 +85,13 @@ func (ns *mpns) ResolveAsync(ctx context.Context, name string, options ...opts.R
 	res := make(chan Result, 1)
 	if strings.HasPrefix(name, "/ipfs/") {
 		p, err := path.ParsePath(name)
-		res <- Result{p, err}
+		res <- Result{Path: p, Err: err}
 		return res
 	}
 
 	if !strings.HasPrefix(name, "/") {
 		p, err := path.ParsePath("/ipfs/" + name)
-		res <- Result{p, err}
+		res <- Result{Path: p, Err: err}
 		return res
 	}
 
@@ -99,16 +115,16 @@ func (ns *mpns) resolveOnceAsync(ctx context.Context, name string, options opts.
 
 	key := segments[2]
 
-	if p, ok := ns.cacheGet(key); ok {
+	if p, ttl, ok := ns.cacheGet(key); ok {
 		if len(segments) > 3 {
 			var err error
 			p, err = path.FromSegments("", strings.TrimRight(p.String(), "/"), segments[3])
 			if err != nil {
-				emitOnceResult(ctx, out, onceResult{value: p, err: err})
+				emitOnceResult(ctx, out, onceResult{value: p, ttl: ttl, src: SourceCache, err: err})
 			}
 		}
 
-		out <- onceResult{value: p}
+		out <- onceResult{value: p, ttl: ttl, src: SourceCache}
 		close(out)
 		return out
 	}
@@ -150,11 +166,11 @@ func (ns *mpns) resolveOnceAsync(ctx context.Context, name string, options opts.
 					var err error
 					p, err = path.FromSegments("", strings.TrimRight(p.String(), "/"), segments[3])
 					if err != nil {
-						emitOnceResult(ctx, out, onceResult{value: p, ttl: res.ttl, err: err})
+						emitOnceResult(ctx, out, onceResult{value: p, ttl: res.ttl, src: res.src, err: err})
 					}
 				}
 
-				emitOnceResult(ctx, out, onceResult{value: p, ttl: res.ttl, err: res.err})
+				emitOnceResult(ctx, out, onceResult{value: p, ttl: res.ttl, src: res.src, err: res.err})
 			case <-ctx.Done():
 				return
 			}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	opts "github.com/ipsn/go-ipfs/namesys/opts"
 
 	ci "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-crypto"
 	ipns "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipns"
+	testutil "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-testutil"
 	peer "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peer"
 	pstoremem "github.com/ipsn/go-ipfs/gxlibs/github.com/libp2p/go-libp2p-peerstore/pstoremem"
 	path "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-path"
@@ -107,3 +109,102 @@ func TestPublishWithCache0(t *testing.T) {
 	}
 	nsys.Publish(context.Background(), priv, p)
 }
+
+// progressionResolver replays a fixed sequence of results, standing in for a
+// routing system where better records keep arriving.
+type progressionResolver struct {
+	results []onceResult
+}
+
+func (r *progressionResolver) resolveOnceAsync(ctx context.Context, name string, options opts.ResolveOpts) <-chan onceResult {
+	out := make(chan onceResult, len(r.results))
+	for _, res := range r.results {
+		out <- res
+	}
+	close(out)
+	return out
+}
+
+func TestResolveAsyncProgression(t *testing.T) {
+	older := path.FromString("/ipfs/Qmcqtw8FfrVSBaRmbWwHxt3AuySBhJLcvmFYi3Lbc4xnwj")
+	newer := path.FromString("/ipfs/QmZULkCELmmk5XNfCgTnCyFgAVxBRBXyDHGGMVoLFLiXEN")
+
+	r := &progressionResolver{results: []onceResult{
+		{value: older, ttl: time.Minute, src: SourceDHT},
+		{value: newer, ttl: time.Minute, src: SourceDHT},
+	}}
+
+	var got []Result
+	for res := range resolveAsync(context.Background(), r, "QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy", opts.DefaultResolveOpts()) {
+		if res.Err != nil {
+			t.Fatal(res.Err)
+		}
+		got = append(got, res)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected both improvements to be streamed, got %d results", len(got))
+	}
+	if got[0].Path != older || got[1].Path != newer {
+		t.Fatalf("unexpected progression: %s, %s", got[0].Path, got[1].Path)
+	}
+	for _, res := range got {
+		if res.Source != SourceDHT {
+			t.Errorf("expected a dht source, got %q", res.Source)
+		}
+		if res.TTL != time.Minute {
+			t.Errorf("expected the record ttl to be passed through, got %s", res.TTL)
+		}
+	}
+}
+
+func TestResolveAsyncSources(t *testing.T) {
+	ctx := context.Background()
+	dstore := dssync.MutexWrap(ds.NewMapDatastore())
+	peerstore := pstoremem.NewPeerstore()
+	vstore := newMockValueStore(testutil.RandIdentityOrFatal(t), dstore, peerstore)
+
+	priv, id, _, _ := genKeys(t)
+	if err := peerstore.AddPubKey(id, priv.GetPublic()); err != nil {
+		t.Fatal(err)
+	}
+
+	h := path.FromString("/ipfs/Qmcqtw8FfrVSBaRmbWwHxt3AuySBhJLcvmFYi3Lbc4xnwj")
+	if err := NewIpnsPublisher(vstore, dstore).Publish(ctx, priv, h); err != nil {
+		t.Fatal(err)
+	}
+
+	ns := NewNameSystem(vstore, dstore, 128)
+
+	// the first lookup has to go through the routing system
+	var last Result
+	for res := range ns.ResolveAsync(ctx, "/ipns/"+id.Pretty()) {
+		if res.Err != nil {
+			t.Fatal(res.Err)
+		}
+		last = res
+	}
+	if last.Path != h {
+		t.Fatalf("resolved to %s != %s", last.Path, h)
+	}
+	if last.Source != SourceDHT {
+		t.Fatalf("expected a dht source, got %q", last.Source)
+	}
+	if last.TTL <= 0 {
+		t.Fatalf("expected a positive ttl, got %s", last.TTL)
+	}
+
+	// the second one is served from the cache primed by the first
+	for res := range ns.ResolveAsync(ctx, "/ipns/"+id.Pretty()) {
+		if res.Err != nil {
+			t.Fatal(res.Err)
+		}
+		last = res
+	}
+	if last.Source != SourceCache {
+		t.Fatalf("expected a cache source, got %q", last.Source)
+	}
+	if last.TTL <= 0 || last.TTL > DefaultResolverCacheTTL {
+		t.Fatalf("expected the remaining cache ttl, got %s", last.TTL)
+	}
+}
@@ -26,22 +26,56 @@ const ipnsPrefix = "/ipns/"
 const PublishPutValTimeout = time.Minute
 const DefaultRecordTTL = 24 * time.Hour
 
+// PkPublishMode controls whether a publisher stores a separate public key
+// record in the routing system alongside each IPNS record.
+type PkPublishMode int
+
+const (
+	// PkPublishAuto publishes a public key record only when the key cannot
+	// be derived from the peer ID (e.g. RSA identities). This is the
+	// default.
+	PkPublishAuto PkPublishMode = iota
+	// PkPublishAlways publishes a public key record for every identity,
+	// even when the key is embedded in the peer ID.
+	PkPublishAlways
+	// PkPublishNever suppresses public key records entirely. Names
+	// published by identities whose key cannot be derived from the peer ID
+	// will not be resolvable by nodes that can't obtain the key elsewhere.
+	PkPublishNever
+)
+
+// PublisherOption configures an IpnsPublisher.
+type PublisherOption func(*IpnsPublisher)
+
+// WithPkPublishMode configures when the publisher stores public key records
+// in the routing system.
+func WithPkPublishMode(mode PkPublishMode) PublisherOption {
+	return func(p *IpnsPublisher) {
+		p.pkMode = mode
+	}
+}
+
 // IpnsPublisher is capable of publishing and resolving names to the IPFS
 // routing system.
 type IpnsPublisher struct {
 	routing routing.ValueStore
 	ds      ds.Datastore
+	pkMode  PkPublishMode
 
 	// Used to ensure we assign IPNS records *sequential* sequence numbers.
 	mu sync.Mutex
 }
 
 // NewIpnsPublisher constructs a publisher for the IPFS Routing name system.
-func NewIpnsPublisher(route routing.ValueStore, ds ds.Datastore) *IpnsPublisher {
+func NewIpnsPublisher(route routing.ValueStore, ds ds.Datastore, opts ...PublisherOption) *IpnsPublisher {
 	if ds == nil {
 		panic("nil datastore")
 	}
-	return &IpnsPublisher{routing: route, ds: ds}
+	p := &IpnsPublisher{routing: route, ds: ds}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
 // Publish implements Publisher. Accepts a keypair and a value,
@@ -194,7 +228,7 @@ func (p *IpnsPublisher) PublishWithEOL(ctx context.Context, k ci.PrivKey, value
 		return err
 	}
 
-	return PutRecordToRouting(ctx, p.routing, k.GetPublic(), record)
+	return putRecordToRouting(ctx, p.routing, k.GetPublic(), record, p.pkMode)
 }
 
 // setting the TTL on published records is an experimental feature.
@@ -210,7 +244,13 @@ func checkCtxTTL(ctx context.Context) (time.Duration, bool) {
 	return d, ok
 }
 
+// PutRecordToRouting stores the given IPNS entry in the routing system,
+// publishing a separate public key record with PkPublishAuto semantics.
 func PutRecordToRouting(ctx context.Context, r routing.ValueStore, k ci.PubKey, entry *pb.IpnsEntry) error {
+	return putRecordToRouting(ctx, r, k, entry, PkPublishAuto)
+}
+
+func putRecordToRouting(ctx context.Context, r routing.ValueStore, k ci.PubKey, entry *pb.IpnsEntry, pkMode PkPublishMode) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -229,14 +269,24 @@ func PutRecordToRouting(ctx context.Context, r routing.ValueStore, k ci.PubKey,
 		errs <- PublishEntry(ctx, r, ipns.RecordKey(id), entry)
 	}()
 
-	// Publish the public key if a public key cannot be extracted from the ID
-	// TODO: once v0.4.16 is widespread enough, we can stop doing this
-	// and at that point we can even deprecate the /pk/ namespace in the dht
-	//
-	// NOTE: This check actually checks if the public key has been embedded
-	// in the IPNS entry. This check is sufficient because we embed the
-	// public key in the IPNS entry if it can't be extracted from the ID.
-	if entry.PubKey != nil {
+	publishPk := false
+	switch pkMode {
+	case PkPublishAlways:
+		publishPk = true
+	case PkPublishNever:
+		publishPk = false
+	default:
+		// Publish the public key if a public key cannot be extracted from the ID
+		// TODO: once v0.4.16 is widespread enough, we can stop doing this
+		// and at that point we can even deprecate the /pk/ namespace in the dht
+		//
+		// NOTE: This check actually checks if the public key has been embedded
+		// in the IPNS entry. This check is sufficient because we embed the
+		// public key in the IPNS entry if it can't be extracted from the ID.
+		publishPk = entry.PubKey != nil
+	}
+
+	if publishPk {
 		go func() {
 			errs <- PublishPublicKey(ctx, r, PkKeyForID(id), k)
 		}()
@@ -2,6 +2,7 @@ package namesys
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 
 	opts "github.com/ipsn/go-ipfs/namesys/opts"
 
+	ds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore"
 	ipns "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipns"
 	pb "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipns/pb"
 	cid "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-cid"
@@ -22,6 +24,12 @@ import (
 
 var log = logging.Logger("namesys")
 
+// ErrPublicKeyNotFound is returned when the public key needed to verify an
+// IPNS record is neither derivable from the peer ID nor present in the
+// routing system, e.g. because the publisher suppressed public key record
+// publication.
+var ErrPublicKeyNotFound = errors.New("public key for ipns name not found")
+
 // IpnsResolver implements NSResolver for the main IPFS SFS-like naming
 type IpnsResolver struct {
 	routing routing.ValueStore
@@ -78,6 +86,12 @@ func (r *IpnsResolver) resolveOnceAsync(ctx context.Context, name string, option
 	_, err = routing.GetPublicKey(r.routing, ctx, pid)
 	if err != nil {
 		log.Debugf("RoutingResolver: could not retrieve public key %s: %s\n", name, err)
+		switch err {
+		case routing.ErrNotFound, ds.ErrNotFound:
+			// The public key record is simply absent, which is what
+			// happens when the publisher never published one.
+			err = ErrPublicKeyNotFound
+		}
 		out <- onceResult{err: err}
 		close(out)
 		cancel()
@@ -152,7 +166,7 @@ func (r *IpnsResolver) resolveOnceAsync(ctx context.Context, name string, option
 					return
 				}
 
-				emitOnceResult(ctx, out, onceResult{value: p, ttl: ttl})
+				emitOnceResult(ctx, out, onceResult{value: p, ttl: ttl, src: SourceDHT})
 			case <-ctx.Done():
 				return
 			}
@@ -22,6 +22,10 @@ var log = logging.Logger("pin")
 
 var pinDatastoreKey = ds.NewKey("/local/pins")
 
+// pinNamesPrefix is the datastore prefix under which optional human-readable
+// pin names are stored, one entry per pinned cid.
+var pinNamesPrefix = ds.NewKey("/local/pins/names")
+
 var emptyKey cid.Cid
 
 func init() {
@@ -137,6 +141,14 @@ type Pinner interface {
 	// be successful.
 	RemovePinWithMode(cid.Cid, Mode)
 
+	// SetPinName attaches a human-readable name to a pinned cid. An empty
+	// name removes any existing one.
+	SetPinName(cid.Cid, string) error
+
+	// PinName returns the name attached to the given cid, or the empty
+	// string if none was set.
+	PinName(cid.Cid) string
+
 	// Flush writes the pin state to the backing datastore
 	Flush() error
 
@@ -280,17 +292,51 @@ func (p *pinner) Unpin(ctx context.Context, c cid.Cid, recursive bool) error {
 	case "recursive":
 		if recursive {
 			p.recursePin.Remove(c)
+			p.removeName(c)
 			return nil
 		}
 		return fmt.Errorf("%s is pinned recursively", c)
 	case "direct":
 		p.directPin.Remove(c)
+		p.removeName(c)
 		return nil
 	default:
 		return fmt.Errorf("%s is pinned indirectly under %s", c, reason)
 	}
 }
 
+// SetPinName attaches a human-readable name to a pinned cid. An empty name
+// removes any existing one. Names live directly in the datastore rather
+// than in the DAG-based pin sets, so setting one does not require a Flush.
+func (p *pinner) SetPinName(c cid.Cid, name string) error {
+	if name == "" {
+		err := p.dstore.Delete(pinNamesPrefix.ChildString(c.String()))
+		if err == ds.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	return p.dstore.Put(pinNamesPrefix.ChildString(c.String()), []byte(name))
+}
+
+// PinName returns the name attached to the given cid, or the empty string
+// if none was set.
+func (p *pinner) PinName(c cid.Cid) string {
+	name, err := p.dstore.Get(pinNamesPrefix.ChildString(c.String()))
+	if err != nil {
+		return ""
+	}
+	return string(name)
+}
+
+// removeName drops any name attached to an unpinned cid. Missing entries
+// are not an error.
+func (p *pinner) removeName(c cid.Cid) {
+	if err := p.dstore.Delete(pinNamesPrefix.ChildString(c.String())); err != nil && err != ds.ErrNotFound {
+		log.Warningf("cannot remove pin name for %s: %s", c, err)
+	}
+}
+
 func (p *pinner) isInternalPin(c cid.Cid) bool {
 	return p.internalPin.Has(c)
 }
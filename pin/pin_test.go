@@ -2,6 +2,7 @@ package pin
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 	offline "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipfs-exchange-offline"
 	ds "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore"
 	dssync "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-datastore/sync"
+	ipld "github.com/ipsn/go-ipfs/gxlibs/github.com/ipfs/go-ipld-format"
 )
 
 var rand = util.NewTimeSeededRand()
@@ -406,3 +408,80 @@ func TestPinUpdate(t *testing.T) {
 	assertPinned(t, p, c2, "c2 should be pinned still")
 	assertPinned(t, p, c1, "c1 should be pinned now")
 }
+
+// countingDAG counts Get calls so tests can assert how much of a graph an
+// operation had to touch.
+type countingDAG struct {
+	ipld.DAGService
+	gets int
+}
+
+func (cg *countingDAG) Get(ctx context.Context, c cid.Cid) (ipld.Node, error) {
+	cg.gets++
+	return cg.DAGService.Get(ctx, c)
+}
+
+func TestPinUpdateTouchesOnlyDiff(t *testing.T) {
+	ctx := context.Background()
+
+	dstore := dssync.MutexWrap(ds.NewMapDatastore())
+	bstore := blockstore.NewBlockstore(dstore)
+	bserv := bs.New(bstore, offline.Exchange(bstore))
+
+	counting := &countingDAG{DAGService: mdag.NewDAGService(bserv)}
+	p := NewPinner(dstore, counting, counting)
+
+	// makeTree builds a two-level tree with 10k leaves; the two versions
+	// differ only in the very last leaf
+	makeTree := func(lastLeaf string) *mdag.ProtoNode {
+		root := new(mdag.ProtoNode)
+		for i := 0; i < 100; i++ {
+			mid := new(mdag.ProtoNode)
+			for j := 0; j < 100; j++ {
+				leaf := new(mdag.ProtoNode)
+				data := fmt.Sprintf("leaf-%d-%d", i, j)
+				if i == 99 && j == 99 {
+					data = lastLeaf
+				}
+				leaf.SetData([]byte(data))
+				if err := counting.Add(ctx, leaf); err != nil {
+					t.Fatal(err)
+				}
+				if err := mid.AddNodeLink(fmt.Sprintf("%d", j), leaf); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := counting.Add(ctx, mid); err != nil {
+				t.Fatal(err)
+			}
+			if err := root.AddNodeLink(fmt.Sprintf("%d", i), mid); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := counting.Add(ctx, root); err != nil {
+			t.Fatal(err)
+		}
+		return root
+	}
+
+	from := makeTree("leaf-99-99")
+	to := makeTree("leaf-99-99-v2")
+
+	if err := p.Pin(ctx, from, true); err != nil {
+		t.Fatal(err)
+	}
+
+	counting.gets = 0
+	if err := p.Update(ctx, from.Cid(), to.Cid(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the two roots, the changed branch and the changed leaf should
+	// have been fetched, not the ~10k shared nodes
+	if counting.gets > 20 {
+		t.Errorf("update fetched %d nodes, expected the walk to be proportional to the diff", counting.gets)
+	}
+
+	assertPinned(t, p, to.Cid(), "to should be pinned after update")
+	assertUnpinned(t, p, from.Cid(), "from should no longer be pinned")
+}